@@ -1,38 +1,295 @@
+-- мероприятия (хакатоны); одновременно активным может быть только одно
+CREATE TABLE IF NOT EXISTS events (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    starts_on DATE NOT NULL,
+    ends_on DATE NOT NULL,
+    venue TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'draft'
+        CHECK (status IN ('draft', 'active', 'finished')),
+    CHECK (ends_on >= starts_on)
+);
+
+CREATE UNIQUE INDEX idx_events_active ON events(status) WHERE status = 'active';
+
 CREATE TABLE IF NOT EXISTS games (
     id SERIAL PRIMARY KEY,
+    -- NULL — стенд вне мероприятий
+    event_id INT REFERENCES events(id) ON DELETE SET NULL,
     name TEXT NOT NULL,
     description TEXT,
     max_slots INT NOT NULL CHECK (max_slots > 0),
-    duration_seconds INT NOT NULL DEFAULT 600 CHECK (duration_seconds > 0)
+    duration_seconds INT NOT NULL DEFAULT 600 CHECK (duration_seconds > 0),
+    -- неявка: сколько ждать подтверждения, на сколько мест отодвигать и сколько раз
+    checkin_grace_seconds INT NOT NULL DEFAULT 120 CHECK (checkin_grace_seconds > 0),
+    noshow_reinsert_places INT NOT NULL DEFAULT 3 CHECK (noshow_reinsert_places >= 0),
+    noshow_max_skips INT NOT NULL DEFAULT 1 CHECK (noshow_max_skips >= 0),
+    -- номера талонов для гостей без аккаунта: VR-001, VR-002, ...
+    ticket_prefix VARCHAR(8) NOT NULL DEFAULT 'T',
+    ticket_seq INT NOT NULL DEFAULT 0,
+    -- приоритетная линия: не больше одного приоритетного игрока на priority_every обычных
+    priority_every INT NOT NULL DEFAULT 0 CHECK (priority_every >= 0),
+    regular_since_priority INT NOT NULL DEFAULT 0,
+    -- кого вызывать следующим: fifo, priority, lottery, round_robin
+    scheduling_policy VARCHAR(20) NOT NULL DEFAULT 'fifo'
+        CHECK (scheduling_policy IN ('fifo', 'priority', 'lottery', 'round_robin')),
+    priority_boost INT NOT NULL DEFAULT 0 CHECK (priority_boost >= 0),
+    lottery_cutoff TIMESTAMPTZ,
+    lottery_seed BIGINT NOT NULL DEFAULT 0,
+    -- часы работы стенда задаются в этом часовом поясе; paused закрывает запись вручную
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    -- сколько бронирований принимает один временной слот; 0 — бронирование выключено
+    reservation_capacity INT NOT NULL DEFAULT 0 CHECK (reservation_capacity >= 0),
+    -- как складываются попытки в таблице лидеров: лучшая или сумма
+    scoring_mode VARCHAR(10) NOT NULL DEFAULT 'best'
+        CHECK (scoring_mode IN ('best', 'sum')),
+    -- импорт находит игру по мероприятию и названию; вне мероприятий тоже без повторов
+    CONSTRAINT games_event_name UNIQUE NULLS NOT DISTINCT (event_id, name)
 );
 
+-- окна работы стенда; weekday 0 = воскресенье, closes <= opens — окно через полночь
+CREATE TABLE IF NOT EXISTS game_hours (
+    id SERIAL PRIMARY KEY,
+    game_id INT NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+    weekday SMALLINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    opens TIME NOT NULL,
+    closes TIME NOT NULL,
+    CHECK (opens <> closes)
+);
+
+CREATE INDEX idx_game_hours ON game_hours(game_id, weekday);
+
 CREATE TABLE IF NOT EXISTS users (
     id SERIAL PRIMARY KEY,
     login TEXT UNIQUE NOT NULL,
     password_hash TEXT NOT NULL,
     role VARCHAR(20) NOT NULL DEFAULT 'user'
-        CHECK (role IN ('user', 'admin'))
+        CHECK (role IN ('user', 'operator', 'admin')),
+    -- уровень приоритета по умолчанию (спонсоры, персонал)
+    priority INT NOT NULL DEFAULT 0 CHECK (priority >= 0),
+    -- группа для поочерёдного вызова (round_robin)
+    user_group VARCHAR(50) NOT NULL DEFAULT ''
 );
 
-CREATE TABLE IF NOT EXISTS queue (
+-- участники мероприятий; role даёт права оператора или админа только на стендах мероприятия
+CREATE TABLE IF NOT EXISTS event_members (
+    event_id INT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL DEFAULT 'user'
+        CHECK (role IN ('user', 'operator', 'admin')),
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (event_id, user_id)
+);
+
+CREATE INDEX idx_event_members_user ON event_members(user_id);
+
+CREATE TABLE IF NOT EXISTS parties (
     id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    code TEXT UNIQUE NOT NULL,
+    leader_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS party_members (
+    party_id INT NOT NULL REFERENCES parties(id) ON DELETE CASCADE,
     user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    accepted BOOLEAN NOT NULL DEFAULT FALSE,
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (party_id, user_id)
+);
+
+CREATE INDEX idx_party_members_user ON party_members(user_id);
+
+-- отдельные места одной игры (например, VR-шлемы)
+CREATE TABLE IF NOT EXISTS stations (
+    id SERIAL PRIMARY KEY,
     game_id INT NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    UNIQUE (game_id, name)
+);
+
+-- время записей хранится с часовым поясом: аналитика сравнивает его с
+-- границами в UTC и не зависит от TimeZone сервера;
+-- записи — это история, поэтому игру или пользователя с записями удалить нельзя
+CREATE TABLE IF NOT EXISTS queue (
+    id SERIAL PRIMARY KEY,
+    user_id INT REFERENCES users(id) ON DELETE RESTRICT,
+    game_id INT NOT NULL REFERENCES games(id) ON DELETE RESTRICT,
+    -- группа занимает столько слотов, сколько в ней участников
+    party_id INT REFERENCES parties(id) ON DELETE SET NULL,
+    slots INT NOT NULL DEFAULT 1 CHECK (slots > 0),
+    priority INT NOT NULL DEFAULT 0 CHECK (priority >= 0),
     position INT NOT NULL CHECK (position >= 0),
-    joined_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    called_at TIMESTAMPTZ,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    left_at TIMESTAMPTZ,
+    skip_count INT NOT NULL DEFAULT 0,
+    -- место, к которому вызван игрок
+    station_id INT REFERENCES stations(id) ON DELETE SET NULL,
+    -- результат сессии, вносится оператором при завершении
+    score INT,
+    result TEXT NOT NULL DEFAULT '',
     status VARCHAR(20) NOT NULL DEFAULT 'waiting'
-        CHECK (status IN ('waiting', 'active', 'skipped', 'finished'))
+        CHECK (status IN ('waiting', 'called', 'active', 'skipped', 'finished', 'left')),
+    -- гость без аккаунта
+    ticket TEXT,
+    claim_code TEXT,
+    nickname TEXT,
+    phone TEXT,
+    CHECK (user_id IS NOT NULL OR ticket IS NOT NULL)
+);
+
+CREATE UNIQUE INDEX idx_queue_ticket ON queue(game_id, ticket) WHERE ticket IS NOT NULL;
+CREATE INDEX idx_queue_station ON queue(station_id) WHERE status IN ('called', 'active');
+CREATE INDEX idx_queue_scores ON queue(game_id) WHERE score IS NOT NULL;
+
+-- кто был в группе, когда её вызвали: по этому списку участники видят
+-- сессию в истории и оценивают её, даже если потом покинули группу
+CREATE TABLE IF NOT EXISTS entry_members (
+    entry_id INT NOT NULL REFERENCES queue(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (entry_id, user_id)
 );
 
+CREATE INDEX idx_entry_members_user ON entry_members(user_id);
+
+-- история очереди: каждое изменение записи пишется в той же транзакции;
+-- удаление стенда или записи не стирает историю, ссылка становится NULL
+CREATE TABLE IF NOT EXISTS queue_events (
+    id BIGSERIAL PRIMARY KEY,
+    game_id INT REFERENCES games(id) ON DELETE SET NULL,
+    entry_id INT REFERENCES queue(id) ON DELETE SET NULL,
+    user_id INT REFERENCES users(id) ON DELETE SET NULL,
+    type VARCHAR(20) NOT NULL,
+    actor_id INT REFERENCES users(id) ON DELETE SET NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_queue_events_game ON queue_events(game_id, created_at, id);
+CREATE INDEX idx_queue_events_entry ON queue_events(entry_id);
+
+-- бронирование временных слотов; в начале слота игрок вызывается раньше живой очереди
+CREATE TABLE IF NOT EXISTS reservations (
+    id SERIAL PRIMARY KEY,
+    game_id INT REFERENCES games(id) ON DELETE SET NULL,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    slot_start TIMESTAMPTZ NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'booked'
+        CHECK (status IN ('booked', 'used', 'cancelled', 'expired')),
+    entry_id INT REFERENCES queue(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_reservations_slot ON reservations(game_id, slot_start) WHERE status <> 'cancelled';
+CREATE INDEX idx_reservations_user ON reservations(user_id, slot_start);
+
+-- оценки игр после завершённой сессии; участник группы оценивает сессию сам
+CREATE TABLE IF NOT EXISTS ratings (
+    id SERIAL PRIMARY KEY,
+    game_id INT REFERENCES games(id) ON DELETE SET NULL,
+    entry_id INT REFERENCES queue(id) ON DELETE SET NULL,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    stars INT NOT NULL CHECK (stars BETWEEN 1 AND 5),
+    comment TEXT NOT NULL DEFAULT '',
+    -- скрытый модератором комментарий; оценка остаётся в среднем
+    hidden BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (entry_id, user_id)
+);
+
+CREATE INDEX idx_ratings_game ON ratings(game_id, created_at, id);
+
+-- блокировки: без игры — на всё мероприятие, без срока — бессрочно;
+-- created_by пуст у автоматических блокировок за частые выходы из очереди
+CREATE TABLE IF NOT EXISTS bans (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    game_id INT REFERENCES games(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    expires_at TIMESTAMPTZ,
+    created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    lifted_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_bans_user ON bans(user_id) WHERE lifted_at IS NULL;
+CREATE INDEX idx_queue_events_user ON queue_events(user_id, game_id, created_at) WHERE type = 'left';
+
+-- корзины ограничителя частоты запросов, общие для нескольких экземпляров сервера;
+-- корзина удаляется, когда за idle_seconds без запросов она наполнилась бы целиком
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limits (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    idle_seconds DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- журнал действий администраторов и операторов
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    -- без внешних ключей: запись переживает удаление пользователя или игры
+    actor_id INT,
+    action TEXT NOT NULL,
+    game_id INT,
+    target_type TEXT NOT NULL,
+    target_id INT NOT NULL,
+    before JSONB,
+    after JSONB,
+    -- request_id выдаёт сервер; X-Request-ID клиента только сохраняется рядом
+    request_id TEXT,
+    client_request_id TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_audit_game ON audit_log(game_id, created_at);
+CREATE INDEX idx_audit_actor ON audit_log(actor_id, created_at);
+CREATE INDEX idx_audit_created ON audit_log(created_at, id);
+CREATE INDEX idx_audit_request ON audit_log(request_id);
+
+-- журнал только дополняется: изменение и удаление записей запрещены
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_change
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
 CREATE INDEX idx_queue_game ON queue(game_id, position);
 CREATE INDEX idx_queue_user ON queue(user_id);
+CREATE INDEX idx_queue_called ON queue(called_at) WHERE status = 'called';
+
+-- keyset-пагинация и фильтры списков
+CREATE INDEX idx_queue_game_status ON queue(game_id, status, position, id);
+CREATE INDEX idx_queue_game_joined ON queue(game_id, joined_at, id);
+CREATE INDEX idx_queue_user_joined ON queue(user_id, joined_at, id);
+-- аналитика по всем играм за период
+CREATE INDEX idx_queue_joined ON queue(joined_at);
+CREATE INDEX idx_games_name ON games(name, id);
+CREATE INDEX idx_games_event ON games(event_id);
+CREATE INDEX idx_games_name_trgm ON games USING gin (name gin_trgm_ops);
+CREATE INDEX idx_users_login_trgm ON users USING gin (login gin_trgm_ops);
 
 -- === Игры ===
-INSERT INTO games (name, description, max_slots, duration_seconds)
+INSERT INTO games (name, description, max_slots, duration_seconds, ticket_prefix)
 VALUES
-    ('Code Challenge', 'Мини-задачи по программированию', 3, 600),
-    ('VR Racing', 'Гонки в VR-шлемах', 2, 300),
-    ('Quiz Battle', 'Интерактивная викторина', 4, 420);
+    ('Code Challenge', 'Мини-задачи по программированию', 3, 600, 'CC'),
+    ('VR Racing', 'Гонки в VR-шлемах', 2, 300, 'VR'),
+    ('Quiz Battle', 'Интерактивная викторина', 4, 420, 'QB');
 
 -- === Пользователи ===
 INSERT INTO users (login, password_hash, role)
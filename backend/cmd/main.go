@@ -1,21 +1,28 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"fmt"
 	"log"
+	"maps"
 	"net/http"
 	"os"
 	"strconv"
 	"time"
+	_ "time/tzdata" // opening hours are kept in IANA time zones
 
+	"github.com/DexScen/Queue/backend/internal/ratelimit"
 	psql "github.com/DexScen/Queue/backend/internal/repository/psql"
 	"github.com/DexScen/Queue/backend/internal/service"
 	"github.com/DexScen/Queue/backend/internal/transport/rest"
 	"github.com/DexScen/Queue/backend/pkg/database"
 )
 
-func main() {
+func connect() (*sql.DB, error) {
 	port, _ := strconv.Atoi(os.Getenv("DB_PORT"))
-	db, err := database.NewPostgresConnection(database.ConnectionInfo{
+	return database.NewPostgresConnection(database.ConnectionInfo{
 		Host:     os.Getenv("DB_HOST"),
 		Port:     port,
 		Username: os.Getenv("DB_USER"),
@@ -23,19 +30,91 @@ func main() {
 		Password: os.Getenv("DB_PASSWORD"),
 		SSLMode:  "disable",
 	})
+}
+
+// rateLimiter configures request throttling from the environment:
+// RATE_LIMITS overrides limits per route group ("auth=10/1m,join=20/1m"),
+// RATE_LIMIT_STORE=postgres shares the buckets between instances and
+// TRUSTED_PROXIES lists the proxies whose X-Forwarded-For is believed.
+func rateLimiter(db *sql.DB) (*rest.RateLimiter, error) {
+	limits := maps.Clone(rest.DefaultRateLimits)
+	overrides, err := ratelimit.ParseLimits(os.Getenv("RATE_LIMITS"))
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(limits, overrides)
 
+	trusted, err := rest.ParseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		return nil, err
+	}
+
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	switch os.Getenv("RATE_LIMIT_STORE") {
+	case "", "memory":
+	case "postgres":
+		store = psql.NewRateLimits(db)
+	default:
+		return nil, fmt.Errorf("RATE_LIMIT_STORE must be memory or postgres")
+	}
+
+	return rest.NewRateLimiter(store, limits, trusted), nil
+}
+
+// secret reads a signing key from the environment, falling back to a
+// random one that lasts until the process exits.
+func secret(name, what string) ([]byte, error) {
+	key := []byte(os.Getenv(name))
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		log.Printf("%s is not set, %s will not survive a restart", name, what)
+	}
+	return key, nil
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		os.Exit(runImport(os.Args[2:]))
+	}
+
+	db, err := connect()
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer db.Close()
 
 	queuesRepo := psql.NewQueues(db)
-	queuesService := service.NewQueues(queuesRepo)
+	checkinKey, err := secret("CHECKIN_SECRET", "check-in codes")
+	if err != nil {
+		log.Fatal(err)
+	}
+	sessionKey, err := secret("SESSION_SECRET", "sessions")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	queuesService := service.NewQueues(queuesRepo, checkinKey, sessionKey)
+	go queuesService.RunNoShowSweeper(context.Background(), 5*time.Second)
+	go queuesService.RunReservationSweeper(context.Background(), time.Minute)
 	handler := rest.NewQueues(queuesService)
 
+	limiter, err := rateLimiter(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	handler.UseRateLimiter(limiter)
+
+	router, err := handler.InitRouter()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	srv := &http.Server{
 		Addr:    ":8080",
-		Handler: handler.InitRouter(),
+		Handler: router,
 	}
 
 	log.Println("Server started at:", time.Now().Format(time.RFC3339))
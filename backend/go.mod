@@ -6,5 +6,7 @@ require github.com/lib/pq v1.10.9
 
 require (
 	github.com/gorilla/mux v1.8.1
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	golang.org/x/crypto v0.42.0
+	golang.org/x/sync v0.17.0
 )
@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+const (
+	BucketHour = "hour"
+	BucketDay  = "day"
+)
+
+// AnalyticsRange selects the entries that joined in [From, To), of the
+// games of EventID unless it is 0. An empty Bucket aggregates the whole
+// range into one row per game; hour and day buckets start in UTC.
+type AnalyticsRange struct {
+	From    time.Time
+	To      time.Time
+	Bucket  string
+	EventID int
+}
+
+// GameStats aggregates the entries of one game that joined in a bucket.
+// Wait is the time from joining to the first call. Abandoned entries left
+// before they were ever called; no-shows were skipped at least once.
+// BusySeconds sums played session time weighted by slots, attributed to
+// the bucket the entry joined in, and Utilisation relates it to max_slots
+// over the bucket length.
+type GameStats struct {
+	GameID          int        `json:"game_id"`
+	Name            string     `json:"name"`
+	Bucket          *time.Time `json:"bucket,omitempty"`
+	MaxSlots        int        `json:"max_slots"`
+	Joined          int        `json:"joined"`
+	Called          int        `json:"called"`
+	Served          int        `json:"served"`
+	Abandoned       int        `json:"abandoned"`
+	NoShows         int        `json:"no_shows"`
+	AvgWaitSeconds  float64    `json:"avg_wait_seconds"`
+	P50WaitSeconds  float64    `json:"p50_wait_seconds"`
+	P90WaitSeconds  float64    `json:"p90_wait_seconds"`
+	BusySeconds     float64    `json:"busy_seconds"`
+	AbandonmentRate float64    `json:"abandonment_rate"`
+	NoShowRate      float64    `json:"no_show_rate"`
+	Utilisation     float64    `json:"utilisation"`
+}
+
+type ListGameStats []GameStats
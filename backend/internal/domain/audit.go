@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditRecord is one privileged action. Before and After hold the fields
+// the action changed; either is null when there was nothing before (a
+// creation) or nothing after. RequestID is the id the server gave the
+// request; ClientRequestID is the X-Request-ID the client sent, if any.
+type AuditRecord struct {
+	ID              int             `json:"id"`
+	ActorID         int             `json:"actor_id,omitempty"`
+	ActorLogin      string          `json:"actor_login,omitempty"`
+	Action          string          `json:"action"`
+	GameID          int             `json:"game_id,omitempty"`
+	TargetType      string          `json:"target_type"`
+	TargetID        int             `json:"target_id"`
+	Before          json.RawMessage `json:"before"`
+	After           json.RawMessage `json:"after"`
+	RequestID       string          `json:"request_id,omitempty"`
+	ClientRequestID string          `json:"client_request_id,omitempty"`
+	At              time.Time       `json:"at"`
+}
+
+type ListAuditRecords []AuditRecord
+
+// AuditFilter narrows the audit log; zero fields match everything.
+// Action matches as a prefix, so "queue." selects every queue action.
+type AuditFilter struct {
+	Actor           string
+	Action          string
+	GameID          int
+	TargetType      string
+	TargetID        int
+	RequestID       string
+	ClientRequestID string
+	EventID         int // the event and its games; 0 for all
+	From            *time.Time
+	To              *time.Time
+}
@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// Ban keeps a user out of every game, or of one game when GameID is set,
+// until ExpiresAt, or for good when it is nil. A ban created by nobody
+// is an automatic rejoin cooldown.
+type Ban struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	Login     string     `json:"login"`
+	GameID    int        `json:"game_id,omitempty"`
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedBy string     `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type ListBans []Ban
+
+// BanInfo is an admin's request to ban the user with the given login.
+type BanInfo struct {
+	Login     string     `json:"login"`
+	GameID    int        `json:"game_id,omitempty"`
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
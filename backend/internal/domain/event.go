@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+const (
+	EventDraft    = "draft"
+	EventActive   = "active"
+	EventFinished = "finished"
+)
+
+// Event is one hackathon the deployment hosts. Dates are YYYY-MM-DD. At
+// most one event is active; lists are scoped to it unless the client
+// picks another one.
+type Event struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	StartsOn string `json:"starts_on"`
+	EndsOn   string `json:"ends_on"`
+	Venue    string `json:"venue"`
+	Status   string `json:"status"`
+}
+
+type ListEvents []Event
+
+// EventMember is a user taking part in an event. A staff role here grants
+// operator or admin rights on the event's games only.
+type EventMember struct {
+	UserID   int       `json:"user_id"`
+	Login    string    `json:"login"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type ListEventMembers []EventMember
+
+// EventAssign moves a game to an event; 0 detaches it.
+type EventAssign struct {
+	EventID int `json:"event_id"`
+}
@@ -0,0 +1,10 @@
+package domain
+
+// UserRecord is a user as exported: everything but the password hash.
+type UserRecord struct {
+	ID       int    `json:"id"`
+	Login    string `json:"login"`
+	Role     string `json:"role"`
+	Priority int    `json:"priority"`
+	Group    string `json:"group,omitempty"`
+}
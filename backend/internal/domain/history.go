@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// HistoryEntry is one visit of a user to a game's queue, kept after the
+// entry has left play.
+type HistoryEntry struct {
+	EntryID    int        `json:"entry_id"`
+	GameID     int        `json:"game_id"`
+	GameName   string     `json:"game_name"`
+	Status     string     `json:"status"`
+	PartyID    int        `json:"party_id,omitempty"`
+	JoinedAt   time.Time  `json:"joined_at"`
+	CalledAt   *time.Time `json:"called_at"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	LeftAt     *time.Time `json:"left_at"`
+	Score      *int       `json:"score,omitempty"`
+	Result     string     `json:"result,omitempty"`
+}
+
+type ListHistoryEntries []HistoryEntry
+
+// QueueEvent is a single change to a queue entry, written in the same
+// transaction as the change itself. ActorID is set when staff made it.
+type QueueEvent struct {
+	ID      int             `json:"id"`
+	GameID  int             `json:"game_id"`
+	EntryID int             `json:"entry_id"`
+	UserID  int             `json:"user_id,omitempty"`
+	Login   string          `json:"login,omitempty"`
+	Type    string          `json:"type"`
+	ActorID int             `json:"actor_id,omitempty"`
+	Details json.RawMessage `json:"details,omitempty"`
+	At      time.Time       `json:"at"`
+}
+
+type ListQueueEvents []QueueEvent
@@ -0,0 +1,52 @@
+package domain
+
+import "time"
+
+// HoursWindow is one staffed period of a stand on a weekday (0 = Sunday).
+// Opens and Closes are wall-clock times "15:04" in the game's timezone;
+// a window that closes at or before it opens runs past midnight.
+type HoursWindow struct {
+	Weekday int    `json:"weekday"`
+	Opens   string `json:"opens"`
+	Closes  string `json:"closes"`
+}
+
+// Hours is a game's opening schedule. A game without windows is always
+// open. Paused is the operators' manual override and closes joining
+// regardless of the windows.
+type Hours struct {
+	Timezone string        `json:"timezone"`
+	Windows  []HoursWindow `json:"windows"`
+	Paused   bool          `json:"paused"`
+}
+
+const (
+	ClosedPaused   = "paused"
+	ClosedHours    = "outside_hours"
+	ClosedLastCall = "last_call"
+)
+
+// HoursStatus tells whether a game accepts new players right now and, if
+// not, why (one of the Closed* reasons) and when it opens next.
+type HoursStatus struct {
+	Hours
+	Open     bool       `json:"open"`
+	Reason   string     `json:"reason,omitempty"`
+	OpensAt  *time.Time `json:"opens_at,omitempty"`
+	ClosesAt *time.Time `json:"closes_at,omitempty"`
+}
+
+// OpenState is what the hours check of a join sees, loaded inside the join
+// transaction. Waiting and Busy are the slots taken by the line the player
+// joins at At: now for a walk-in, the slot start for a reservation, which
+// goes ahead of everyone waiting.
+type OpenState struct {
+	Hours   Hours
+	Game    Game
+	Waiting int
+	Busy    int
+	At      time.Time
+}
+
+// OpenFunc refuses a join while the game is closed.
+type OpenFunc func(state OpenState) error
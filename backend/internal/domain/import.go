@@ -0,0 +1,42 @@
+package domain
+
+// GameImport is one row of a game import; games are matched by event and
+// name, a zero event standing for a game outside any event. A zero
+// duration keeps the current one, or the default for a new game.
+type GameImport struct {
+	EventID         int    `json:"event_id"`
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	MaxSlots        int    `json:"max_slots"`
+	DurationSeconds int    `json:"duration_seconds"`
+}
+
+// UserImport is one row of a user import; users are matched by login.
+// The password is required for a new user and optional for an existing
+// one, whose password is kept when it is left empty; so is an empty role.
+// Priority and group are always overwritten.
+type UserImport struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+	Priority int    `json:"priority"`
+	Group    string `json:"group"`
+}
+
+// ImportError points at a rejected row, counted from 1 after the header.
+type ImportError struct {
+	Row   int    `json:"row"`
+	Field string `json:"field,omitempty"`
+	Error string `json:"error"`
+}
+
+// ImportResult reports an import. Nothing is written when Errors is not
+// empty or when the import was a dry run; the counts then tell what the
+// import would have done.
+type ImportResult struct {
+	DryRun  bool          `json:"dry_run"`
+	Applied bool          `json:"applied"`
+	Created int           `json:"created"`
+	Updated int           `json:"updated"`
+	Errors  []ImportError `json:"errors"`
+}
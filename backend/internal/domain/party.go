@@ -0,0 +1,33 @@
+package domain
+
+// Party is a group of users that queues for a game as a single entry.
+type Party struct {
+	ID       int           `json:"id"`
+	Name     string        `json:"name"`
+	Code     string        `json:"code"`
+	LeaderID int           `json:"leader_id"`
+	Leader   string        `json:"leader"`
+	Members  []PartyMember `json:"members"`
+}
+
+type PartyMember struct {
+	UserID   int    `json:"user_id"`
+	Login    string `json:"login"`
+	Accepted bool   `json:"accepted"`
+}
+
+type PartyInfo struct {
+	Name string `json:"name"`
+}
+
+type PartyInvite struct {
+	Login string `json:"login"`
+}
+
+type PartyCode struct {
+	Code string `json:"code"`
+}
+
+type PartyQueueInfo struct {
+	PartyID int `json:"party_id"`
+}
@@ -1,12 +1,17 @@
 package domain
 
+import "time"
+
 type Game struct {
-	ID               int    `json:"id"`
-	Name             string `json:"name"`
-	Description      string `json:"description"`
-	Max_slots        int    `json:"max_slots"`
-	Current_people   int    `json:"current_people"`
-	Duration_seconds int    `json:"duration_seconds"`
+	ID               int      `json:"id"`
+	EventID          int      `json:"event_id,omitempty"`
+	Name             string   `json:"name"`
+	Description      string   `json:"description"`
+	Max_slots        int      `json:"max_slots"`
+	Current_people   int      `json:"current_people"`
+	Duration_seconds int      `json:"duration_seconds"`
+	Rating           *float64 `json:"rating"`
+	RatingCount      int      `json:"rating_count"`
 }
 
 type ListGames []Game
@@ -19,26 +24,77 @@ type User struct {
 }
 
 type GameInfo struct {
-	ID               int    `json:"id"`
-	Name             string `json:"name"`
-	Description      string `json:"description"`
-	Max_slots        int    `json:"max_slots"`
-	Current_people   int    `json:"current_people"`
-	Duration_seconds int    `json:"duration_seconds"`
-	Position int `json:"position"`
+	ID               int       `json:"id"`
+	Name             string    `json:"name"`
+	Description      string    `json:"description"`
+	Max_slots        int       `json:"max_slots"`
+	Current_people   int       `json:"current_people"`
+	Duration_seconds int       `json:"duration_seconds"`
+	Position         int       `json:"position"`
+	Status           string    `json:"status"`
+	JoinedAt         time.Time `json:"joined_at"`
+	Station          string    `json:"station,omitempty"`
 }
 
 type ListGameInfos []GameInfo
 
 type ListUsers []User
 
+const (
+	StatusWaiting  = "waiting"
+	StatusCalled   = "called"
+	StatusActive   = "active"
+	StatusSkipped  = "skipped"
+	StatusFinished = "finished"
+	StatusLeft     = "left"
+)
+
+// QueueEntry is a single place in a game's queue as operators see it.
+// Walk-in entries carry a Ticket instead of a user.
+// EstimatedStart is only set for entries that are still waiting. Under
+// any scheduling policy but fifo players may be called out of position
+// order, so Estimated marks both Position and EstimatedStart as guesses.
+type QueueEntry struct {
+	ID             int        `json:"id"`
+	GameID         int        `json:"game_id"`
+	UserID         int        `json:"user_id,omitempty"`
+	Login          string     `json:"login,omitempty"`
+	Ticket         string     `json:"ticket,omitempty"`
+	Nickname       string     `json:"nickname,omitempty"`
+	PartyID        int        `json:"party_id,omitempty"`
+	Slots          int        `json:"slots"`
+	Priority       int        `json:"priority"`
+	Group          string     `json:"group,omitempty"`
+	Station        string     `json:"station,omitempty"`
+	Position       int        `json:"position" doc:"place in the line; only an estimate of the call order when estimated is set"`
+	Status         string     `json:"status"`
+	JoinedAt       time.Time  `json:"joined_at"`
+	CalledAt       *time.Time `json:"called_at"`
+	StartedAt      *time.Time `json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at"`
+	LeftAt         *time.Time `json:"left_at"`
+	Score          *int       `json:"score,omitempty"`
+	Result         string     `json:"result,omitempty"`
+	EstimatedStart *time.Time `json:"estimated_start" doc:"expected start if players are called in position order"`
+	Estimated      bool       `json:"estimated,omitempty" doc:"set when the scheduling policy is not fifo, so position and estimated_start are estimates"`
+}
+
+type ListQueueEntries []QueueEntry
+
+const (
+	RoleUser     = "user"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
 type LoginInfo struct {
 	Login    string `json:"login"`
 	Password string `json:"password"`
 }
 
 type RoleInfo struct {
-	Role string `json:"role"`
+	Role  string `json:"role"`
+	Token string `json:"token,omitempty"`
 }
 
 type ChangeInfo struct {
@@ -50,6 +106,129 @@ type PosInfo struct {
 	Pos int `json:"position"`
 }
 
-type IdInfo struct{
+type IdInfo struct {
 	Id int `json:"id"`
-}
\ No newline at end of file
+}
+
+// ListFilter narrows and orders list queries. A zero Limit means unbounded.
+type ListFilter struct {
+	Limit       int
+	Cursor      *Cursor
+	Sort        string
+	Desc        bool
+	Status      string
+	JoinedAfter *time.Time
+	Search      string
+	EventID     int // only games of this event; 0 for all
+}
+
+// Cursor points just past the last row of a page in the chosen sort order.
+type Cursor struct {
+	Sort string `json:"s"`
+	Key  string `json:"k"`
+	ID   int    `json:"i"`
+}
+
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type MoveInfo struct {
+	Position int `json:"position"`
+}
+
+type SwapInfo struct {
+	EntryID      int `json:"entry_id"`
+	OtherEntryID int `json:"other_entry_id"`
+}
+
+type InsertInfo struct {
+	Login    string `json:"login"`
+	Position int    `json:"position"`
+}
+
+// QueueUpdate is pushed to live subscribers of a game whenever its queue changes.
+type QueueUpdate struct {
+	GameID  int       `json:"game_id"`
+	Type    string    `json:"type"`
+	EntryID int       `json:"entry_id,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// NoShowPolicy controls what happens when a called player does not check
+// in at the stand within GraceSeconds.
+type NoShowPolicy struct {
+	GraceSeconds   int `json:"grace_seconds"`
+	ReinsertPlaces int `json:"reinsert_places"`
+	MaxSkips       int `json:"max_skips"`
+}
+
+// NoShow reports one expired call handled by the no-show sweep.
+type NoShow struct {
+	GameID        int
+	EntryID       int
+	Dropped       bool
+	CalledEntryID int
+}
+
+type CheckinInfo struct {
+	Token string `json:"token"`
+}
+
+// TicketInfo describes a walk-in visitor without an account.
+type TicketInfo struct {
+	Nickname string `json:"nickname"`
+	Phone    string `json:"phone"`
+}
+
+// Ticket is handed to a walk-in visitor. ClaimCode lets the visitor attach
+// the entry to an account after registering.
+type Ticket struct {
+	EntryID   int    `json:"entry_id"`
+	Number    string `json:"ticket"`
+	ClaimCode string `json:"claim_code"`
+	Position  int    `json:"position"`
+}
+
+type ClaimInfo struct {
+	Ticket    string `json:"ticket"`
+	ClaimCode string `json:"claim_code"`
+}
+
+// Board is the read model behind the stands wall display.
+type Board struct {
+	Games       []BoardGame `json:"games"`
+	GeneratedAt time.Time   `json:"generated_at"`
+}
+
+type BoardGame struct {
+	ID              int          `json:"id"`
+	EventID         int          `json:"event_id,omitempty"`
+	Name            string       `json:"name"`
+	MaxSlots        int          `json:"max_slots"`
+	DurationSeconds int          `json:"duration_seconds"`
+	QueueLength     int          `json:"queue_length"`
+	Playing         []BoardEntry `json:"playing"`
+	Next            []BoardEntry `json:"next"`
+}
+
+// BoardEntry shows a player on the public board: a masked login, or the
+// ticket number of a walk-in visitor.
+type BoardEntry struct {
+	Name             string `json:"name"`
+	Status           string `json:"status"`
+	Position         int    `json:"position,omitempty"`
+	Station          string `json:"station,omitempty"`
+	RemainingSeconds *int   `json:"remaining_seconds,omitempty"`
+}
+
+type PriorityInfo struct {
+	Priority int `json:"priority"`
+}
+
+// PriorityPolicy lets at most one priority player through for every Every
+// regular players; Every = 0 disables the priority lane of a game.
+type PriorityPolicy struct {
+	Every int `json:"every"`
+}
@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// RatingInfo rates a finished session. Without EntryID it rates the
+// caller's latest finished session of the game not rated yet.
+type RatingInfo struct {
+	EntryID int    `json:"entry_id,omitempty"`
+	Stars   int    `json:"stars"`
+	Comment string `json:"comment"`
+}
+
+type Rating struct {
+	ID        int       `json:"id"`
+	GameID    int       `json:"game_id"`
+	EntryID   int       `json:"entry_id"`
+	Login     string    `json:"login"`
+	Stars     int       `json:"stars"`
+	Comment   string    `json:"comment"`
+	Hidden    bool      `json:"hidden"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ListRatings []Rating
@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+const (
+	ReservationBooked    = "booked"
+	ReservationUsed      = "used"
+	ReservationCancelled = "cancelled"
+	ReservationExpired   = "expired"
+)
+
+// Reservation books a user into a time slot of a game. When the slot
+// starts, the next call-next of the game takes the reservation before the
+// walk-in queue; EntryID is the queue entry it then became. One whose slot
+// ends unused expires.
+type Reservation struct {
+	ID        int       `json:"id"`
+	GameID    int       `json:"game_id"`
+	UserID    int       `json:"user_id"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Status    string    `json:"status"`
+	EntryID   int       `json:"entry_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ListReservations []Reservation
+
+// ReservationSlot is a bookable interval cut from a game's opening hours
+// in steps of duration_seconds.
+type ReservationSlot struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Capacity int       `json:"capacity"`
+	Booked   int       `json:"booked"`
+}
+
+type ReservationInfo struct {
+	Start time.Time `json:"start"`
+}
+
+// ReservationPolicy sets how many reservations one slot takes; the rest
+// of the game's capacity stays with the walk-in queue. Capacity = 0 turns
+// reservations off.
+type ReservationPolicy struct {
+	Capacity int `json:"capacity"`
+}
@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+const (
+	ScheduleFIFO       = "fifo"
+	SchedulePriority   = "priority"
+	ScheduleLottery    = "lottery"
+	ScheduleRoundRobin = "round_robin"
+)
+
+// SchedulingPolicy selects how a game picks the next player to call.
+// PriorityBoost is how many places one priority level is worth under the
+// priority policy. LotteryCutoff and Seed configure the lottery policy:
+// only entries that joined before the cutoff take part in the draw, and
+// the draw is reproducible from the seed. The seed can be set but is never
+// read back, or anyone could predict the winners.
+type SchedulingPolicy struct {
+	Name          string     `json:"name"`
+	PriorityBoost int        `json:"priority_boost"`
+	LotteryCutoff *time.Time `json:"lottery_cutoff"`
+	Seed          int64      `json:"seed,omitempty"`
+}
+
+// ScheduleState is what a scheduler sees when the next player is called.
+// Waiting is ordered by position; LastGroup is the group of the entry that
+// was called most recently.
+type ScheduleState struct {
+	Policy    SchedulingPolicy
+	Waiting   ListQueueEntries
+	LastGroup string
+}
+
+// PickFunc returns the id of the waiting entry to call next, or 0.
+type PickFunc func(state ScheduleState) int
+
+type GroupInfo struct {
+	Group string `json:"group"`
+}
@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+const (
+	ScoringBest = "best"
+	ScoringSum  = "sum"
+)
+
+// ResultInfo is what an operator records when a session finishes. Score
+// is optional; games that are not scored leave it out.
+type ResultInfo struct {
+	Score  *int   `json:"score"`
+	Result string `json:"result"`
+}
+
+// ScoringPolicy decides how the attempts of a player add up on the
+// leaderboard: the best attempt counts, or all of them summed.
+type ScoringPolicy struct {
+	Mode string `json:"mode"`
+}
+
+// LeaderboardEntry is one player on a leaderboard. Players on equal
+// scores are ranked by AchievedAt, the earlier first: the time of their
+// best attempt, or of the attempt that completed their sum.
+type LeaderboardEntry struct {
+	Rank       int       `json:"rank"`
+	UserID     int       `json:"user_id,omitempty"`
+	Name       string    `json:"name"`
+	Score      int       `json:"score"`
+	Attempts   int       `json:"attempts"`
+	AchievedAt time.Time `json:"achieved_at"`
+}
+
+// Leaderboard ranks the players of a game, or over all the games of an
+// event for the overall board, where each game adds up its own way.
+type Leaderboard struct {
+	GameID      int                `json:"game_id,omitempty"`
+	EventID     int                `json:"event_id,omitempty"`
+	Mode        string             `json:"mode,omitempty"`
+	Entries     []LeaderboardEntry `json:"entries"`
+	GeneratedAt time.Time          `json:"generated_at"`
+}
@@ -0,0 +1,21 @@
+package domain
+
+// Station is one physical seat of a game, such as a single VR headset.
+// A called or active entry occupies one station; EntryID is 0 when the
+// station is free.
+type Station struct {
+	ID      int    `json:"id"`
+	GameID  int    `json:"game_id"`
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	EntryID int    `json:"entry_id,omitempty"`
+}
+
+type ListStations []Station
+
+// StationInfo creates or updates a station; a nil Enabled leaves the flag
+// as it is (new stations start enabled).
+type StationInfo struct {
+	Name    string `json:"name"`
+	Enabled *bool  `json:"enabled"`
+}
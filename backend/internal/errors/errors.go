@@ -1,10 +1,51 @@
 package errors
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 var (
-    ErrGameNotFound = errors.New("game not found")
-	ErrUserNotFound = errors.New("user not found")
-    ErrWrongPassword = errors.New("wrong password")
-	ErrUserExists = errors.New("user exists")
-)
\ No newline at end of file
+	ErrGameNotFound        = errors.New("game not found")
+	ErrGameExists          = errors.New("game exists")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrWrongPassword       = errors.New("wrong password")
+	ErrUserExists          = errors.New("user exists")
+	ErrUnauthorized        = errors.New("unauthorized")
+	ErrInvalidParams       = errors.New("invalid parameters")
+	ErrForbidden           = errors.New("forbidden")
+	ErrEntryNotFound       = errors.New("queue entry not found")
+	ErrNothingToCall       = errors.New("no free slot or nobody waiting")
+	ErrInvalidToken        = errors.New("invalid check-in token")
+	ErrTokenExpired        = errors.New("check-in token expired")
+	ErrWrongGame           = errors.New("entry belongs to another game")
+	ErrNotCalled           = errors.New("entry has not been called")
+	ErrAlreadyInQueue      = errors.New("already in the queue")
+	ErrPartyNotFound       = errors.New("party not found")
+	ErrPartyTooLarge       = errors.New("party does not fit the game")
+	ErrPartyInPlay         = errors.New("party is called or playing")
+	ErrStationNotFound     = errors.New("station not found")
+	ErrStationBusy         = errors.New("station is disabled or occupied")
+	ErrStationExists       = errors.New("station exists")
+	ErrQueueClosed         = errors.New("queue is closed")
+	ErrReservationNotFound = errors.New("reservation not found")
+	ErrSlotFull            = errors.New("time slot is fully booked")
+	ErrAlreadyBooked       = errors.New("already holds a reservation")
+	ErrEventNotFound       = errors.New("event not found")
+	ErrEventActive         = errors.New("another event is active")
+	ErrNotFinished         = errors.New("entry has not finished")
+	ErrRatingNotFound      = errors.New("rating not found")
+	ErrAlreadyRated        = errors.New("session already rated")
+	ErrBanned              = errors.New("user is banned")
+	ErrBanNotFound         = errors.New("ban not found")
+)
+
+// Banned wraps ErrBanned with the reason of the ban and, unless it is
+// for good, when it ends.
+func Banned(reason string, expiresAt *time.Time) error {
+	if expiresAt != nil {
+		return fmt.Errorf("%w until %s: %s", ErrBanned, expiresAt.Format(time.RFC3339), reason)
+	}
+	return fmt.Errorf("%w: %s", ErrBanned, reason)
+}
@@ -0,0 +1,133 @@
+// Package importer decodes the CSV and JSON files accepted by the bulk
+// import, shared by the admin endpoints and the command line.
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// FormatOf guesses the format of a file from its name.
+func FormatOf(name string) string {
+	if strings.EqualFold(filepath.Ext(name), ".json") {
+		return FormatJSON
+	}
+	return FormatCSV
+}
+
+// Decode reads the rows of r. JSON is an array of objects; CSV has a
+// header naming the JSON fields of T, in any order. A value that does
+// not fit its field is reported as a row error and the row is still
+// returned with that field left zero, so that all problems of a file show
+// up at once; a row with the wrong number of columns is returned empty.
+// Either way row n of the errors is rows[n-1]. The error is for a file
+// that cannot be read at all.
+func Decode[T any](r io.Reader, format string) ([]T, []domain.ImportError, error) {
+	switch format {
+	case FormatJSON:
+		var rows []T
+		if err := json.NewDecoder(r).Decode(&rows); err != nil {
+			return nil, nil, fmt.Errorf("decode json: %w", err)
+		}
+		return rows, nil, nil
+	case FormatCSV:
+		return decodeCSV[T](r)
+	}
+	return nil, nil, fmt.Errorf("unknown format %q", format)
+}
+
+func decodeCSV[T any](r io.Reader) ([]T, []domain.ImportError, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("read csv header: %w", err)
+	}
+
+	fields := fieldsByName(reflect.TypeFor[T]())
+	columns := make([]int, len(header))
+	for i, name := range header {
+		idx, ok := fields[strings.TrimSpace(strings.ToLower(name))]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown csv column %q", name)
+		}
+		columns[i] = idx
+	}
+
+	var rows []T
+	var rowErrs []domain.ImportError
+	for n := 1; ; n++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && parseErr.Err == csv.ErrFieldCount {
+			rowErrs = append(rowErrs, domain.ImportError{Row: n, Error: "wrong number of columns"})
+			rows = append(rows, *new(T))
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("read csv row %d: %w", n, err)
+		}
+
+		var row T
+		v := reflect.ValueOf(&row).Elem()
+		for i, value := range record {
+			f := v.Field(columns[i])
+			if err := setField(f, value); err != nil {
+				rowErrs = append(rowErrs, domain.ImportError{Row: n, Field: header[i], Error: err.Error()})
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, rowErrs, nil
+}
+
+func fieldsByName(t reflect.Type) map[string]int {
+	fields := map[string]int{}
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	return fields
+}
+
+func setField(f reflect.Value, value string) error {
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(value)
+	case reflect.Int:
+		if value == "" {
+			return nil
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		f.SetInt(int64(n))
+	default:
+		return fmt.Errorf("unsupported field type %s", f.Kind())
+	}
+	return nil
+}
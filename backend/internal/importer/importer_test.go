@@ -0,0 +1,155 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+type testRow struct {
+	Name  string `json:"name"`
+	Slots int    `json:"slots"`
+	Skip  string `json:"-"`
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		input   string
+		rows    []testRow
+		rowErrs []domain.ImportError
+		failed  bool
+	}{
+		{
+			name:   "json",
+			format: FormatJSON,
+			input:  `[{"name": "VR", "slots": 2}, {"name": "Quiz"}]`,
+			rows:   []testRow{{Name: "VR", Slots: 2}, {Name: "Quiz"}},
+		},
+		{"json not an array", FormatJSON, `{"name": "VR"}`, nil, nil, true},
+		{"json wrong type", FormatJSON, `[{"slots": "two"}]`, nil, nil, true},
+		{
+			name:   "csv",
+			format: FormatCSV,
+			input:  "name,slots\nVR,2\n",
+			rows:   []testRow{{Name: "VR", Slots: 2}},
+		},
+		{"unknown format", "xml", "<games/>", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, rowErrs, err := Decode[testRow](strings.NewReader(tt.input), tt.format)
+			if tt.failed {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(rows, tt.rows) {
+				t.Fatalf("rows = %+v, want %+v", rows, tt.rows)
+			}
+			if !reflect.DeepEqual(rowErrs, tt.rowErrs) {
+				t.Fatalf("row errors = %+v, want %+v", rowErrs, tt.rowErrs)
+			}
+		})
+	}
+}
+
+func TestDecodeCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		rows    []testRow
+		rowErrs []domain.ImportError
+		failed  bool
+	}{
+		{"empty file", "", nil, nil, false},
+		{"header only", "name,slots\n", nil, nil, false},
+		{
+			name:  "columns in any order and case",
+			input: "Slots, NAME\n3,VR\n",
+			rows:  []testRow{{Name: "VR", Slots: 3}},
+		},
+		{
+			name:  "missing column stays zero",
+			input: "name\nVR\n",
+			rows:  []testRow{{Name: "VR"}},
+		},
+		{"unknown column", "name,colour\nVR,red\n", nil, nil, true},
+		{"ignored field is unknown", "name,Skip\nVR,x\n", nil, nil, true},
+		{
+			name:    "wrong field count",
+			input:   "name,slots\nVR\nQuiz,4\n",
+			rows:    []testRow{{}, {Name: "Quiz", Slots: 4}},
+			rowErrs: []domain.ImportError{{Row: 1, Error: "wrong number of columns"}},
+		},
+		{
+			name:    "non-numeric int",
+			input:   "name,slots\nVR,two\n",
+			rows:    []testRow{{Name: "VR"}},
+			rowErrs: []domain.ImportError{{Row: 1, Field: "slots", Error: `"two" is not a number`}},
+		},
+		{
+			name:  "duplicate rows are left to validation",
+			input: "name,slots\nVR,2\nVR,2\n",
+			rows:  []testRow{{Name: "VR", Slots: 2}, {Name: "VR", Slots: 2}},
+		},
+		{"broken quote", "name,slots\n\"VR,2\n", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, rowErrs, err := decodeCSV[testRow](strings.NewReader(tt.input))
+			if tt.failed {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(rows, tt.rows) {
+				t.Fatalf("rows = %+v, want %+v", rows, tt.rows)
+			}
+			if !reflect.DeepEqual(rowErrs, tt.rowErrs) {
+				t.Fatalf("row errors = %+v, want %+v", rowErrs, tt.rowErrs)
+			}
+		})
+	}
+}
+
+func TestSetField(t *testing.T) {
+	tests := []struct {
+		name   string
+		field  any
+		value  string
+		want   any
+		failed bool
+	}{
+		{"string", "", "VR", "VR", false},
+		{"int", 0, "42", 42, false},
+		{"int with spaces", 0, " 42 ", 42, false},
+		{"negative int", 0, "-1", -1, false},
+		{"empty int stays zero", 0, "", 0, false},
+		{"non-numeric int", 0, "4x", 0, true},
+		{"unsupported kind", false, "true", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := reflect.New(reflect.TypeOf(tt.field)).Elem()
+			err := setField(f, tt.value)
+			if (err != nil) != tt.failed {
+				t.Fatalf("err = %v, failed %v", err, tt.failed)
+			}
+			if got := f.Interface(); got != tt.want {
+				t.Fatalf("field = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	idle    time.Duration // time to refill completely
+}
+
+// MemoryStore keeps the buckets of a single instance in memory. Buckets
+// that have refilled completely are dropped once a minute.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, lastSweep: time.Now()}
+}
+
+func (s *MemoryStore) Allow(ctx context.Context, key string, limit Limit) (time.Duration, bool, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) > sweepInterval {
+		for k, b := range s.buckets {
+			if now.Sub(b.updated) > b.idle {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	b := s.buckets[key]
+	if b == nil {
+		b = &bucket{tokens: float64(limit.Requests), updated: now, idle: limit.Per}
+		s.buckets[key] = b
+	}
+
+	left, retryAfter, ok := Spend(b.tokens, now.Sub(b.updated).Seconds(), limit)
+	b.tokens, b.updated = left, now
+	return retryAfter, ok, nil
+}
@@ -0,0 +1,78 @@
+// Package ratelimit implements token buckets shared by the HTTP rate
+// limiting middleware and its stores.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit lets Requests requests through per Per, in bursts of up to
+// Requests. A zero Limit lets everything through.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+func (l Limit) String() string {
+	return strconv.Itoa(l.Requests) + "/" + l.Per.String()
+}
+
+// rate is the refill rate in tokens per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// Store keeps the buckets. Allow takes a token from the bucket of key,
+// or reports how long until one is available.
+type Store interface {
+	Allow(ctx context.Context, key string, limit Limit) (retryAfter time.Duration, ok bool, err error)
+}
+
+// Spend refills a bucket holding tokens after elapsed seconds and takes
+// one token from it. It returns the tokens left and, when the bucket is
+// empty, how long until the next token.
+func Spend(tokens, elapsed float64, limit Limit) (left float64, retryAfter time.Duration, ok bool) {
+	tokens = math.Min(float64(limit.Requests), tokens+math.Max(elapsed, 0)*limit.rate())
+	if tokens >= 1 {
+		return tokens - 1, 0, true
+	}
+	wait := (1 - tokens) / limit.rate()
+	return tokens, time.Duration(wait * float64(time.Second)), false
+}
+
+// ParseLimits reads limits per route group written as
+// "auth=10/1m,join=20/1m"; the burst is the request count.
+func ParseLimits(s string) (map[string]Limit, error) {
+	limits := map[string]Limit{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		group, spec, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("rate limit %q: want group=requests/period", part)
+		}
+		count, period, ok := strings.Cut(spec, "/")
+		if !ok {
+			return nil, fmt.Errorf("rate limit %q: want group=requests/period", part)
+		}
+
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("rate limit %q: bad request count", part)
+		}
+		per, err := time.ParseDuration(period)
+		if err != nil || per <= 0 {
+			return nil, fmt.Errorf("rate limit %q: bad period", part)
+		}
+		limits[strings.TrimSpace(group)] = Limit{Requests: n, Per: per}
+	}
+	return limits, nil
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSpend(t *testing.T) {
+	limit := Limit{Requests: 10, Per: 10 * time.Second} // a token a second
+
+	tests := []struct {
+		name       string
+		tokens     float64
+		elapsed    float64
+		left       float64
+		retryAfter time.Duration
+		ok         bool
+	}{
+		{"full bucket", 10, 0, 9, 0, true},
+		{"last token", 1, 0, 0, 0, true},
+		{"empty", 0, 0, 0, time.Second, false},
+		{"partly refilled", 0, 0.25, 0.25, 750 * time.Millisecond, false},
+		{"refilled", 0, 1, 0, 0, true},
+		{"refill stops at the burst", 5, 60, 9, 0, true},
+		{"clock going back refills nothing", 0.5, -5, 0.5, 500 * time.Millisecond, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			left, retryAfter, ok := Spend(tt.tokens, tt.elapsed, limit)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if diff := left - tt.left; diff > 1e-9 || diff < -1e-9 {
+				t.Fatalf("left = %v, want %v", left, tt.left)
+			}
+			if diff := retryAfter - tt.retryAfter; diff > time.Microsecond || diff < -time.Microsecond {
+				t.Fatalf("retry after %v, want %v", retryAfter, tt.retryAfter)
+			}
+		})
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want map[string]Limit
+		ok   bool
+	}{
+		{"empty", "", map[string]Limit{}, true},
+		{"two groups", "auth=10/1m, join=20/30s", map[string]Limit{
+			"auth": {Requests: 10, Per: time.Minute},
+			"join": {Requests: 20, Per: 30 * time.Second},
+		}, true},
+		{"zero turns a group off", "read=0/1m", map[string]Limit{"read": {Requests: 0, Per: time.Minute}}, true},
+		{"trailing comma", "auth=10/1m,", map[string]Limit{"auth": {Requests: 10, Per: time.Minute}}, true},
+		{"no group", "10/1m", nil, false},
+		{"no period", "auth=10", nil, false},
+		{"negative count", "auth=-1/1m", nil, false},
+		{"bad count", "auth=ten/1m", nil, false},
+		{"bad period", "auth=10/minute", nil, false},
+		{"zero period", "auth=10/0s", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLimits(tt.s)
+			if tt.ok != (err == nil) {
+				t.Fatalf("err = %v, want ok %v", err, tt.ok)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("limits = %v, want %v", got, tt.want)
+			}
+			for group, limit := range tt.want {
+				if got[group] != limit {
+					t.Fatalf("%s = %v, want %v", group, got[group], limit)
+				}
+			}
+		})
+	}
+}
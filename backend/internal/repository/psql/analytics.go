@@ -0,0 +1,93 @@
+package psql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+// GetGameStats aggregates the queue history of every game, or of one when
+// gameID is not 0, over the entries that joined within r. Rates are left
+// to the caller; the counts they derive from are filled in.
+func (q *Queues) GetGameStats(ctx context.Context, gameID int, r domain.AnalyticsRange) (domain.ListGameStats, error) {
+	// the bucket unit is one of the domain constants, never user text;
+	// buckets start on UTC hours and days whatever the server's zone is
+	bucket := "NULL::timestamptz"
+	if r.Bucket != "" {
+		bucket = fmt.Sprintf("date_trunc('%s', q.joined_at, 'UTC')", r.Bucket)
+	}
+
+	rows, err := q.db.QueryContext(ctx, `
+		WITH entries AS (
+			SELECT
+				q.game_id,
+				`+bucket+` AS bucket,
+				q.slots,
+				q.status,
+				q.started_at,
+				q.finished_at,
+				EXTRACT(EPOCH FROM c.first_called - q.joined_at) AS wait,
+				c.first_called IS NOT NULL AS called,
+				EXISTS (
+					SELECT 1 FROM queue_events e
+					WHERE e.entry_id = q.id AND e.type IN ('skipped', 'requeued')
+				) AS no_show
+			FROM queue q
+			LEFT JOIN LATERAL (
+				SELECT MIN(e.created_at) AS first_called
+				FROM queue_events e
+				WHERE e.entry_id = q.id AND e.type = 'called'
+			) c ON TRUE
+			WHERE q.joined_at >= $1 AND q.joined_at < $2
+			  AND ($3 = 0 OR q.game_id = $3)
+		)
+		SELECT
+			g.id,
+			g.name,
+			s.bucket,
+			g.max_slots,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE s.called),
+			COUNT(*) FILTER (WHERE s.finished_at IS NOT NULL),
+			COUNT(*) FILTER (WHERE s.status = 'left' AND NOT s.called),
+			COUNT(*) FILTER (WHERE s.no_show),
+			COALESCE(AVG(s.wait), 0),
+			COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY s.wait), 0),
+			COALESCE(percentile_cont(0.9) WITHIN GROUP (ORDER BY s.wait), 0),
+			COALESCE(SUM(EXTRACT(EPOCH FROM s.finished_at - s.started_at) * s.slots), 0)
+		FROM entries s
+		JOIN games g ON g.id = s.game_id
+		WHERE $4 = 0 OR g.event_id = $4
+		GROUP BY g.id, g.name, g.max_slots, s.bucket
+		ORDER BY g.id, s.bucket
+	`, r.From, r.To, gameID, r.EventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := domain.ListGameStats{}
+	for rows.Next() {
+		var s domain.GameStats
+		if err := rows.Scan(
+			&s.GameID,
+			&s.Name,
+			&s.Bucket,
+			&s.MaxSlots,
+			&s.Joined,
+			&s.Called,
+			&s.Served,
+			&s.Abandoned,
+			&s.NoShows,
+			&s.AvgWaitSeconds,
+			&s.P50WaitSeconds,
+			&s.P90WaitSeconds,
+			&s.BusySeconds,
+		); err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
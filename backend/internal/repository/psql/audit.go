@@ -0,0 +1,203 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/DexScen/Queue/backend/pkg/requestid"
+)
+
+// auditTarget names the object a privileged action changed.
+type auditTarget struct {
+	kind string
+	id   int
+}
+
+func gameTarget(id int) auditTarget    { return auditTarget{"game", id} }
+func entryTarget(id int) auditTarget   { return auditTarget{"entry", id} }
+func userTarget(id int) auditTarget    { return auditTarget{"user", id} }
+func stationTarget(id int) auditTarget { return auditTarget{"station", id} }
+func eventTarget(id int) auditTarget   { return auditTarget{"event", id} }
+func ratingTarget(id int) auditTarget  { return auditTarget{"rating", id} }
+
+// writeAudit appends a record of a privileged action inside the caller's
+// transaction, so the action and its audit row commit together. before
+// and after hold the changed fields as they were and as they became; nil
+// is stored as NULL. The request ids are taken from ctx.
+func writeAudit(ctx context.Context, tx *sql.Tx, actorID int, action string, gameID int, target auditTarget, before, after any) error {
+	beforeRaw, err := auditJSON(before)
+	if err != nil {
+		return err
+	}
+	afterRaw, err := auditJSON(after)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO audit_log (actor_id, action, game_id, target_type, target_id, before, after, request_id, client_request_id)
+		VALUES (NULLIF($1, 0), $2, NULLIF($3, 0), $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''))
+	`, actorID, action, gameID, target.kind, target.id, beforeRaw, afterRaw, requestid.From(ctx), requestid.ClientFrom(ctx))
+	return err
+}
+
+func auditJSON(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+var auditSorts = map[string]sortColumn{
+	"at": {"at", "timestamptz"},
+}
+
+var auditFields = []string{"id", "actor_id", "actor_login", "action", "game_id", "target_type", "target_id", "before", "after", "request_id", "client_request_id", "at"}
+
+// auditQuery builds the filtered audit log query; a page limit of 0 reads
+// every matching row.
+func auditQuery(filter domain.AuditFilter, page domain.ListFilter) (string, []any, error) {
+	if page.Sort == "" {
+		page.Sort, page.Desc = "at", true
+	}
+
+	var l listQuery
+	if filter.Actor != "" {
+		l.where("t.actor_login = " + l.arg(filter.Actor))
+	}
+	if filter.Action != "" {
+		l.where("t.action LIKE " + l.arg(likeEscaper.Replace(filter.Action)+"%"))
+	}
+	if filter.GameID != 0 {
+		l.where("t.game_id = " + l.arg(filter.GameID))
+	}
+	if filter.TargetType != "" {
+		l.where("t.target_type = " + l.arg(filter.TargetType))
+	}
+	if filter.TargetID != 0 {
+		l.where("t.target_id = " + l.arg(filter.TargetID))
+	}
+	if filter.RequestID != "" {
+		l.where("t.request_id = " + l.arg(filter.RequestID))
+	}
+	if filter.ClientRequestID != "" {
+		l.where("t.client_request_id = " + l.arg(filter.ClientRequestID))
+	}
+	if filter.EventID != 0 {
+		l.where("t.event_id = " + l.arg(filter.EventID))
+	}
+	if filter.From != nil {
+		l.where("t.at >= " + l.arg(*filter.From))
+	}
+	if filter.To != nil {
+		l.where("t.at < " + l.arg(*filter.To))
+	}
+
+	query, err := l.build(`
+		SELECT
+			a.id,
+			COALESCE(a.actor_id, 0) AS actor_id,
+			COALESCE(u.login, '') AS actor_login,
+			a.action,
+			COALESCE(a.game_id, 0) AS game_id,
+			a.target_type,
+			a.target_id,
+			a.before,
+			a.after,
+			COALESCE(a.request_id, '') AS request_id,
+			COALESCE(a.client_request_id, '') AS client_request_id,
+			a.created_at AS at,
+			CASE WHEN a.target_type = 'event' THEN a.target_id ELSE g.event_id END AS event_id
+		FROM audit_log a
+		LEFT JOIN users u ON u.id = a.actor_id
+		LEFT JOIN games g ON g.id = a.game_id`,
+		auditFields, page, auditSorts, "at", "id")
+	return query, l.args, err
+}
+
+func scanAudit(row interface{ Scan(...any) error }, r *domain.AuditRecord, extra ...any) error {
+	var before, after []byte
+	err := row.Scan(append([]any{
+		&r.ID,
+		&r.ActorID,
+		&r.ActorLogin,
+		&r.Action,
+		&r.GameID,
+		&r.TargetType,
+		&r.TargetID,
+		&before,
+		&after,
+		&r.RequestID,
+		&r.ClientRequestID,
+		&r.At,
+	}, extra...)...)
+	r.Before, r.After = before, after
+	return err
+}
+
+func (q *Queues) GetAuditLog(ctx context.Context, filter domain.AuditFilter, page domain.ListFilter, list *domain.ListAuditRecords) (*domain.Cursor, error) {
+	query, args, err := auditQuery(filter, page)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	var ids []int
+	for rows.Next() {
+		var r domain.AuditRecord
+		var key string
+		var id int
+		if err := scanAudit(rows, &r, &key, &id); err != nil {
+			return nil, err
+		}
+		*list = append(*list, r)
+		keys = append(keys, key)
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if page.Sort == "" {
+		page.Sort = "at"
+	}
+	cursor, n := nextCursor(page, "at", len(ids), keys, ids)
+	*list = (*list)[:n]
+	return cursor, nil
+}
+
+// ExportAuditLog hands every matching record to fn, oldest first, as it
+// is read, so an export never holds the whole log in memory.
+func (q *Queues) ExportAuditLog(ctx context.Context, filter domain.AuditFilter, fn func(*domain.AuditRecord) error) error {
+	query, args, err := auditQuery(filter, domain.ListFilter{Sort: "at"})
+	if err != nil {
+		return err
+	}
+
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r domain.AuditRecord
+		var key string
+		var id int
+		if err := scanAudit(rows, &r, &key, &id); err != nil {
+			return err
+		}
+		if err := fn(&r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,159 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/DexScen/Queue/backend/internal/errors"
+)
+
+const banColumns = `
+	b.id,
+	b.user_id,
+	u.login,
+	COALESCE(b.game_id, 0),
+	b.reason,
+	b.expires_at,
+	COALESCE(a.login, ''),
+	b.created_at`
+
+// activeBan is the condition of bans in force.
+const activeBan = `b.lifted_at IS NULL AND (b.expires_at IS NULL OR b.expires_at > NOW())`
+
+func scanBan(row interface{ Scan(...any) error }, ban *domain.Ban) error {
+	return row.Scan(
+		&ban.ID,
+		&ban.UserID,
+		&ban.Login,
+		&ban.GameID,
+		&ban.Reason,
+		&ban.ExpiresAt,
+		&ban.CreatedBy,
+		&ban.CreatedAt,
+	)
+}
+
+// GetBans lists the bans in force, the latest first. With an eventID
+// other than 0 only global bans and bans from that event's games are
+// listed.
+func (q *Queues) GetBans(ctx context.Context, eventID int) (domain.ListBans, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT `+banColumns+`
+		FROM bans b
+		JOIN users u ON u.id = b.user_id
+		LEFT JOIN users a ON a.id = b.created_by
+		LEFT JOIN games g ON g.id = b.game_id
+		WHERE `+activeBan+`
+		  AND ($1 = 0 OR b.game_id IS NULL OR g.event_id = $1)
+		ORDER BY b.created_at DESC, b.id DESC
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bans := domain.ListBans{}
+	for rows.Next() {
+		var ban domain.Ban
+		if err := scanBan(rows, &ban); err != nil {
+			return nil, err
+		}
+		bans = append(bans, ban)
+	}
+	return bans, rows.Err()
+}
+
+// GetActiveBan returns the ban keeping the user out of the game, or with
+// gameID 0 out of everything; a global ban wins over a game's one.
+func (q *Queues) GetActiveBan(ctx context.Context, userID, gameID int) (*domain.Ban, error) {
+	return loadActiveBan(ctx, q.db, userID, gameID)
+}
+
+// checkBan fails with ErrBanned while a ban keeps the user out of the
+// game, or out of everything when gameID is 0. Joins run it inside their
+// transaction so that a ban created meanwhile is not missed.
+func checkBan(ctx context.Context, tx *sql.Tx, userID, gameID int) error {
+	ban, err := loadActiveBan(ctx, tx, userID, gameID)
+	if err == errors.ErrBanNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return errors.Banned(ban.Reason, ban.ExpiresAt)
+}
+
+func loadActiveBan(ctx context.Context, db querier, userID, gameID int) (*domain.Ban, error) {
+	var ban domain.Ban
+	err := scanBan(db.QueryRowContext(ctx, `
+		SELECT `+banColumns+`
+		FROM bans b
+		JOIN users u ON u.id = b.user_id
+		LEFT JOIN users a ON a.id = b.created_by
+		WHERE b.user_id = $1 AND (b.game_id IS NULL OR b.game_id = NULLIF($2, 0))
+			AND `+activeBan+`
+		ORDER BY b.game_id NULLS FIRST, b.expires_at DESC NULLS FIRST
+		LIMIT 1
+	`, userID, gameID), &ban)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrBanNotFound
+		}
+		return nil, err
+	}
+	return &ban, nil
+}
+
+// CreateBan bans a user; actorID 0 records an automatic ban.
+func (q *Queues) CreateBan(ctx context.Context, actorID int, ban domain.Ban) (int, error) {
+	var id int
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO bans (user_id, game_id, reason, expires_at, created_by)
+			VALUES ($1, NULLIF($2, 0), $3, $4, NULLIF($5, 0))
+			RETURNING id
+		`, ban.UserID, ban.GameID, ban.Reason, ban.ExpiresAt, actorID).Scan(&id); err != nil {
+			return err
+		}
+
+		ban.ID = id
+		return writeAudit(ctx, tx, actorID, "user.ban", ban.GameID, userTarget(ban.UserID), nil, ban)
+	})
+	return id, err
+}
+
+// LiftBan ends a ban in force before it expires.
+func (q *Queues) LiftBan(ctx context.Context, actorID, banID int) error {
+	return q.withTx(ctx, func(tx *sql.Tx) error {
+		var userID, gameID int
+		err := tx.QueryRowContext(ctx, `
+			UPDATE bans b SET lifted_at = NOW()
+			WHERE b.id = $1 AND `+activeBan+`
+			RETURNING b.user_id, COALESCE(b.game_id, 0)
+		`, banID).Scan(&userID, &gameID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return errors.ErrBanNotFound
+			}
+			return err
+		}
+
+		return writeAudit(ctx, tx, actorID, "user.unban", gameID, userTarget(userID),
+			map[string]int{"ban_id": banID}, nil)
+	})
+}
+
+// CountLeaves counts how many times the user left the game's queue
+// within the last window.
+func (q *Queues) CountLeaves(ctx context.Context, userID, gameID int, window time.Duration) (int, error) {
+	var n int
+	err := q.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM queue_events
+		WHERE user_id = $1 AND game_id = $2 AND type = 'left'
+			AND created_at > NOW() - make_interval(secs => $3)
+	`, userID, gameID, window.Seconds()).Scan(&n)
+	return n, err
+}
@@ -0,0 +1,121 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+func TestJoinChecksBans(t *testing.T) {
+	// each path puts the user "banned" into the game's queue or books it
+	paths := map[string]func(ctx context.Context, q *Queues, gameID, banned, other int) error{
+		"join": func(ctx context.Context, q *Queues, gameID, banned, other int) error {
+			_, err := q.AddPlayerToQueue(ctx, banned, gameID, nil)
+			return err
+		},
+		"party member": func(ctx context.Context, q *Queues, gameID, banned, other int) error {
+			partyID, err := q.CreateParty(ctx, other, "team", "TEAM")
+			if err != nil {
+				return err
+			}
+			if _, _, err := q.JoinPartyByCode(ctx, banned, "TEAM"); err != nil {
+				return err
+			}
+			_, err = q.AddPartyToQueue(ctx, partyID, gameID, nil)
+			return err
+		},
+		"join a queued party": func(ctx context.Context, q *Queues, gameID, banned, other int) error {
+			partyID, err := q.CreateParty(ctx, other, "team", "TEAM")
+			if err != nil {
+				return err
+			}
+			if _, err := q.AddPartyToQueue(ctx, partyID, gameID, nil); err != nil {
+				return err
+			}
+			_, _, err = q.JoinPartyByCode(ctx, banned, "TEAM")
+			return err
+		},
+		"claim a ticket": func(ctx context.Context, q *Queues, gameID, banned, other int) error {
+			ticket, err := q.IssueTicket(ctx, 0, gameID, domain.TicketInfo{Nickname: "guest"}, "code")
+			if err != nil {
+				return err
+			}
+			_, err = q.ClaimTicket(ctx, banned, domain.ClaimInfo{Ticket: ticket.Number, ClaimCode: "code"})
+			return err
+		},
+		"book": func(ctx context.Context, q *Queues, gameID, banned, other int) error {
+			_, err := q.BookReservation(ctx, banned, gameID, time.Now().Add(time.Hour).Truncate(time.Minute), nil)
+			return err
+		},
+		"operator insert": func(ctx context.Context, q *Queues, gameID, banned, other int) error {
+			_, _, err := q.InsertPlayerAt(ctx, 0, gameID, banned, 1)
+			return err
+		},
+	}
+	bans := []struct {
+		name  string
+		scope string // game, other game, global or none
+		err   error
+	}{
+		{"no ban", "none", nil},
+		{"banned from the game", "game", e.ErrBanned},
+		{"banned globally", "global", e.ErrBanned},
+		{"banned from another game", "other game", nil},
+	}
+
+	for name, join := range paths {
+		for _, ban := range bans {
+			t.Run(name+"/"+ban.name, func(t *testing.T) {
+				q, db := testQueues(t)
+				ctx := context.Background()
+				gameID := newTestGame(t, db, 4)
+				otherGameID := newTestGame(t, db, 4)
+				banned := newTestUser(t, db, "banned")
+				other := newTestUser(t, db, "other")
+
+				var banGame any
+				switch ban.scope {
+				case "game":
+					banGame = gameID
+				case "other game":
+					banGame = otherGameID
+				}
+				if ban.scope != "none" {
+					if _, err := db.Exec(`
+						INSERT INTO bans (user_id, game_id, reason) VALUES ($1, $2, 'test')
+					`, banned, banGame); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				err := join(ctx, q, gameID, banned, other)
+				if !errors.Is(err, ban.err) || (ban.err == nil && err != nil) {
+					t.Fatalf("err = %v, want %v", err, ban.err)
+				}
+				if ban.err == nil {
+					return
+				}
+
+				var queued, booked bool
+				if err := db.QueryRow(`
+					SELECT
+						EXISTS (
+							SELECT 1 FROM queue q
+							LEFT JOIN party_members m ON m.party_id = q.party_id AND m.accepted
+							WHERE q.game_id = $1 AND (q.user_id = $2 OR m.user_id = $2)
+						),
+						EXISTS (SELECT 1 FROM reservations WHERE user_id = $2)
+				`, gameID, banned).Scan(&queued, &booked); err != nil {
+					t.Fatal(err)
+				}
+				if queued || booked {
+					t.Fatalf("banned user got in: queued %v, booked %v", queued, booked)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package psql
+
+import (
+	"context"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+// GetBoard loads every game with its queue length, plus the entries the
+// board shows: everyone called or playing and the first next waiting ones.
+// Two queries serve the whole board regardless of the number of games.
+func (q *Queues) GetBoard(ctx context.Context, next int) ([]domain.BoardGame, domain.ListQueueEntries, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT
+			g.id,
+			COALESCE(g.event_id, 0),
+			g.name,
+			g.max_slots,
+			g.duration_seconds,
+			COUNT(q.id) FILTER (WHERE q.status = 'waiting')
+		FROM games g
+		LEFT JOIN queue q ON q.game_id = g.id
+		GROUP BY g.id
+		ORDER BY g.id
+	`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var games []domain.BoardGame
+	for rows.Next() {
+		var game domain.BoardGame
+		if err := rows.Scan(
+			&game.ID,
+			&game.EventID,
+			&game.Name,
+			&game.MaxSlots,
+			&game.DurationSeconds,
+			&game.QueueLength,
+		); err != nil {
+			return nil, nil, err
+		}
+		games = append(games, game)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	entryRows, err := q.db.QueryContext(ctx, `
+		SELECT `+entryColumns+`
+		FROM queue q
+		LEFT JOIN users u ON u.id = q.user_id
+		WHERE q.status IN ('called', 'active')
+			OR (q.status = 'waiting' AND q.position <= $1)
+		ORDER BY q.game_id, q.position, q.called_at
+	`, next)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer entryRows.Close()
+
+	var entries domain.ListQueueEntries
+	for entryRows.Next() {
+		var entry domain.QueueEntry
+		if err := scanEntry(entryRows, &entry); err != nil {
+			return nil, nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return games, entries, entryRows.Err()
+}
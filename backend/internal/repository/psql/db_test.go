@@ -0,0 +1,151 @@
+package psql
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	_ "github.com/lib/pq"
+)
+
+// The repository tests run against a real PostgreSQL server given by
+// QUEUE_TEST_DSN, e.g.
+//
+//	QUEUE_TEST_DSN="host=localhost user=postgres password=postgres dbname=queue sslmode=disable"
+//
+// Each test gets a schema of its own loaded from PostgreSQL/init.sql and
+// dropped afterwards. Without the variable they are skipped.
+
+const schemaFile = "../../../../PostgreSQL/init.sql"
+
+func testQueues(t *testing.T) (*Queues, *sql.DB) {
+	t.Helper()
+
+	dsn := os.Getenv("QUEUE_TEST_DSN")
+	if dsn == "" {
+		t.Skip("QUEUE_TEST_DSN is not set")
+	}
+
+	suffix := make([]byte, 6)
+	rand.Read(suffix)
+	schema := "test_" + hex.EncodeToString(suffix)
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`); err != nil {
+			t.Log("drop schema:", err)
+		}
+	})
+
+	db, err := sql.Open("postgres", withSearchPath(dsn, schema+",public"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ddl, err := os.ReadFile(schemaFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(string(ddl)); err != nil {
+		t.Fatal("load schema:", err)
+	}
+	// start from empty tables rather than the demo data
+	if _, err := db.Exec(`TRUNCATE queue, users, games, events RESTART IDENTITY CASCADE`); err != nil {
+		t.Fatal(err)
+	}
+
+	return NewQueues(db), db
+}
+
+// withSearchPath adds a search_path run-time parameter to a key=value or
+// URL connection string.
+func withSearchPath(dsn, path string) string {
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err == nil {
+			query := u.Query()
+			query.Set("search_path", path)
+			u.RawQuery = query.Encode()
+			return u.String()
+		}
+	}
+	return dsn + " search_path=" + path
+}
+
+// newTestGame adds a game outside any event; names are numbered since a
+// name may only be used once per event.
+func newTestGame(t *testing.T, db *sql.DB, maxSlots int) int {
+	t.Helper()
+	var id int
+	if err := db.QueryRow(`
+		INSERT INTO games (name, description, max_slots)
+		VALUES ('game ' || (SELECT COUNT(*) + 1 FROM games), '', $1)
+		RETURNING id
+	`, maxSlots).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func newTestUser(t *testing.T, db *sql.DB, login string) int {
+	t.Helper()
+	var id int
+	if err := db.QueryRow(`
+		INSERT INTO users (login, password_hash) VALUES ($1, '') RETURNING id
+	`, login).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// waitingUsers returns the users waiting in a game in queue order and
+// fails the test unless their positions are exactly 1..N and every other
+// entry of the game is at position 0.
+func waitingUsers(t *testing.T, db *sql.DB, gameID int) []int {
+	t.Helper()
+	rows, err := db.QueryContext(context.Background(), `
+		SELECT COALESCE(user_id, 0), position, status FROM queue
+		WHERE game_id = $1
+		ORDER BY status <> 'waiting', position, id
+	`, gameID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var users []int
+	for rows.Next() {
+		var user, position int
+		var status string
+		if err := rows.Scan(&user, &position, &status); err != nil {
+			t.Fatal(err)
+		}
+		if status != "waiting" {
+			if position != 0 {
+				t.Errorf("%s entry of user %d at position %d, want 0", status, user, position)
+			}
+			continue
+		}
+		users = append(users, user)
+		if position != len(users) {
+			t.Errorf("waiting user %d at position %d, want %d", user, position, len(users))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return users
+}
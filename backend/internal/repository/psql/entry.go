@@ -0,0 +1,102 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/DexScen/Queue/backend/internal/errors"
+)
+
+// entryColumns selects a queue entry as scanned by scanEntry, from queue q
+// LEFT JOIN users u. Walk-in tickets have no user, so user fields fall back
+// to zero values. entryFields names the same columns for listQuery.
+const entryColumns = `
+	q.id,
+	q.game_id,
+	COALESCE(q.user_id, 0) AS user_id,
+	COALESCE(u.login, '') AS login,
+	q.position,
+	q.status,
+	q.joined_at,
+	q.called_at,
+	q.started_at,
+	q.finished_at,
+	q.left_at,
+	q.score,
+	q.result,
+	COALESCE(q.ticket, '') AS ticket,
+	COALESCE(q.nickname, '') AS nickname,
+	COALESCE(q.party_id, 0) AS party_id,
+	q.slots,
+	q.priority,
+	COALESCE(u.user_group, '') AS user_group,
+	COALESCE((SELECT s.name FROM stations s WHERE s.id = q.station_id), '') AS station
+`
+
+var entryFields = []string{"id", "game_id", "user_id", "login", "position", "status", "joined_at", "called_at", "started_at", "finished_at", "left_at", "score", "result", "ticket", "nickname", "party_id", "slots", "priority", "user_group", "station"}
+
+func scanEntry(row interface{ Scan(...any) error }, entry *domain.QueueEntry, extra ...any) error {
+	return row.Scan(append([]any{
+		&entry.ID,
+		&entry.GameID,
+		&entry.UserID,
+		&entry.Login,
+		&entry.Position,
+		&entry.Status,
+		&entry.JoinedAt,
+		&entry.CalledAt,
+		&entry.StartedAt,
+		&entry.FinishedAt,
+		&entry.LeftAt,
+		&entry.Score,
+		&entry.Result,
+		&entry.Ticket,
+		&entry.Nickname,
+		&entry.PartyID,
+		&entry.Slots,
+		&entry.Priority,
+		&entry.Group,
+		&entry.Station,
+	}, extra...)...)
+}
+
+func (q *Queues) GetEntryByID(ctx context.Context, entryID int) (*domain.QueueEntry, error) {
+	var entry domain.QueueEntry
+	err := scanEntry(q.db.QueryRowContext(ctx, `
+		SELECT `+entryColumns+`
+		FROM queue q
+		LEFT JOIN users u ON u.id = q.user_id
+		WHERE q.id = $1
+	`, entryID), &entry)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrEntryNotFound
+		}
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// GetEntryForUser returns the user's entry in a game that is still in play.
+func (q *Queues) GetEntryForUser(ctx context.Context, gameID, userID int) (*domain.QueueEntry, error) {
+	var entry domain.QueueEntry
+	err := scanEntry(q.db.QueryRowContext(ctx, `
+		SELECT `+entryColumns+`
+		FROM queue q
+		LEFT JOIN users u ON u.id = q.user_id
+		WHERE q.game_id = $1 AND q.user_id = $2
+			AND q.status IN ('waiting', 'called', 'active')
+		ORDER BY q.joined_at DESC
+		LIMIT 1
+	`, gameID, userID), &entry)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrEntryNotFound
+		}
+		return nil, err
+	}
+
+	return &entry, nil
+}
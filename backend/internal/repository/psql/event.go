@@ -0,0 +1,267 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/DexScen/Queue/backend/internal/errors"
+	"github.com/lib/pq"
+)
+
+const eventColumns = `
+	id,
+	name,
+	to_char(starts_on, 'YYYY-MM-DD'),
+	to_char(ends_on, 'YYYY-MM-DD'),
+	venue,
+	status
+`
+
+func scanEvent(row interface{ Scan(...any) error }, event *domain.Event) error {
+	err := row.Scan(&event.ID, &event.Name, &event.StartsOn, &event.EndsOn, &event.Venue, &event.Status)
+	if err == sql.ErrNoRows {
+		return errors.ErrEventNotFound
+	}
+	return err
+}
+
+// eventWriteError maps the one-active-event index to ErrEventActive.
+func eventWriteError(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" && pqErr.Constraint == "idx_events_active" {
+		return errors.ErrEventActive
+	}
+	return err
+}
+
+// gameWriteError maps the unique event and name of a game to
+// ErrGameExists.
+func gameWriteError(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" && pqErr.Constraint == "games_event_name" {
+		return errors.ErrGameExists
+	}
+	return err
+}
+
+func (q *Queues) GetEvents(ctx context.Context) (domain.ListEvents, error) {
+	rows, err := q.db.QueryContext(ctx, `SELECT `+eventColumns+` FROM events ORDER BY starts_on DESC, id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := domain.ListEvents{}
+	for rows.Next() {
+		var event domain.Event
+		if err := scanEvent(rows, &event); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (q *Queues) GetEvent(ctx context.Context, eventID int) (*domain.Event, error) {
+	var event domain.Event
+	if err := scanEvent(q.db.QueryRowContext(ctx, `SELECT `+eventColumns+` FROM events WHERE id = $1`, eventID), &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// GetActiveEvent returns the active event, or ErrEventNotFound when the
+// deployment is between events.
+func (q *Queues) GetActiveEvent(ctx context.Context) (*domain.Event, error) {
+	var event domain.Event
+	if err := scanEvent(q.db.QueryRowContext(ctx, `SELECT `+eventColumns+` FROM events WHERE status = 'active'`), &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (q *Queues) CreateEvent(ctx context.Context, actorID int, event domain.Event) (int, error) {
+	var eventID int
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO events (name, starts_on, ends_on, venue, status)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`, event.Name, event.StartsOn, event.EndsOn, event.Venue, event.Status).Scan(&eventID); err != nil {
+			return eventWriteError(err)
+		}
+
+		event.ID = eventID
+		return writeAudit(ctx, tx, actorID, "event.create", 0, eventTarget(eventID), nil, event)
+	})
+	return eventID, err
+}
+
+func (q *Queues) UpdateEvent(ctx context.Context, actorID int, event domain.Event) error {
+	return q.withTx(ctx, func(tx *sql.Tx) error {
+		var before domain.Event
+		if err := scanEvent(tx.QueryRowContext(ctx, `SELECT `+eventColumns+` FROM events WHERE id = $1 FOR UPDATE`, event.ID), &before); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE events
+			SET name = $2, starts_on = $3, ends_on = $4, venue = $5, status = $6
+			WHERE id = $1
+		`, event.ID, event.Name, event.StartsOn, event.EndsOn, event.Venue, event.Status); err != nil {
+			return eventWriteError(err)
+		}
+
+		return writeAudit(ctx, tx, actorID, "event.update", 0, eventTarget(event.ID), before, event)
+	})
+}
+
+// JoinEvent makes the user a participant; joining again changes nothing,
+// in particular not a staff role they hold.
+func (q *Queues) JoinEvent(ctx context.Context, eventID, userID int) error {
+	if _, err := q.GetEvent(ctx, eventID); err != nil {
+		return err
+	}
+
+	_, err := q.db.ExecContext(ctx, `
+		INSERT INTO event_members (event_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`, eventID, userID)
+	return err
+}
+
+func (q *Queues) LeaveEvent(ctx context.Context, eventID, userID int) error {
+	if _, err := q.GetEvent(ctx, eventID); err != nil {
+		return err
+	}
+
+	_, err := q.db.ExecContext(ctx, `DELETE FROM event_members WHERE event_id = $1 AND user_id = $2`, eventID, userID)
+	return err
+}
+
+func (q *Queues) GetEventMembers(ctx context.Context, eventID int) (domain.ListEventMembers, error) {
+	if _, err := q.GetEvent(ctx, eventID); err != nil {
+		return nil, err
+	}
+
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT m.user_id, u.login, m.role, m.joined_at
+		FROM event_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.event_id = $1
+		ORDER BY m.joined_at, m.user_id
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := domain.ListEventMembers{}
+	for rows.Next() {
+		var m domain.EventMember
+		if err := rows.Scan(&m.UserID, &m.Login, &m.Role, &m.JoinedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// SetEventMember adds the user to the event with the role, or changes the
+// role they have there.
+func (q *Queues) SetEventMember(ctx context.Context, actorID, eventID, userID int, role string) error {
+	return q.withTx(ctx, func(tx *sql.Tx) error {
+		var id int
+		err := tx.QueryRowContext(ctx, `SELECT id FROM events WHERE id = $1 FOR UPDATE`, eventID).Scan(&id)
+		if err == sql.ErrNoRows {
+			return errors.ErrEventNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		var before *domain.RoleInfo
+		var old sql.NullString
+		if err := tx.QueryRowContext(ctx, `
+			SELECT (SELECT role FROM event_members WHERE event_id = $1 AND user_id = $2)
+		`, eventID, userID).Scan(&old); err != nil {
+			return err
+		}
+		if old.Valid {
+			before = &domain.RoleInfo{Role: old.String}
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO event_members (event_id, user_id, role)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (event_id, user_id) DO UPDATE SET role = EXCLUDED.role
+		`, eventID, userID, role); err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, actorID, "event.member", 0, userTarget(userID), before, map[string]any{
+			"event_id": eventID,
+			"role":     role,
+		})
+	})
+}
+
+// SetGameEvent moves a game to an event, or out of any with eventID 0.
+func (q *Queues) SetGameEvent(ctx context.Context, actorID, gameID, eventID int) error {
+	return q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+		if eventID != 0 {
+			var id int
+			err := tx.QueryRowContext(ctx, `SELECT id FROM events WHERE id = $1`, eventID).Scan(&id)
+			if err == sql.ErrNoRows {
+				return errors.ErrEventNotFound
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		var before domain.EventAssign
+		if err := tx.QueryRowContext(ctx, `
+			UPDATE games g
+			SET event_id = NULLIF($2, 0)
+			FROM (SELECT id, COALESCE(event_id, 0) AS event_id FROM games WHERE id = $1) old
+			WHERE g.id = old.id
+			RETURNING old.event_id
+		`, gameID, eventID).Scan(&before.EventID); err != nil {
+			return gameWriteError(err)
+		}
+
+		return writeAudit(ctx, tx, actorID, "game.event", gameID, gameTarget(gameID), before, domain.EventAssign{EventID: eventID})
+	})
+}
+
+// GetEventRole returns the role the user holds in the event, or "" when
+// they are not a member.
+func (q *Queues) GetEventRole(ctx context.Context, eventID, userID int) (string, error) {
+	var role string
+	err := q.db.QueryRowContext(ctx, `
+		SELECT role FROM event_members WHERE event_id = $1 AND user_id = $2
+	`, eventID, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return role, err
+}
+
+// GetGameEventRole is GetEventRole for the event the game belongs to.
+func (q *Queues) GetGameEventRole(ctx context.Context, gameID, userID int) (string, error) {
+	var role string
+	err := q.db.QueryRowContext(ctx, `
+		SELECT m.role
+		FROM games g
+		JOIN event_members m ON m.event_id = g.event_id
+		WHERE g.id = $1 AND m.user_id = $2
+	`, gameID, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return role, err
+}
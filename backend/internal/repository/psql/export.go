@@ -0,0 +1,156 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+const exportBatch = 500
+
+// exportRows runs query through a server-side cursor and hands every row
+// to scan, fetching exportBatch rows at a time, so an export holds at most
+// one batch in memory however large the table is. The read-only
+// transaction also gives the whole export a single consistent snapshot.
+func (q *Queues) exportRows(ctx context.Context, query string, scan func(*sql.Rows) error) error {
+	tx, err := q.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DECLARE export_cursor NO SCROLL CURSOR FOR "+query); err != nil {
+		return err
+	}
+
+	fetch := fmt.Sprintf("FETCH %d FROM export_cursor", exportBatch)
+	for {
+		rows, err := tx.QueryContext(ctx, fetch)
+		if err != nil {
+			return err
+		}
+
+		n := 0
+		for rows.Next() {
+			n++
+			if err := scan(rows); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if n < exportBatch {
+			return tx.Commit()
+		}
+	}
+}
+
+func (q *Queues) ExportGames(ctx context.Context, fn func(*domain.Game) error) error {
+	return q.exportRows(ctx, `
+		SELECT
+			g.id,
+			COALESCE(g.event_id, 0),
+			g.name,
+			g.description,
+			g.max_slots,
+			g.duration_seconds,
+			(SELECT COUNT(*) FROM queue w WHERE w.game_id = g.id AND w.status = 'waiting'),
+			`+gameRatingColumns+`
+		FROM games g
+		ORDER BY g.id
+	`, func(rows *sql.Rows) error {
+		var game domain.Game
+		if err := rows.Scan(
+			&game.ID,
+			&game.EventID,
+			&game.Name,
+			&game.Description,
+			&game.Max_slots,
+			&game.Duration_seconds,
+			&game.Current_people,
+			&game.Rating,
+			&game.RatingCount,
+		); err != nil {
+			return err
+		}
+		return fn(&game)
+	})
+}
+
+func (q *Queues) ExportUsers(ctx context.Context, fn func(*domain.UserRecord) error) error {
+	return q.exportRows(ctx, `
+		SELECT id, login, role, priority, user_group
+		FROM users
+		ORDER BY id
+	`, func(rows *sql.Rows) error {
+		var user domain.UserRecord
+		if err := rows.Scan(&user.ID, &user.Login, &user.Role, &user.Priority, &user.Group); err != nil {
+			return err
+		}
+		return fn(&user)
+	})
+}
+
+// ExportEntries streams queue entries game by game: the ones still in play
+// in queue order, or, with all set, every entry ever made in join order.
+func (q *Queues) ExportEntries(ctx context.Context, all bool, fn func(*domain.QueueEntry) error) error {
+	where, order := "q.status IN ('waiting', 'called', 'active')", "q.game_id, q.position = 0, q.position, q.id"
+	if all {
+		where, order = "TRUE", "q.game_id, q.joined_at, q.id"
+	}
+
+	return q.exportRows(ctx, `
+		SELECT `+entryColumns+`
+		FROM queue q
+		LEFT JOIN users u ON u.id = q.user_id
+		WHERE `+where+`
+		ORDER BY `+order,
+		func(rows *sql.Rows) error {
+			var entry domain.QueueEntry
+			if err := scanEntry(rows, &entry); err != nil {
+				return err
+			}
+			return fn(&entry)
+		})
+}
+
+func (q *Queues) ExportEvents(ctx context.Context, fn func(*domain.QueueEvent) error) error {
+	return q.exportRows(ctx, `
+		SELECT
+			e.id,
+			COALESCE(e.game_id, 0),
+			COALESCE(e.entry_id, 0),
+			COALESCE(e.user_id, 0),
+			COALESCE(u.login, ''),
+			e.type,
+			COALESCE(e.actor_id, 0),
+			e.details,
+			e.created_at
+		FROM queue_events e
+		LEFT JOIN users u ON u.id = e.user_id
+		ORDER BY e.id
+	`, func(rows *sql.Rows) error {
+		var ev domain.QueueEvent
+		var details []byte
+		if err := rows.Scan(
+			&ev.ID,
+			&ev.GameID,
+			&ev.EntryID,
+			&ev.UserID,
+			&ev.Login,
+			&ev.Type,
+			&ev.ActorID,
+			&details,
+			&ev.At,
+		); err != nil {
+			return err
+		}
+		ev.Details = details
+		return fn(&ev)
+	})
+}
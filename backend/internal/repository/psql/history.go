@@ -0,0 +1,217 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/lib/pq"
+)
+
+// History: queue rows are never deleted; an entry that leaves play keeps
+// its row with a final status and timestamps. Every change to an entry is
+// also appended to queue_events by writeEvent, inside the transaction that
+// makes the change, so the timeline cannot miss or invent a step.
+
+// writeEvent records a change of a queue entry. actorID is the staff
+// member who made it, or 0 for the player themselves and the system.
+func writeEvent(ctx context.Context, tx *sql.Tx, actorID int, kind string, gameID, entryID int, details any) error {
+	raw := []byte("{}")
+	if details != nil {
+		var err error
+		if raw, err = json.Marshal(details); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO queue_events (game_id, entry_id, user_id, type, actor_id, details)
+		SELECT $1, q.id, q.user_id, $3, NULLIF($4, 0), $5
+		FROM queue q
+		WHERE q.id = $2
+	`, gameID, entryID, kind, actorID, raw)
+	return err
+}
+
+var historySorts = map[string]sortColumn{
+	"joined_at": {"joined_at", "timestamptz"},
+	"game":      {"game_id", "int"},
+}
+
+// GetUserHistory lists every queue entry of a user, including the ones of
+// parties they belonged to, newest first unless sorted otherwise. A party
+// entry that has been called belongs to the members recorded at the call;
+// one that never was, to the party as it is now.
+func (q *Queues) GetUserHistory(ctx context.Context, userID int, filter domain.ListFilter, list *domain.ListHistoryEntries) (*domain.Cursor, error) {
+	if filter.Sort == "" {
+		filter.Sort, filter.Desc = "joined_at", true
+	}
+
+	var l listQuery
+	userArg := l.arg(userID)
+	if filter.Status != "" {
+		l.where("t.status = ANY(" + l.arg(pq.Array(strings.Split(filter.Status, ","))) + ")")
+	}
+	if filter.JoinedAfter != nil {
+		l.where("t.joined_at > " + l.arg(*filter.JoinedAfter))
+	}
+	if filter.Search != "" {
+		l.where("t.game_name ILIKE " + l.arg(searchPattern(filter.Search)))
+	}
+	if filter.EventID != 0 {
+		l.where("t.event_id = " + l.arg(filter.EventID))
+	}
+
+	query, err := l.build(`
+		SELECT
+			q.id,
+			q.game_id,
+			g.name AS game_name,
+			g.event_id,
+			q.status,
+			COALESCE(q.party_id, 0) AS party_id,
+			q.joined_at,
+			q.called_at,
+			q.started_at,
+			q.finished_at,
+			q.left_at,
+			q.score,
+			q.result
+		FROM queue q
+		JOIN games g ON g.id = q.game_id
+		WHERE q.user_id = `+userArg+` OR q.id IN (
+			SELECT entry_id FROM entry_members WHERE user_id = `+userArg+`
+		) OR (q.party_id IN (
+			SELECT party_id FROM party_members WHERE user_id = `+userArg+` AND accepted
+		) AND NOT EXISTS (SELECT 1 FROM entry_members em WHERE em.entry_id = q.id))`,
+		[]string{"id", "game_id", "game_name", "status", "party_id", "joined_at", "called_at", "started_at", "finished_at", "left_at", "score", "result"},
+		filter, historySorts, "joined_at", "id")
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.db.QueryContext(ctx, query, l.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	var ids []int
+	for rows.Next() {
+		var h domain.HistoryEntry
+		var key string
+		var id int
+		if err := rows.Scan(
+			&h.EntryID,
+			&h.GameID,
+			&h.GameName,
+			&h.Status,
+			&h.PartyID,
+			&h.JoinedAt,
+			&h.CalledAt,
+			&h.StartedAt,
+			&h.FinishedAt,
+			&h.LeftAt,
+			&h.Score,
+			&h.Result,
+			&key,
+			&id,
+		); err != nil {
+			return nil, err
+		}
+		*list = append(*list, h)
+		keys = append(keys, key)
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor, n := nextCursor(filter, "joined_at", len(ids), keys, ids)
+	*list = (*list)[:n]
+	return cursor, nil
+}
+
+var timelineSorts = map[string]sortColumn{
+	"at": {"at", "timestamptz"},
+}
+
+// GetGameTimeline lists the queue events of a game in order. The search
+// matches the login of the player.
+func (q *Queues) GetGameTimeline(ctx context.Context, gameID int, filter domain.ListFilter, list *domain.ListQueueEvents) (*domain.Cursor, error) {
+	var l listQuery
+	gameArg := l.arg(gameID)
+	if filter.Status != "" {
+		l.where("t.type = ANY(" + l.arg(pq.Array(strings.Split(filter.Status, ","))) + ")")
+	}
+	if filter.JoinedAfter != nil {
+		l.where("t.at > " + l.arg(*filter.JoinedAfter))
+	}
+	if filter.Search != "" {
+		l.where("t.login ILIKE " + l.arg(searchPattern(filter.Search)))
+	}
+
+	query, err := l.build(`
+		SELECT
+			e.id,
+			e.game_id,
+			COALESCE(e.entry_id, 0) AS entry_id,
+			COALESCE(e.user_id, 0) AS user_id,
+			COALESCE(u.login, '') AS login,
+			e.type,
+			COALESCE(e.actor_id, 0) AS actor_id,
+			e.details,
+			e.created_at AS at
+		FROM queue_events e
+		LEFT JOIN users u ON u.id = e.user_id
+		WHERE e.game_id = `+gameArg,
+		[]string{"id", "game_id", "entry_id", "user_id", "login", "type", "actor_id", "details", "at"},
+		filter, timelineSorts, "at", "id")
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.db.QueryContext(ctx, query, l.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	var ids []int
+	for rows.Next() {
+		var ev domain.QueueEvent
+		var details []byte
+		var key string
+		var id int
+		if err := rows.Scan(
+			&ev.ID,
+			&ev.GameID,
+			&ev.EntryID,
+			&ev.UserID,
+			&ev.Login,
+			&ev.Type,
+			&ev.ActorID,
+			&details,
+			&ev.At,
+			&key,
+			&id,
+		); err != nil {
+			return nil, err
+		}
+		ev.Details = details
+		*list = append(*list, ev)
+		keys = append(keys, key)
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor, n := nextCursor(filter, "at", len(ids), keys, ids)
+	*list = (*list)[:n]
+	return cursor, nil
+}
@@ -0,0 +1,163 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/DexScen/Queue/backend/internal/errors"
+)
+
+func (q *Queues) GetHours(ctx context.Context, gameID int) (*domain.Hours, error) {
+	return loadHours(ctx, q.db, gameID)
+}
+
+func loadHours(ctx context.Context, db querier, gameID int) (*domain.Hours, error) {
+	var hours domain.Hours
+	err := db.QueryRowContext(ctx, `
+		SELECT timezone, paused FROM games WHERE id = $1
+	`, gameID).Scan(&hours.Timezone, &hours.Paused)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrGameNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT weekday, to_char(opens, 'HH24:MI'), to_char(closes, 'HH24:MI')
+		FROM game_hours
+		WHERE game_id = $1
+		ORDER BY weekday, opens
+	`, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hours.Windows = []domain.HoursWindow{}
+	for rows.Next() {
+		var w domain.HoursWindow
+		if err := rows.Scan(&w.Weekday, &w.Opens, &w.Closes); err != nil {
+			return nil, err
+		}
+		hours.Windows = append(hours.Windows, w)
+	}
+
+	return &hours, rows.Err()
+}
+
+// checkOpen runs the hours check of a walk-in join against the line as it
+// stands inside the join transaction. A nil open skips the check. The game
+// must already be locked.
+func checkOpen(ctx context.Context, tx *sql.Tx, gameID int, open domain.OpenFunc) error {
+	if open == nil {
+		return nil
+	}
+	state, err := loadOpenState(ctx, tx, gameID, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := tx.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COALESCE(SUM(slots), 0) FROM queue WHERE game_id = $1 AND status = 'waiting'),
+			(SELECT COALESCE(SUM(slots), 0) FROM queue WHERE game_id = $1 AND status IN ('called', 'active'))
+	`, gameID).Scan(&state.Waiting, &state.Busy); err != nil {
+		return err
+	}
+	return open(*state)
+}
+
+// checkOpenAt is checkOpen for a reservation that joins at the start of
+// its slot, ahead of the line. The paused flag only counts for a slot
+// that has already started.
+func checkOpenAt(ctx context.Context, tx *sql.Tx, gameID int, at time.Time, open domain.OpenFunc) error {
+	if open == nil {
+		return nil
+	}
+	state, err := loadOpenState(ctx, tx, gameID, at)
+	if err != nil {
+		return err
+	}
+	if at.After(time.Now()) {
+		state.Hours.Paused = false
+	}
+	return open(*state)
+}
+
+func loadOpenState(ctx context.Context, tx *sql.Tx, gameID int, at time.Time) (*domain.OpenState, error) {
+	hours, err := loadHours(ctx, tx, gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	state := domain.OpenState{Hours: *hours, At: at}
+	if err := tx.QueryRowContext(ctx, `
+		SELECT id, max_slots, duration_seconds FROM games WHERE id = $1
+	`, gameID).Scan(&state.Game.ID, &state.Game.Max_slots, &state.Game.Duration_seconds); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// SetHours replaces the timezone and every window of a game; the paused
+// flag is left alone.
+func (q *Queues) SetHours(ctx context.Context, actorID, gameID int, hours domain.Hours) error {
+	return q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+
+		before, err := loadHours(ctx, tx, gameID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE games SET timezone = $2 WHERE id = $1
+		`, gameID, hours.Timezone); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM game_hours WHERE game_id = $1`, gameID); err != nil {
+			return err
+		}
+		for _, w := range hours.Windows {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO game_hours (game_id, weekday, opens, closes)
+				VALUES ($1, $2, $3, $4)
+			`, gameID, w.Weekday, w.Opens, w.Closes); err != nil {
+				return err
+			}
+		}
+
+		hours.Paused = before.Paused
+		return writeAudit(ctx, tx, actorID, "game.hours", gameID, gameTarget(gameID), before, hours)
+	})
+}
+
+func (q *Queues) SetPaused(ctx context.Context, actorID, gameID int, paused bool) error {
+	return q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+
+		var was bool
+		if err := tx.QueryRowContext(ctx, `
+			UPDATE games g SET paused = $2
+			FROM (SELECT id, paused FROM games WHERE id = $1) old
+			WHERE g.id = old.id
+			RETURNING old.paused
+		`, gameID, paused).Scan(&was); err != nil {
+			return err
+		}
+
+		action := "game.resume"
+		if paused {
+			action = "game.pause"
+		}
+		return writeAudit(ctx, tx, actorID, action, gameID, gameTarget(gameID),
+			map[string]bool{"paused": was},
+			map[string]bool{"paused": paused})
+	})
+}
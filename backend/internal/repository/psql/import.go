@@ -0,0 +1,171 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	goerrors "errors"
+	"strconv"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+// errRollback makes withTx roll back a transaction that did nothing
+// wrong: a dry run, or an import with rejected rows.
+var errRollback = goerrors.New("rollback")
+
+// importTx runs an import in a single transaction that is committed only
+// when no row was rejected and it is not a dry run.
+func (q *Queues) importTx(ctx context.Context, result *domain.ImportResult, fn func(tx *sql.Tx) error) error {
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if result.DryRun || len(result.Errors) > 0 {
+			return errRollback
+		}
+		return nil
+	})
+	if err == errRollback {
+		return nil
+	}
+	result.Applied = err == nil
+	return err
+}
+
+// ImportGames upserts games by event and name. The table is locked
+// against other writers for the duration, so that two imports creating
+// the same game wait for each other instead of failing on the unique
+// event and name.
+func (q *Queues) ImportGames(ctx context.Context, actorID int, rows []domain.GameImport, result *domain.ImportResult) error {
+	return q.importTx(ctx, result, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `LOCK TABLE games IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			var gameID int
+			var before domain.GameImport
+			err := tx.QueryRowContext(ctx, `
+				UPDATE games g
+				SET description = $3,
+					max_slots = $4,
+					duration_seconds = COALESCE(NULLIF($5, 0), old.duration_seconds)
+				FROM (
+					SELECT id, COALESCE(event_id, 0) AS event_id, name, COALESCE(description, '') AS description, max_slots, duration_seconds
+					FROM games
+					WHERE event_id IS NOT DISTINCT FROM NULLIF($1, 0) AND name = $2
+				) old
+				WHERE g.id = old.id
+				RETURNING g.id, old.event_id, old.name, old.description, old.max_slots, old.duration_seconds
+			`, row.EventID, row.Name, row.Description, row.MaxSlots, row.DurationSeconds).Scan(
+				&gameID, &before.EventID, &before.Name, &before.Description, &before.MaxSlots, &before.DurationSeconds)
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+
+			if err == nil {
+				result.Updated++
+				if row.DurationSeconds == 0 {
+					row.DurationSeconds = before.DurationSeconds
+				}
+				if err := writeAudit(ctx, tx, actorID, "game.import", gameID, gameTarget(gameID), before, row); err != nil {
+					return err
+				}
+				continue
+			}
+
+			duration := "DEFAULT"
+			args := []any{row.Name, row.Description, row.MaxSlots, row.EventID}
+			if row.DurationSeconds != 0 {
+				args = append(args, row.DurationSeconds)
+				duration = "$" + strconv.Itoa(len(args))
+			}
+			if err := tx.QueryRowContext(ctx, `
+				INSERT INTO games (name, description, max_slots, event_id, duration_seconds)
+				VALUES ($1, $2, $3, NULLIF($4, 0), `+duration+`)
+				RETURNING id, duration_seconds
+			`, args...).Scan(&gameID, &row.DurationSeconds); err != nil {
+				return err
+			}
+			result.Created++
+			if err := writeAudit(ctx, tx, actorID, "game.import", gameID, gameTarget(gameID), nil, row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// userAudit is a user as the import audit records it, without the hash.
+type userAudit struct {
+	Login           string `json:"login"`
+	Role            string `json:"role"`
+	Priority        int    `json:"priority"`
+	Group           string `json:"group"`
+	PasswordChanged bool   `json:"password_changed,omitempty"`
+}
+
+// ImportUsers upserts users by login. Row passwords must already be
+// hashed; an empty one keeps the password of an existing user and an
+// empty role keeps their role. A new user without a password is rejected
+// as a row error.
+func (q *Queues) ImportUsers(ctx context.Context, actorID int, rows []domain.UserImport, result *domain.ImportResult) error {
+	return q.importTx(ctx, result, func(tx *sql.Tx) error {
+		for i, row := range rows {
+			var userID int
+			var before userAudit
+			err := tx.QueryRowContext(ctx, `
+				SELECT id, login, role, priority, user_group
+				FROM users
+				WHERE login = $1
+				FOR UPDATE
+			`, row.Login).Scan(&userID, &before.Login, &before.Role, &before.Priority, &before.Group)
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+
+			if err == sql.ErrNoRows {
+				if row.Password == "" {
+					result.Errors = append(result.Errors, domain.ImportError{Row: i + 1, Field: "password", Error: "required for a new user"})
+					continue
+				}
+				if row.Role == "" {
+					row.Role = domain.RoleUser
+				}
+				if err := tx.QueryRowContext(ctx, `
+					INSERT INTO users (login, password_hash, role, priority, user_group)
+					VALUES ($1, $2, $3, $4, $5)
+					RETURNING id
+				`, row.Login, row.Password, row.Role, row.Priority, row.Group).Scan(&userID); err != nil {
+					return err
+				}
+				result.Created++
+				after := userAudit{Login: row.Login, Role: row.Role, Priority: row.Priority, Group: row.Group, PasswordChanged: true}
+				if err := writeAudit(ctx, tx, actorID, "user.import", 0, userTarget(userID), nil, after); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if row.Role == "" {
+				row.Role = before.Role
+			}
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE users
+				SET role = $2,
+					priority = $3,
+					user_group = $4,
+					password_hash = COALESCE(NULLIF($5, ''), password_hash)
+				WHERE id = $1
+			`, userID, row.Role, row.Priority, row.Group, row.Password); err != nil {
+				return err
+			}
+			result.Updated++
+			after := userAudit{Login: row.Login, Role: row.Role, Priority: row.Priority, Group: row.Group, PasswordChanged: row.Password != ""}
+			if err := writeAudit(ctx, tx, actorID, "user.import", 0, userTarget(userID), before, after); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,57 @@
+package psql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+func TestImportGamesByEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		eventID int // of the imported row; 0 is outside any event
+		created int
+		updated int
+	}{
+		{"same event updates", 1, 0, 1},
+		{"other event creates", 2, 1, 0},
+		{"no event creates", 0, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, db := testQueues(t)
+			ctx := context.Background()
+
+			for _, name := range []string{"first", "second"} {
+				if _, err := db.Exec(`
+					INSERT INTO events (name, starts_on, ends_on) VALUES ($1, CURRENT_DATE, CURRENT_DATE)
+				`, name); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := db.Exec(`
+				INSERT INTO games (event_id, name, description, max_slots) VALUES (1, 'VR', '', 2)
+			`); err != nil {
+				t.Fatal(err)
+			}
+
+			var result domain.ImportResult
+			rows := []domain.GameImport{{EventID: tt.eventID, Name: "VR", MaxSlots: 3}}
+			if err := q.ImportGames(ctx, 0, rows, &result); err != nil {
+				t.Fatal(err)
+			}
+			if result.Created != tt.created || result.Updated != tt.updated {
+				t.Fatalf("created %d, updated %d, want %d and %d", result.Created, result.Updated, tt.created, tt.updated)
+			}
+
+			var eventID, maxSlots int
+			if err := db.QueryRow(`SELECT event_id, max_slots FROM games WHERE id = 1`).Scan(&eventID, &maxSlots); err != nil {
+				t.Fatal(err)
+			}
+			if eventID != 1 || (tt.updated == 1) != (maxSlots == 3) {
+				t.Fatalf("existing game is in event %d with %d slots", eventID, maxSlots)
+			}
+		})
+	}
+}
@@ -0,0 +1,374 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/DexScen/Queue/backend/internal/errors"
+)
+
+// callNext calls a reservation whose slot has started and that open lets
+// in, or else lets pick choose among the waiting entries. The chosen entry is only called if the
+// game has enough free slots for it; a party occupies as many slots as it
+// has members. If it does not fit, nobody is called rather than passing
+// over it. A game with stations also needs a free station, stationID if
+// given, and the entry is sent there. It returns the called entry, or 0
+// when nobody could be called. The game must already be locked.
+func callNext(ctx context.Context, tx *sql.Tx, actorID, gameID, stationID int, pick domain.PickFunc, open domain.OpenFunc) (int, error) {
+	stationID, ok, err := freeStation(ctx, tx, gameID, stationID)
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	// a reservation whose slot has started goes before the walk-in queue
+	entryID, err := dueReservation(ctx, tx, gameID, open)
+	if err != nil {
+		return 0, err
+	}
+
+	if entryID == 0 {
+		state, err := scheduleState(ctx, tx, gameID)
+		if err != nil {
+			return 0, err
+		}
+		if len(state.Waiting) == 0 {
+			return 0, nil
+		}
+
+		entryID = pick(*state)
+		if entryID == 0 {
+			return 0, nil
+		}
+	}
+
+	var priority int
+	err = tx.QueryRowContext(ctx, `
+		WITH busy AS (
+			SELECT COALESCE(SUM(slots), 0) AS slots
+			FROM queue
+			WHERE game_id = $1 AND status IN ('called', 'active')
+		)
+		UPDATE queue q SET status = 'called', called_at = NOW(), station_id = NULLIF($3, 0)
+		FROM busy, games g
+		WHERE q.id = $2 AND q.game_id = $1 AND q.status = 'waiting'
+			AND g.id = $1 AND busy.slots + q.slots <= g.max_slots
+		RETURNING q.priority
+	`, gameID, entryID, stationID).Scan(&priority)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// keeps the priority lane fair, see placeEntry
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE games
+		SET regular_since_priority = CASE WHEN $2 > 0 THEN 0 ELSE regular_since_priority + 1 END
+		WHERE id = $1
+	`, gameID, priority); err != nil {
+		return 0, err
+	}
+
+	if err := compactPositions(ctx, tx, gameID); err != nil {
+		return 0, err
+	}
+	if err := recordMembers(ctx, tx, entryID); err != nil {
+		return 0, err
+	}
+
+	var details any
+	if stationID != 0 {
+		details = map[string]int{"station_id": stationID}
+	}
+	return entryID, writeEvent(ctx, tx, actorID, "called", gameID, entryID, details)
+}
+
+// recordMembers keeps the accepted members of a party entry as they are
+// when it is called; ratings and history go by this record rather than by
+// whoever is in the party later. A recalled entry is recorded afresh.
+func recordMembers(ctx context.Context, tx *sql.Tx, entryID int) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM entry_members WHERE entry_id = $1`, entryID); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO entry_members (entry_id, user_id)
+		SELECT q.id, pm.user_id
+		FROM queue q
+		JOIN party_members pm ON pm.party_id = q.party_id AND pm.accepted
+		WHERE q.id = $1
+	`, entryID)
+	return err
+}
+
+func (q *Queues) CallNext(ctx context.Context, actorID, gameID, stationID int, pick domain.PickFunc, open domain.OpenFunc) (int, error) {
+	var entryID int
+
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+
+		var err error
+		entryID, err = callNext(ctx, tx, actorID, gameID, stationID, pick, open)
+		if err != nil {
+			return err
+		}
+		if entryID == 0 {
+			return errors.ErrNothingToCall
+		}
+
+		after := map[string]any{"status": domain.StatusCalled}
+		var station int
+		if err := tx.QueryRowContext(ctx, `
+			SELECT COALESCE(station_id, 0) FROM queue WHERE id = $1
+		`, entryID).Scan(&station); err != nil {
+			return err
+		}
+		if station != 0 {
+			after["station_id"] = station
+		}
+		return writeAudit(ctx, tx, actorID, "queue.call", gameID, entryTarget(entryID),
+			map[string]string{"status": domain.StatusWaiting}, after)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return entryID, nil
+}
+
+// setEntryStatus moves an entry of a game from one status to another.
+func setEntryStatus(ctx context.Context, tx *sql.Tx, gameID, entryID int, from, to string) error {
+	res, err := tx.ExecContext(ctx, `
+		UPDATE queue SET status = $4
+		WHERE game_id = $1 AND id = $2 AND status = $3
+	`, gameID, entryID, from, to)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return errors.ErrEntryNotFound
+	}
+	return nil
+}
+
+// MarkArrived confirms that a called player showed up at the stand.
+func (q *Queues) MarkArrived(ctx context.Context, actorID, gameID, entryID int) error {
+	return q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+
+		if err := setEntryStatus(ctx, tx, gameID, entryID, domain.StatusCalled, domain.StatusActive); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE queue SET started_at = NOW() WHERE id = $1`, entryID); err != nil {
+			return err
+		}
+		if err := writeEvent(ctx, tx, actorID, "started", gameID, entryID, nil); err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, actorID, "queue.arrive", gameID, entryTarget(entryID),
+			map[string]string{"status": domain.StatusCalled},
+			map[string]string{"status": domain.StatusActive})
+	})
+}
+
+// FinishEntry ends an active session, recording the score and result the
+// operator entered, if any, and calls the next player into the freed slot.
+// It returns the called entry, or 0.
+func (q *Queues) FinishEntry(ctx context.Context, actorID, gameID, entryID int, res domain.ResultInfo, pick domain.PickFunc, open domain.OpenFunc) (int, error) {
+	var calledID int
+
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+
+		if err := setEntryStatus(ctx, tx, gameID, entryID, domain.StatusActive, domain.StatusFinished); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE queue SET finished_at = NOW(), score = $2, result = $3 WHERE id = $1
+		`, entryID, res.Score, res.Result); err != nil {
+			return err
+		}
+		if err := writeEvent(ctx, tx, actorID, "finished", gameID, entryID, res); err != nil {
+			return err
+		}
+		if err := writeAudit(ctx, tx, actorID, "queue.finish", gameID, entryTarget(entryID),
+			map[string]any{"status": domain.StatusActive},
+			map[string]any{"status": domain.StatusFinished, "score": res.Score, "result": res.Result}); err != nil {
+			return err
+		}
+
+		var err error
+		calledID, err = callFreed(ctx, tx, actorID, gameID, domain.StatusActive, pick, open)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return calledID, nil
+}
+
+// callFreed offers the slot an entry in the given status held to the next
+// player once the entry has left it. Waiting entries hold no slot, so
+// nobody is called for them. It returns the called entry, or 0.
+func callFreed(ctx context.Context, tx *sql.Tx, actorID, gameID int, status string, pick domain.PickFunc, open domain.OpenFunc) (int, error) {
+	if status != domain.StatusCalled && status != domain.StatusActive {
+		return 0, nil
+	}
+	return callNext(ctx, tx, actorID, gameID, 0, pick, open)
+}
+
+func (q *Queues) GetNoShowPolicy(ctx context.Context, gameID int) (*domain.NoShowPolicy, error) {
+	return loadNoShowPolicy(ctx, q.db, gameID)
+}
+
+func loadNoShowPolicy(ctx context.Context, db querier, gameID int) (*domain.NoShowPolicy, error) {
+	var policy domain.NoShowPolicy
+	err := db.QueryRowContext(ctx, `
+		SELECT checkin_grace_seconds, noshow_reinsert_places, noshow_max_skips
+		FROM games WHERE id = $1
+	`, gameID).Scan(&policy.GraceSeconds, &policy.ReinsertPlaces, &policy.MaxSkips)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrGameNotFound
+		}
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+func (q *Queues) SetNoShowPolicy(ctx context.Context, actorID, gameID int, policy domain.NoShowPolicy) error {
+	return q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+
+		before, err := loadNoShowPolicy(ctx, tx, gameID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE games
+			SET checkin_grace_seconds = $2, noshow_reinsert_places = $3, noshow_max_skips = $4
+			WHERE id = $1
+		`, gameID, policy.GraceSeconds, policy.ReinsertPlaces, policy.MaxSkips); err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, actorID, "game.no_show_policy", gameID, gameTarget(gameID), before, policy)
+	})
+}
+
+// ExpireNoShows handles every called entry whose check-in grace period has
+// run out: the entry is skipped and, unless it has used up
+// noshow_max_skips, put back noshow_reinsert_places places behind the head
+// of the line. The freed slot is offered to the next player.
+func (q *Queues) ExpireNoShows(ctx context.Context, pick domain.PickFunc, open domain.OpenFunc) ([]domain.NoShow, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT q.id, q.game_id
+		FROM queue q
+		JOIN games g ON g.id = q.game_id
+		WHERE q.status = 'called'
+			AND q.called_at + make_interval(secs => g.checkin_grace_seconds) < NOW()
+		ORDER BY q.called_at
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	var expired []domain.NoShow
+	for rows.Next() {
+		var ns domain.NoShow
+		if err := rows.Scan(&ns.EntryID, &ns.GameID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		expired = append(expired, ns)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var handled []domain.NoShow
+	for _, ns := range expired {
+		err := q.withTx(ctx, func(tx *sql.Tx) error {
+			if err := lockGame(ctx, tx, ns.GameID); err != nil {
+				return err
+			}
+			return expireNoShow(ctx, tx, &ns, pick, open)
+		})
+		if err == errors.ErrEntryNotFound {
+			// confirmed or removed while we were looking
+			continue
+		}
+		if err != nil {
+			return handled, err
+		}
+		handled = append(handled, ns)
+	}
+
+	return handled, nil
+}
+
+func expireNoShow(ctx context.Context, tx *sql.Tx, ns *domain.NoShow, pick domain.PickFunc, open domain.OpenFunc) error {
+	var skips, places, maxSkips int
+	err := tx.QueryRowContext(ctx, `
+		SELECT q.skip_count + 1, g.noshow_reinsert_places, g.noshow_max_skips
+		FROM queue q
+		JOIN games g ON g.id = q.game_id
+		WHERE q.id = $1 AND q.status = 'called'
+			AND q.called_at + make_interval(secs => g.checkin_grace_seconds) < NOW()
+	`, ns.EntryID).Scan(&skips, &places, &maxSkips)
+	if err == sql.ErrNoRows {
+		return errors.ErrEntryNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// a no-show is always skipped; under the limit it then goes back into
+	// the line behind the next few players
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE queue SET status = 'skipped', called_at = NULL, station_id = NULL, skip_count = $2, position = 0
+		WHERE id = $1
+	`, ns.EntryID, skips); err != nil {
+		return err
+	}
+	if err := writeEvent(ctx, tx, 0, "skipped", ns.GameID, ns.EntryID, map[string]int{"skips": skips}); err != nil {
+		return err
+	}
+
+	if skips > maxSkips {
+		ns.Dropped = true
+	} else {
+		to, err := placeEntryAt(ctx, tx, ns.GameID, places+1, ns.EntryID)
+		if err != nil {
+			return err
+		}
+		if err := setEntryStatus(ctx, tx, ns.GameID, ns.EntryID, domain.StatusSkipped, domain.StatusWaiting); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE queue SET position = $2 WHERE id = $1`, ns.EntryID, to); err != nil {
+			return err
+		}
+		if err := writeEvent(ctx, tx, 0, "requeued", ns.GameID, ns.EntryID, map[string]int{"skips": skips, "position": to}); err != nil {
+			return err
+		}
+	}
+
+	ns.CalledEntryID, err = callNext(ctx, tx, 0, ns.GameID, 0, pick, open)
+	return err
+}
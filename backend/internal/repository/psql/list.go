@@ -0,0 +1,123 @@
+package psql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/DexScen/Queue/backend/internal/errors"
+)
+
+// sortColumn is a column of the inner list query a caller may sort by,
+// together with the SQL type its cursor key is cast back to.
+type sortColumn struct {
+	name string
+	typ  string
+}
+
+// cursorTimeLayouts are the forms timestamps take when cast to text.
+var cursorTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999",
+	"2006-01-02 15:04:05.999999-07",
+	"2006-01-02 15:04:05.999999-07:00",
+}
+
+// validKey tells whether a cursor key casts back to the column's type, so
+// that a tampered cursor is rejected rather than failing in the query.
+func (c sortColumn) validKey(key string) bool {
+	switch c.typ {
+	case "int", "bigint":
+		_, err := strconv.ParseInt(key, 10, 64)
+		return err == nil
+	case "timestamp", "timestamptz":
+		for _, layout := range cursorTimeLayouts {
+			if _, err := time.Parse(layout, key); err == nil {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// listQuery wraps an inner SELECT with filtering and keyset pagination:
+//
+//	SELECT <columns>, <sort>::text FROM (<inner>) t WHERE ... ORDER BY <sort>, <tie> LIMIT n+1
+//
+// The extra row tells whether a next page exists; the trailing text column
+// becomes the cursor key.
+type listQuery struct {
+	conds []string
+	args  []any
+}
+
+func (l *listQuery) arg(v any) string {
+	l.args = append(l.args, v)
+	return "$" + strconv.Itoa(len(l.args))
+}
+
+func (l *listQuery) where(cond string) {
+	l.conds = append(l.conds, cond)
+}
+
+func (l *listQuery) build(inner string, columns []string, filter domain.ListFilter, sorts map[string]sortColumn, defaultSort, tie string) (string, error) {
+	if filter.Sort == "" {
+		filter.Sort = defaultSort
+	}
+	col, ok := sorts[filter.Sort]
+	if !ok {
+		return "", fmt.Errorf("%w: unknown sort %q", errors.ErrInvalidParams, filter.Sort)
+	}
+
+	cmp, dir := ">", "ASC"
+	if filter.Desc {
+		cmp, dir = "<", "DESC"
+	}
+
+	if c := filter.Cursor; c != nil {
+		if c.Sort != filter.Sort {
+			return "", fmt.Errorf("%w: cursor does not match sort", errors.ErrInvalidParams)
+		}
+		if !col.validKey(c.Key) {
+			return "", fmt.Errorf("%w: malformed cursor", errors.ErrInvalidParams)
+		}
+		l.where(fmt.Sprintf("(t.%s, t.%s) %s (CAST(%s AS %s), %s)",
+			col.name, tie, cmp, l.arg(c.Key), col.typ, l.arg(c.ID)))
+	}
+
+	var b strings.Builder
+	b.WriteString("SELECT ")
+	for _, c := range columns {
+		b.WriteString("t." + c + ", ")
+	}
+	fmt.Fprintf(&b, "t.%s::text, t.%s FROM (%s) AS t", col.name, tie, inner)
+	if len(l.conds) > 0 {
+		b.WriteString(" WHERE " + strings.Join(l.conds, " AND "))
+	}
+	fmt.Fprintf(&b, " ORDER BY t.%s %s, t.%s %s", col.name, dir, tie, dir)
+	if filter.Limit > 0 {
+		fmt.Fprintf(&b, " LIMIT %d", filter.Limit+1)
+	}
+	return b.String(), nil
+}
+
+// nextCursor trims the look-ahead row and returns the cursor of the page's
+// last row, or nil when there are no more rows.
+func nextCursor(filter domain.ListFilter, defaultSort string, n int, keys []string, ids []int) (*domain.Cursor, int) {
+	if filter.Limit <= 0 || n <= filter.Limit {
+		return nil, n
+	}
+	if filter.Sort == "" {
+		filter.Sort = defaultSort
+	}
+	last := filter.Limit - 1
+	return &domain.Cursor{Sort: filter.Sort, Key: keys[last], ID: ids[last]}, filter.Limit
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func searchPattern(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
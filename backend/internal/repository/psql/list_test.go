@@ -0,0 +1,55 @@
+package psql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+func TestListQueryCursorKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		sort  string
+		key   string
+		valid bool
+	}{
+		{"int", "position", "12", true},
+		{"int garbage", "position", "12; DROP TABLE queue", false},
+		{"timestamp", "joined_at", "2025-03-01 12:30:00.123456", true},
+		{"timestamp whole seconds", "joined_at", "2025-03-01 12:30:00", true},
+		{"timestamptz", "joined_at", "2025-03-01 12:30:00.5+00", true},
+		{"timestamp garbage", "joined_at", "yesterday", false},
+		{"text", "login", "anything at all", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var l listQuery
+			filter := domain.ListFilter{
+				Limit:  10,
+				Sort:   tt.sort,
+				Cursor: &domain.Cursor{Sort: tt.sort, Key: tt.key, ID: 1},
+			}
+			_, err := l.build("SELECT 1", []string{"id"}, filter, playerSorts, "position", "id")
+			if tt.valid && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, e.ErrInvalidParams) {
+				t.Fatalf("err = %v, want ErrInvalidParams", err)
+			}
+		})
+	}
+}
+
+func TestListQueryCursorSort(t *testing.T) {
+	var l listQuery
+	filter := domain.ListFilter{
+		Limit:  10,
+		Sort:   "login",
+		Cursor: &domain.Cursor{Sort: "position", Key: "3", ID: 1},
+	}
+	if _, err := l.build("SELECT 1", []string{"id"}, filter, playerSorts, "position", "id"); !errors.Is(err, e.ErrInvalidParams) {
+		t.Fatalf("err = %v, want ErrInvalidParams", err)
+	}
+}
@@ -0,0 +1,70 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/DexScen/Queue/backend/internal/errors"
+)
+
+// Ordering model: the position column is the single source of truth for
+// the order of a game's queue. Waiting entries always hold the contiguous
+// ranks 1..N; every other status holds position 0. Each change to a queue
+// runs in a transaction that first locks the game row, so concurrent
+// joins and leaves on the same game are serialized, and ends with
+// compactPositions.
+
+// querier is what *sql.DB and *sql.Tx have in common, for reads that run
+// both on their own and inside a transaction.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func (q *Queues) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return runTx(ctx, q.db, fn)
+}
+
+func runTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tr, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tr); err != nil {
+		tr.Rollback()
+		return err
+	}
+	return tr.Commit()
+}
+
+func lockGame(ctx context.Context, tx *sql.Tx, gameID int) error {
+	var id int
+	err := tx.QueryRowContext(ctx, `SELECT id FROM games WHERE id = $1 FOR UPDATE`, gameID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return errors.ErrGameNotFound
+	}
+	return err
+}
+
+// compactPositions renumbers waiting entries 1..N keeping their relative
+// order and resets every other entry of the game to position 0.
+func compactPositions(ctx context.Context, tx *sql.Tx, gameID int) error {
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE queue q
+		SET position = r.rank
+		FROM (
+			SELECT id, ROW_NUMBER() OVER (ORDER BY position, joined_at, id) AS rank
+			FROM queue
+			WHERE game_id = $1 AND status = 'waiting'
+		) AS r
+		WHERE q.id = r.id AND q.position <> r.rank
+	`, gameID); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		UPDATE queue SET position = 0
+		WHERE game_id = $1 AND status <> 'waiting' AND position <> 0
+	`, gameID)
+	return err
+}
@@ -0,0 +1,200 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"testing"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+func TestCompactPositions(t *testing.T) {
+	type row struct {
+		user     int
+		position int
+		status   string
+	}
+	tests := []struct {
+		name string
+		rows []row
+		want []int // waiting users in order
+	}{
+		{"empty", nil, nil},
+		{"contiguous", []row{{1, 1, "waiting"}, {2, 2, "waiting"}}, []int{1, 2}},
+		{"gaps", []row{{1, 2, "waiting"}, {2, 5, "waiting"}, {3, 9, "waiting"}}, []int{1, 2, 3}},
+		{"reversed", []row{{1, 3, "waiting"}, {2, 2, "waiting"}, {3, 1, "waiting"}}, []int{3, 2, 1}},
+		{"ties keep join order", []row{{1, 1, "waiting"}, {2, 1, "waiting"}, {3, 2, "waiting"}}, []int{1, 2, 3}},
+		{"others go to zero", []row{{1, 4, "called"}, {2, 2, "waiting"}, {3, 7, "left"}, {4, 3, "finished"}}, []int{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, db := testQueues(t)
+			ctx := context.Background()
+			gameID := newTestGame(t, db, 1)
+
+			users := map[int]int{}
+			for _, r := range tt.rows {
+				if _, ok := users[r.user]; !ok {
+					users[r.user] = newTestUser(t, db, "u"+string(rune('a'+r.user)))
+				}
+				if _, err := db.Exec(`
+					INSERT INTO queue (user_id, game_id, position, status) VALUES ($1, $2, $3, $4)
+				`, users[r.user], gameID, r.position, r.status); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := q.withTx(ctx, func(tx *sql.Tx) error {
+				return compactPositions(ctx, tx, gameID)
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			var want []int
+			for _, u := range tt.want {
+				want = append(want, users[u])
+			}
+			if got := waitingUsers(t, db, gameID); !slices.Equal(got, want) {
+				t.Fatalf("waiting = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// pickFirst calls the head of the line.
+func pickFirst(state domain.ScheduleState) int {
+	if len(state.Waiting) == 0 {
+		return 0
+	}
+	return state.Waiting[0].ID
+}
+
+func TestQueuePositions(t *testing.T) {
+	type step struct {
+		op   string // join, leave, call, finish or noshow
+		user int
+	}
+	tests := []struct {
+		name  string
+		steps []step
+		want  []int // waiting users in order after the last step
+	}{
+		{"joins append", []step{{"join", 1}, {"join", 2}, {"join", 3}}, []int{1, 2, 3}},
+		{"leave the head", []step{{"join", 1}, {"join", 2}, {"join", 3}, {"leave", 1}}, []int{2, 3}},
+		{"leave the middle", []step{{"join", 1}, {"join", 2}, {"join", 3}, {"leave", 2}}, []int{1, 3}},
+		{"leave the tail", []step{{"join", 1}, {"join", 2}, {"join", 3}, {"leave", 3}}, []int{1, 2}},
+		{"rejoin goes last", []step{{"join", 1}, {"join", 2}, {"leave", 1}, {"join", 1}}, []int{2, 1}},
+		{"call the head", []step{{"join", 1}, {"join", 2}, {"join", 3}, {"call", 0}}, []int{2, 3}},
+		// a freed slot goes to the next player
+		{"called player leaves", []step{{"join", 1}, {"join", 2}, {"join", 3}, {"call", 0}, {"leave", 1}}, []int{3}},
+		{"waiting player leaves", []step{{"join", 1}, {"join", 2}, {"join", 3}, {"call", 0}, {"leave", 2}}, []int{3}},
+		{"finish", []step{{"join", 1}, {"join", 2}, {"join", 3}, {"call", 0}, {"finish", 0}}, []int{3}},
+		// the default policy puts a no-show 3 places back, then calls user 2
+		{"no-show requeued", []step{{"join", 1}, {"join", 2}, {"join", 3}, {"join", 4}, {"join", 5}, {"call", 0}, {"noshow", 0}}, []int{3, 4, 1, 5}},
+		{"no-show in a short line", []step{{"join", 1}, {"join", 2}, {"call", 0}, {"noshow", 0}}, []int{1}},
+		// the second no-show uses up the only skip allowed
+		{"no-show dropped", []step{{"join", 1}, {"call", 0}, {"noshow", 0}, {"noshow", 0}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, db := testQueues(t)
+			ctx := context.Background()
+			gameID := newTestGame(t, db, 1)
+
+			users := map[int]int{}
+			for _, s := range tt.steps {
+				if _, ok := users[s.user]; !ok && s.user != 0 {
+					users[s.user] = newTestUser(t, db, "u"+string(rune('a'+s.user)))
+				}
+
+				var err error
+				switch s.op {
+				case "join":
+					_, err = q.AddPlayerToQueue(ctx, users[s.user], gameID, nil)
+				case "leave":
+					_, err = q.RemovePlayerFromQueue(ctx, users[s.user], gameID, pickFirst, nil)
+				case "call":
+					_, err = q.CallNext(ctx, 0, gameID, 0, pickFirst, nil)
+				case "finish":
+					var entryID int
+					if err = db.QueryRow(`
+						SELECT id FROM queue WHERE game_id = $1 AND status = 'called'
+					`, gameID).Scan(&entryID); err == nil {
+						if err = q.MarkArrived(ctx, 0, gameID, entryID); err == nil {
+							_, err = q.FinishEntry(ctx, 0, gameID, entryID, domain.ResultInfo{}, pickFirst, nil)
+						}
+					}
+				case "noshow":
+					if _, err = db.Exec(`
+						UPDATE queue SET called_at = NOW() - INTERVAL '1 hour'
+						WHERE game_id = $1 AND status = 'called'
+					`, gameID); err == nil {
+						_, err = q.ExpireNoShows(ctx, pickFirst, nil)
+					}
+				}
+				if err != nil {
+					t.Fatalf("%s %d: %v", s.op, s.user, err)
+				}
+				// positions stay contiguous after every step
+				waitingUsers(t, db, gameID)
+			}
+
+			var want []int
+			for _, u := range tt.want {
+				want = append(want, users[u])
+			}
+			if got := waitingUsers(t, db, gameID); !slices.Equal(got, want) {
+				t.Fatalf("waiting = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestInsertPlayerAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		position int
+		want     []int // waiting users in order, 4 is the inserted one
+	}{
+		{"head", 1, []int{4, 1, 2, 3}},
+		{"middle", 2, []int{1, 4, 2, 3}},
+		{"tail", 4, []int{1, 2, 3, 4}},
+		{"below the line", 0, []int{4, 1, 2, 3}},
+		{"past the line", 10, []int{1, 2, 3, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, db := testQueues(t)
+			ctx := context.Background()
+			gameID := newTestGame(t, db, 1)
+
+			users := map[int]int{}
+			for u := 1; u <= 4; u++ {
+				users[u] = newTestUser(t, db, "u"+string(rune('a'+u)))
+			}
+			for u := 1; u <= 3; u++ {
+				if _, err := q.AddPlayerToQueue(ctx, users[u], gameID, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, to, err := q.InsertPlayerAt(ctx, 0, gameID, users[4], tt.position)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var want []int
+			for _, u := range tt.want {
+				want = append(want, users[u])
+			}
+			got := waitingUsers(t, db, gameID)
+			if !slices.Equal(got, want) {
+				t.Fatalf("waiting = %v, want %v", got, want)
+			}
+			if got[to-1] != users[4] {
+				t.Fatalf("returned position %d, inserted user is elsewhere", to)
+			}
+		})
+	}
+}
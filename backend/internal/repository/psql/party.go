@@ -0,0 +1,411 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/DexScen/Queue/backend/internal/errors"
+	"github.com/lib/pq"
+)
+
+// ensureNotQueued fails if any of the users is already in play in the
+// game's queue, on their own or as a member of a queued party.
+func ensureNotQueued(ctx context.Context, tx *sql.Tx, gameID int, userIDs []int) error {
+	var queued bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM queue q
+			LEFT JOIN party_members pm ON pm.party_id = q.party_id AND pm.accepted
+			WHERE q.game_id = $1
+				AND q.status IN ('waiting', 'called', 'active')
+				AND (q.user_id = ANY($2) OR pm.user_id = ANY($2))
+		)
+	`, gameID, pq.Array(userIDs)).Scan(&queued); err != nil {
+		return err
+	}
+	if queued {
+		return errors.ErrAlreadyInQueue
+	}
+	return nil
+}
+
+func (q *Queues) CreateParty(ctx context.Context, leaderID int, name, code string) (int, error) {
+	var partyID int
+
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO parties (name, code, leader_id) VALUES ($1, $2, $3)
+			RETURNING id
+		`, name, code, leaderID).Scan(&partyID); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO party_members (party_id, user_id, accepted) VALUES ($1, $2, TRUE)
+		`, partyID, leaderID)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return partyID, nil
+}
+
+func (q *Queues) GetParty(ctx context.Context, partyID int) (*domain.Party, error) {
+	var party domain.Party
+	err := q.db.QueryRowContext(ctx, `
+		SELECT p.id, p.name, p.code, p.leader_id, u.login
+		FROM parties p
+		JOIN users u ON u.id = p.leader_id
+		WHERE p.id = $1
+	`, partyID).Scan(&party.ID, &party.Name, &party.Code, &party.LeaderID, &party.Leader)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrPartyNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT u.id, u.login, pm.accepted
+		FROM party_members pm
+		JOIN users u ON u.id = pm.user_id
+		WHERE pm.party_id = $1
+		ORDER BY pm.joined_at
+	`, partyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	party.Members = []domain.PartyMember{}
+	for rows.Next() {
+		var m domain.PartyMember
+		if err := rows.Scan(&m.UserID, &m.Login, &m.Accepted); err != nil {
+			return nil, err
+		}
+		party.Members = append(party.Members, m)
+	}
+
+	return &party, rows.Err()
+}
+
+// InviteToParty adds a pending member; the invitee has to accept.
+func (q *Queues) InviteToParty(ctx context.Context, partyID, userID int) error {
+	_, err := q.db.ExecContext(ctx, `
+		INSERT INTO party_members (party_id, user_id, accepted) VALUES ($1, $2, FALSE)
+		ON CONFLICT (party_id, user_id) DO NOTHING
+	`, partyID, userID)
+	return err
+}
+
+// Membership and queue entries: a queued party takes one slot per accepted
+// member, so joining or leaving resizes its waiting entries. While the
+// party is called or playing somewhere its membership is locked.
+
+// lockParty locks a party and then the games it is queued for, the order
+// AddPartyToQueue also takes them in, and returns those games.
+func lockParty(ctx context.Context, tx *sql.Tx, partyID int) ([]int, error) {
+	var id int
+	err := tx.QueryRowContext(ctx, `SELECT id FROM parties WHERE id = $1 FOR UPDATE`, partyID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrPartyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var games []int64
+	if err := tx.QueryRowContext(ctx, `
+		SELECT ARRAY(
+			SELECT DISTINCT game_id FROM queue
+			WHERE party_id = $1 AND status IN ('waiting', 'called', 'active')
+			ORDER BY game_id
+		)
+	`, partyID).Scan(pq.Array(&games)); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, len(games))
+	for i, g := range games {
+		ids[i] = int(g)
+		if err := lockGame(ctx, tx, ids[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	var playing bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM queue WHERE party_id = $1 AND status IN ('called', 'active'))
+	`, partyID).Scan(&playing); err != nil {
+		return nil, err
+	}
+	if playing {
+		return nil, errors.ErrPartyInPlay
+	}
+	return ids, nil
+}
+
+// resizePartyEntries gives the party's waiting entries one slot per
+// accepted member, as long as that still fits each game, and records why
+// they changed.
+func resizePartyEntries(ctx context.Context, tx *sql.Tx, partyID, userID int, kind string) error {
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE queue q SET slots = m.members
+		FROM (SELECT COUNT(*) AS members FROM party_members WHERE party_id = $1 AND accepted) m, games g
+		WHERE q.party_id = $1 AND q.status = 'waiting' AND g.id = q.game_id
+		RETURNING q.id, q.game_id, q.slots, g.max_slots
+	`, partyID)
+	if err != nil {
+		return err
+	}
+
+	type resized struct{ entryID, gameID, slots, maxSlots int }
+	var entries []resized
+	for rows.Next() {
+		var r resized
+		if err := rows.Scan(&r.entryID, &r.gameID, &r.slots, &r.maxSlots); err != nil {
+			rows.Close()
+			return err
+		}
+		entries = append(entries, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, r := range entries {
+		if r.slots > r.maxSlots {
+			return errors.ErrPartyTooLarge
+		}
+		if err := writeEvent(ctx, tx, userID, kind, r.gameID, r.entryID, map[string]int{
+			"party_id": partyID,
+			"user_id":  userID,
+			"slots":    r.slots,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// acceptMember makes the user an accepted member of a locked party and
+// resizes its entries. A user queued on their own for a game the party is
+// queued for cannot join, or they would be counted twice; neither can a
+// user banned from one of those games.
+func acceptMember(ctx context.Context, tx *sql.Tx, partyID, userID int, games []int) error {
+	for _, gameID := range games {
+		if err := ensureNotQueued(ctx, tx, gameID, []int{userID}); err != nil {
+			return err
+		}
+		if err := checkBan(ctx, tx, userID, gameID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO party_members (party_id, user_id, accepted) VALUES ($1, $2, TRUE)
+		ON CONFLICT (party_id, user_id) DO UPDATE SET accepted = TRUE
+	`, partyID, userID); err != nil {
+		return err
+	}
+	return resizePartyEntries(ctx, tx, partyID, userID, "party_joined")
+}
+
+func isAcceptedMember(ctx context.Context, tx *sql.Tx, partyID, userID int) (bool, bool, error) {
+	var accepted bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT accepted FROM party_members WHERE party_id = $1 AND user_id = $2
+	`, partyID, userID).Scan(&accepted)
+	if err == sql.ErrNoRows {
+		return false, false, nil
+	}
+	return true, accepted, err
+}
+
+// JoinPartyByCode makes the user an accepted member of the party with the
+// code. It returns the party and the games whose queue changed.
+func (q *Queues) JoinPartyByCode(ctx context.Context, userID int, code string) (int, []int, error) {
+	var partyID int
+	var games []int
+
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `SELECT id FROM parties WHERE code = $1`, code).Scan(&partyID)
+		if err == sql.ErrNoRows {
+			return errors.ErrPartyNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if games, err = lockParty(ctx, tx, partyID); err != nil {
+			return err
+		}
+		if _, accepted, err := isAcceptedMember(ctx, tx, partyID, userID); err != nil || accepted {
+			games = nil
+			return err
+		}
+		return acceptMember(ctx, tx, partyID, userID, games)
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return partyID, games, nil
+}
+
+// AcceptPartyInvite accepts a pending invitation. It returns the games
+// whose queue changed.
+func (q *Queues) AcceptPartyInvite(ctx context.Context, partyID, userID int) ([]int, error) {
+	var games []int
+
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		if games, err = lockParty(ctx, tx, partyID); err != nil {
+			return err
+		}
+
+		invited, accepted, err := isAcceptedMember(ctx, tx, partyID, userID)
+		if err != nil {
+			return err
+		}
+		if !invited {
+			return errors.ErrPartyNotFound
+		}
+		if accepted {
+			games = nil
+			return nil
+		}
+		return acceptMember(ctx, tx, partyID, userID, games)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return games, nil
+}
+
+// LeaveParty removes a member. The leader cannot leave; the party goes
+// away with them through ON DELETE CASCADE only. It returns the games
+// whose queue changed.
+func (q *Queues) LeaveParty(ctx context.Context, partyID, userID int) ([]int, error) {
+	var games []int
+
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		if games, err = lockParty(ctx, tx, partyID); err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, `
+			DELETE FROM party_members pm
+			USING parties p
+			WHERE p.id = pm.party_id AND pm.party_id = $1 AND pm.user_id = $2 AND p.leader_id <> $2
+		`, partyID, userID)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return errors.ErrPartyNotFound
+		}
+
+		return resizePartyEntries(ctx, tx, partyID, userID, "party_left")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return games, nil
+}
+
+// AddPartyToQueue queues the party as one entry under its leader that
+// takes one slot per accepted member when called.
+func (q *Queues) AddPartyToQueue(ctx context.Context, partyID, gameID int, open domain.OpenFunc) (int, error) {
+	var position int
+
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		// the party before the game, as lockParty does
+		var locked int
+		err := tx.QueryRowContext(ctx, `SELECT id FROM parties WHERE id = $1 FOR UPDATE`, partyID).Scan(&locked)
+		if err == sql.ErrNoRows {
+			return errors.ErrPartyNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+		if err := checkOpen(ctx, tx, gameID, open); err != nil {
+			return err
+		}
+
+		var leaderID, maxSlots int
+		var members []int64
+		if err := tx.QueryRowContext(ctx, `
+			SELECT p.leader_id, g.max_slots, ARRAY(
+				SELECT user_id FROM party_members WHERE party_id = p.id AND accepted
+			)
+			FROM parties p, games g
+			WHERE p.id = $1 AND g.id = $2
+		`, partyID, gameID).Scan(&leaderID, &maxSlots, pq.Array(&members)); err != nil {
+			if err == sql.ErrNoRows {
+				return errors.ErrPartyNotFound
+			}
+			return err
+		}
+		if len(members) > maxSlots {
+			return errors.ErrPartyTooLarge
+		}
+
+		ids := make([]int, len(members))
+		for i, m := range members {
+			ids[i] = int(m)
+		}
+		if err := ensureNotQueued(ctx, tx, gameID, ids); err != nil {
+			return err
+		}
+		// one banned member keeps the whole party out
+		for _, id := range ids {
+			if err := checkBan(ctx, tx, id, gameID); err != nil {
+				return err
+			}
+		}
+
+		priority, err := userPriority(ctx, tx, leaderID)
+		if err != nil {
+			return err
+		}
+
+		position, err = placeEntry(ctx, tx, gameID, priority, 0)
+		if err != nil {
+			return err
+		}
+
+		var entryID int
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO queue (user_id, game_id, party_id, slots, position, status, priority)
+			VALUES ($1, $2, $3, $4, $5, 'waiting', $6)
+			RETURNING id
+		`, leaderID, gameID, partyID, len(members), position, priority).Scan(&entryID); err != nil {
+			return err
+		}
+
+		return writeEvent(ctx, tx, 0, "joined", gameID, entryID, map[string]int{
+			"position": position,
+			"party_id": partyID,
+			"slots":    len(members),
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return position, nil
+}
@@ -0,0 +1,146 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+func TestPartyMembershipResizesEntry(t *testing.T) {
+	q, db := testQueues(t)
+	ctx := context.Background()
+	gameID := newTestGame(t, db, 4)
+	leader := newTestUser(t, db, "leader")
+	member := newTestUser(t, db, "member")
+
+	partyID, err := q.CreateParty(ctx, leader, "team", "TEAM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := q.JoinPartyByCode(ctx, member, "TEAM"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.AddPartyToQueue(ctx, partyID, gameID, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	slots := func() int {
+		t.Helper()
+		var n int
+		if err := db.QueryRow(`
+			SELECT slots FROM queue WHERE party_id = $1 AND status = 'waiting'
+		`, partyID).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	if n := slots(); n != 2 {
+		t.Fatalf("slots = %d, want 2", n)
+	}
+
+	games, err := q.LeaveParty(ctx, partyID, member)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 1 || games[0] != gameID {
+		t.Fatalf("changed games = %v, want [%d]", games, gameID)
+	}
+	if n := slots(); n != 1 {
+		t.Fatalf("slots after leaving = %d, want 1", n)
+	}
+
+	// on their own now, and counted only once
+	if _, err := q.AddPlayerToQueue(ctx, member, gameID, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := q.JoinPartyByCode(ctx, member, "TEAM"); !errors.Is(err, e.ErrAlreadyInQueue) {
+		t.Fatalf("rejoining the queued party: err = %v, want ErrAlreadyInQueue", err)
+	}
+	if n := slots(); n != 1 {
+		t.Fatalf("slots after a refused join = %d, want 1", n)
+	}
+
+	var events int
+	if err := db.QueryRow(`
+		SELECT COUNT(*) FROM queue_events WHERE game_id = $1 AND type IN ('party_joined', 'party_left')
+	`, gameID).Scan(&events); err != nil {
+		t.Fatal(err)
+	}
+	if events != 1 {
+		t.Fatalf("membership events = %d, want 1", events)
+	}
+
+	if _, err := q.CallNext(ctx, 0, gameID, 0, pickFirst, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.RemovePlayerFromQueue(ctx, member, gameID, pickFirst, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := q.JoinPartyByCode(ctx, member, "TEAM"); !errors.Is(err, e.ErrPartyInPlay) {
+		t.Fatalf("joining a called party: err = %v, want ErrPartyInPlay", err)
+	}
+}
+
+func TestPartyEntryKeepsCalledMembers(t *testing.T) {
+	q, db := testQueues(t)
+	ctx := context.Background()
+	gameID := newTestGame(t, db, 4)
+	leader := newTestUser(t, db, "leader")
+	member := newTestUser(t, db, "member")
+	late := newTestUser(t, db, "late")
+
+	partyID, err := q.CreateParty(ctx, leader, "team", "TEAM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := q.JoinPartyByCode(ctx, member, "TEAM"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.AddPartyToQueue(ctx, partyID, gameID, nil); err != nil {
+		t.Fatal(err)
+	}
+	entryID, err := q.CallNext(ctx, 0, gameID, 0, pickFirst, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE queue SET status = 'finished', finished_at = NOW() WHERE id = $1`, entryID); err != nil {
+		t.Fatal(err)
+	}
+
+	// the party changes after the session
+	if _, err := q.LeaveParty(ctx, partyID, member); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := q.JoinPartyByCode(ctx, late, "TEAM"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		userID  int
+		wantErr error
+	}{
+		{"leader", leader, nil},
+		{"member who left", member, nil},
+		{"member who joined later", late, e.ErrNotFinished},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := q.RateGame(ctx, tt.userID, gameID, domain.RatingInfo{Stars: 5})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("rate: err = %v, want %v", err, tt.wantErr)
+			}
+
+			var history domain.ListHistoryEntries
+			if _, err := q.GetUserHistory(ctx, tt.userID, domain.ListFilter{}, &history); err != nil {
+				t.Fatal(err)
+			}
+			if inHistory := len(history) == 1 && history[0].EntryID == entryID; inHistory != (tt.wantErr == nil) {
+				t.Fatalf("history = %+v", history)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/DexScen/Queue/backend/internal/errors"
+)
+
+// Priority lanes: an entry with priority > 0 is placed so that at least
+// priority_every regular players are served between two priority ones.
+// Counting starts after the last priority entry still waiting, or, if there
+// is none, from the regular players called since the last priority one
+// (games.regular_since_priority). Among priority entries the order stays
+// first come, first served. A game with priority_every = 0 has no lane and
+// treats everyone as regular.
+
+// placeEntry picks the position a new waiting entry of the given priority
+// gets and shifts everyone from there on back by one. excludeID is an entry
+// that is being re-placed and must not be counted. The game must already
+// be locked.
+func placeEntry(ctx context.Context, tx *sql.Tx, gameID, priority, excludeID int) (int, error) {
+	var total, every, regularSince, lastPriority int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM queue
+				WHERE game_id = $1 AND status = 'waiting' AND id <> $2),
+			g.priority_every,
+			g.regular_since_priority,
+			(SELECT COALESCE(MAX(position), 0) FROM queue
+				WHERE game_id = $1 AND status = 'waiting' AND priority > 0 AND id <> $2)
+		FROM games g
+		WHERE g.id = $1
+	`, gameID, excludeID).Scan(&total, &every, &regularSince, &lastPriority); err != nil {
+		return 0, err
+	}
+
+	position := total + 1
+	if priority > 0 && every > 0 {
+		if lastPriority > 0 {
+			position = clamp(lastPriority+every+1, 1, total+1)
+		} else {
+			position = clamp(every-regularSince+1, 1, total+1)
+		}
+	}
+
+	return position, makeRoom(ctx, tx, gameID, position, total, excludeID)
+}
+
+// placeEntryAt is placeEntry for a position chosen by the caller, e.g. by
+// staff or the no-show policy. The position is clamped to the line.
+func placeEntryAt(ctx context.Context, tx *sql.Tx, gameID, position, excludeID int) (int, error) {
+	var total int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM queue WHERE game_id = $1 AND status = 'waiting' AND id <> $2
+	`, gameID, excludeID).Scan(&total); err != nil {
+		return 0, err
+	}
+
+	position = clamp(position, 1, total+1)
+	return position, makeRoom(ctx, tx, gameID, position, total, excludeID)
+}
+
+// makeRoom shifts the waiting entries from position on back by one.
+func makeRoom(ctx context.Context, tx *sql.Tx, gameID, position, total, excludeID int) error {
+	if position > total {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, `
+		UPDATE queue SET position = position + 1
+		WHERE game_id = $1 AND status = 'waiting' AND position >= $2 AND id <> $3
+	`, gameID, position, excludeID)
+	return err
+}
+
+func userPriority(ctx context.Context, tx *sql.Tx, userID int) (int, error) {
+	var priority int
+	err := tx.QueryRowContext(ctx, `SELECT priority FROM users WHERE id = $1`, userID).Scan(&priority)
+	if err == sql.ErrNoRows {
+		return 0, errors.ErrUserNotFound
+	}
+	return priority, err
+}
+
+// SetEntryPriority changes the priority of a waiting entry and places it
+// again according to the lane rules.
+func (q *Queues) SetEntryPriority(ctx context.Context, actorID, gameID, entryID, priority int) (int, error) {
+	var to int
+
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+
+		from, _, err := waitingPosition(ctx, tx, gameID, entryID)
+		if err != nil {
+			return err
+		}
+		var was int
+		if err := tx.QueryRowContext(ctx, `SELECT priority FROM queue WHERE id = $1`, entryID).Scan(&was); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE queue SET position = position - 1
+			WHERE game_id = $1 AND status = 'waiting' AND position > $2
+		`, gameID, from); err != nil {
+			return err
+		}
+
+		to, err = placeEntry(ctx, tx, gameID, priority, entryID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE queue SET priority = $2, position = $3 WHERE id = $1
+		`, entryID, priority, to); err != nil {
+			return err
+		}
+
+		if err := compactPositions(ctx, tx, gameID); err != nil {
+			return err
+		}
+
+		if err := writeEvent(ctx, tx, actorID, "priority", gameID, entryID, map[string]int{
+			"priority": priority,
+			"from":     from,
+			"to":       to,
+		}); err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, actorID, "queue.priority", gameID, entryTarget(entryID),
+			map[string]int{"priority": was, "position": from},
+			map[string]int{"priority": priority, "position": to})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return to, nil
+}
+
+func (q *Queues) SetUserPriority(ctx context.Context, actorID, userID, priority int) error {
+	return q.withTx(ctx, func(tx *sql.Tx) error {
+		var was int
+		err := tx.QueryRowContext(ctx, `
+			UPDATE users u SET priority = $2
+			FROM (SELECT id, priority FROM users WHERE id = $1) old
+			WHERE u.id = old.id
+			RETURNING old.priority
+		`, userID, priority).Scan(&was)
+		if err == sql.ErrNoRows {
+			return errors.ErrUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, actorID, "user.priority", 0, userTarget(userID),
+			map[string]int{"priority": was},
+			map[string]int{"priority": priority})
+	})
+}
+
+func (q *Queues) GetPriorityPolicy(ctx context.Context, gameID int) (*domain.PriorityPolicy, error) {
+	return loadPriorityPolicy(ctx, q.db, gameID)
+}
+
+func loadPriorityPolicy(ctx context.Context, db querier, gameID int) (*domain.PriorityPolicy, error) {
+	var policy domain.PriorityPolicy
+	err := db.QueryRowContext(ctx, `
+		SELECT priority_every FROM games WHERE id = $1
+	`, gameID).Scan(&policy.Every)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrGameNotFound
+		}
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+func (q *Queues) SetPriorityPolicy(ctx context.Context, actorID, gameID int, policy domain.PriorityPolicy) error {
+	return q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+
+		before, err := loadPriorityPolicy(ctx, tx, gameID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE games SET priority_every = $2 WHERE id = $1
+		`, gameID, policy.Every); err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, actorID, "game.priority_policy", gameID, gameTarget(gameID), before, policy)
+	})
+}
@@ -3,10 +3,11 @@ package psql
 import (
 	"context"
 	"database/sql"
+	"strings"
 
 	"github.com/DexScen/Queue/backend/internal/domain"
 	"github.com/DexScen/Queue/backend/internal/errors"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type Queues struct {
@@ -21,26 +22,31 @@ func (q *Queues) GetGameInfoByID(ctx context.Context, id int) (*domain.Game, err
     query := `
         SELECT 
             g.id,
+            COALESCE(g.event_id, 0),
             g.name,
             g.description,
             g.max_slots,
             g.duration_seconds,
-            COALESCE(COUNT(q.id), 0) AS current_people
+            COALESCE(COUNT(q.id), 0) AS current_people,
+            ` + gameRatingColumns + `
         FROM games g
         LEFT JOIN queue q 
             ON g.id = q.game_id AND q.status = 'waiting'
         WHERE g.id = $1
-        GROUP BY g.id, g.name, g.description, g.max_slots, g.duration_seconds
+        GROUP BY g.id, g.event_id, g.name, g.description, g.max_slots, g.duration_seconds
     `
 
     var result domain.Game
     err := q.db.QueryRowContext(ctx, query, id).Scan(
         &result.ID,
+        &result.EventID,
         &result.Name,
         &result.Description,
         &result.Max_slots,
         &result.Duration_seconds,
         &result.Current_people,
+        &result.Rating,
+        &result.RatingCount,
     )
     if err != nil {
         if err == sql.ErrNoRows {
@@ -53,78 +59,142 @@ func (q *Queues) GetGameInfoByID(ctx context.Context, id int) (*domain.Game, err
 }
 
 
-func (q *Queues) GetAllGames(ctx context.Context, listGames *domain.ListGames) error {
-    rows, err := q.db.QueryContext(ctx, `
-        SELECT 
-            g.id,
-            g.name,
-            g.description,
-            g.max_slots,
-            g.duration_seconds,
-            COALESCE(COUNT(q.id), 0) AS current_people
-        FROM games g
-        LEFT JOIN queue q 
-            ON g.id = q.game_id AND q.status = 'waiting'
-        GROUP BY g.id, g.name, g.description, g.max_slots, g.duration_seconds
-        ORDER BY g.id;
-    `)
-    if err != nil {
-        return err
-    }
-    defer rows.Close()
-
-    for rows.Next() {
-        var game domain.Game
-        if err := rows.Scan(
-            &game.ID,
-            &game.Name,
-            &game.Description,
-            &game.Max_slots,
-            &game.Duration_seconds,
-            &game.Current_people,
-        ); err != nil {
-            return err
-        }
+var gameSorts = map[string]sortColumn{
+	"id":             {"id", "int"},
+	"name":           {"name", "text"},
+	"current_people": {"current_people", "bigint"},
+}
 
-        *listGames = append(*listGames, game)
-    }
+func (q *Queues) GetAllGames(ctx context.Context, filter domain.ListFilter, listGames *domain.ListGames) (*domain.Cursor, error) {
+	var l listQuery
+	if filter.Search != "" {
+		l.where("t.name ILIKE " + l.arg(searchPattern(filter.Search)))
+	}
+	if filter.EventID != 0 {
+		l.where("t.event_id = " + l.arg(filter.EventID))
+	}
+
+	query, err := l.build(`
+		SELECT
+			g.id,
+			COALESCE(g.event_id, 0) AS event_id,
+			g.name,
+			g.description,
+			g.max_slots,
+			g.duration_seconds,
+			(SELECT COUNT(*) FROM queue w WHERE w.game_id = g.id AND w.status = 'waiting') AS current_people,
+			`+gameRatingColumns+`
+		FROM games g
+	`, []string{"id", "event_id", "name", "description", "max_slots", "duration_seconds", "current_people", "rating", "rating_count"},
+		filter, gameSorts, "id", "id")
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.db.QueryContext(ctx, query, l.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	var ids []int
+	for rows.Next() {
+		var game domain.Game
+		var key string
+		var id int
+		if err := rows.Scan(
+			&game.ID,
+			&game.EventID,
+			&game.Name,
+			&game.Description,
+			&game.Max_slots,
+			&game.Duration_seconds,
+			&game.Current_people,
+			&game.Rating,
+			&game.RatingCount,
+			&key,
+			&id,
+		); err != nil {
+			return nil, err
+		}
 
-    return rows.Err()
+		*listGames = append(*listGames, game)
+		keys = append(keys, key)
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor, n := nextCursor(filter, "id", len(ids), keys, ids)
+	*listGames = (*listGames)[:n]
+	return cursor, nil
 }
 
-func (q *Queues) GetGamesByLogin(ctx context.Context, login string, listGames *domain.ListGameInfos) error {
-	rows, err := q.db.QueryContext(ctx, `
-		SELECT 
+var userGameSorts = map[string]sortColumn{
+	"game":      {"id", "int"},
+	"name":      {"name", "text"},
+	"joined_at": {"joined_at", "timestamptz"},
+	"position":  {"position", "int"},
+}
+
+func (q *Queues) GetGamesByLogin(ctx context.Context, login string, filter domain.ListFilter, listGames *domain.ListGameInfos) (*domain.Cursor, error) {
+	var l listQuery
+	loginArg := l.arg(login)
+	if filter.Status != "" {
+		l.where("t.status = ANY(" + l.arg(pq.Array(strings.Split(filter.Status, ","))) + ")")
+	} else {
+		l.where("t.status <> 'left'")
+	}
+	if filter.JoinedAfter != nil {
+		l.where("t.joined_at > " + l.arg(*filter.JoinedAfter))
+	}
+	if filter.Search != "" {
+		l.where("t.name ILIKE " + l.arg(searchPattern(filter.Search)))
+	}
+	if filter.EventID != 0 {
+		l.where("t.event_id = " + l.arg(filter.EventID))
+	}
+
+	query, err := l.build(`
+		SELECT
 			g.id,
+			g.event_id,
 			g.name,
 			g.description,
 			g.max_slots,
 			g.duration_seconds,
-			COALESCE(COUNT(q2.id), 0) AS current_people,
-			COALESCE(pos.position, 0) AS user_position
+			(SELECT COUNT(*) FROM queue w WHERE w.game_id = g.id AND w.status = 'waiting') AS current_people,
+			q1.position,
+			q1.status,
+			q1.joined_at,
+			COALESCE((SELECT s.name FROM stations s WHERE s.id = q1.station_id), '') AS station,
+			q1.id AS entry_id
 		FROM users u
-		JOIN queue q1 ON u.id = q1.user_id
+		JOIN queue q1 ON u.id = q1.user_id OR q1.party_id IN (
+			SELECT party_id FROM party_members WHERE user_id = u.id AND accepted
+		)
 		JOIN games g ON q1.game_id = g.id
-		LEFT JOIN queue q2 ON g.id = q2.game_id AND q2.status = 'waiting'
-		LEFT JOIN (
-			SELECT 
-				game_id,
-				user_id,
-				ROW_NUMBER() OVER (PARTITION BY game_id ORDER BY joined_at) AS position
-			FROM queue
-			WHERE status = 'waiting'
-		) AS pos ON pos.game_id = g.id AND pos.user_id = u.id
-		WHERE u.login = $1
-		GROUP BY g.id, g.name, g.description, g.max_slots, g.duration_seconds, pos.position
-		ORDER BY g.id;
-	`, login)
+		WHERE u.login = `+loginArg,
+		[]string{"id", "name", "description", "max_slots", "duration_seconds", "current_people", "position", "status", "joined_at", "station"},
+		filter, userGameSorts, "game", "entry_id")
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	rows, err := q.db.QueryContext(ctx, query, l.args...)
+	if err != nil {
+		return nil, err
 	}
 	defer rows.Close()
 
+	var keys []string
+	var ids []int
 	for rows.Next() {
 		var game domain.GameInfo
+		var key string
+		var id int
 		if err := rows.Scan(
 			&game.ID,
 			&game.Name,
@@ -133,16 +203,27 @@ func (q *Queues) GetGamesByLogin(ctx context.Context, login string, listGames *d
 			&game.Duration_seconds,
 			&game.Current_people,
 			&game.Position,
+			&game.Status,
+			&game.JoinedAt,
+			&game.Station,
+			&key,
+			&id,
 		); err != nil {
-			return err
+			return nil, err
 		}
 		*listGames = append(*listGames, game)
+		keys = append(keys, key)
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return rows.Err()
+	cursor, n := nextCursor(filter, "game", len(ids), keys, ids)
+	*listGames = (*listGames)[:n]
+	return cursor, nil
 }
 
-
 func (q *Queues) GetPassword(ctx context.Context, login string) (string, error) {
 	tr, err := q.db.Begin()
 	if err != nil {
@@ -248,49 +329,111 @@ func (q *Queues) Register(ctx context.Context, user *domain.User) error {
 	return tr.Commit()
 }
 
-func (q *Queues) RemovePlayerFromQueue(ctx context.Context, user_id, game_id int) error{
-	tr, err := q.db.Begin()
-	if err != nil {
-		return err
-	}
-	statement, err := tr.Prepare("DELETE FROM queue WHERE user_id = $1 AND game_id = $2")
-	if err != nil {
-		tr.Rollback()
-		return err
-	}
-	defer statement.Close()
+// RemovePlayerFromQueue takes the user out of the game's queue and, if
+// they held a slot, calls the next player into it. It returns the called
+// entry, or 0.
+func (q *Queues) RemovePlayerFromQueue(ctx context.Context, user_id, game_id int, pick domain.PickFunc, open domain.OpenFunc) (int, error) {
+	var calledID int
 
-	_, err = statement.Exec(user_id, game_id)
-	if err != nil {
-		tr.Rollback()
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, game_id); err != nil {
+			return err
+		}
+
+		rows, err := tx.QueryContext(ctx, `
+			UPDATE queue q SET status = 'left', left_at = NOW(), position = 0
+			FROM (
+				SELECT id, status FROM queue
+				WHERE user_id = $1 AND game_id = $2 AND status IN ('waiting', 'called', 'active')
+			) old
+			WHERE q.id = old.id
+			RETURNING q.id, old.status
+		`, user_id, game_id)
+		if err != nil {
+			return err
+		}
+		left := map[int]string{}
+		for rows.Next() {
+			var id int
+			var status string
+			if err := rows.Scan(&id, &status); err != nil {
+				rows.Close()
+				return err
+			}
+			left[id] = status
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		freed := domain.StatusWaiting
+		for id, status := range left {
+			if err := writeEvent(ctx, tx, 0, "left", game_id, id, nil); err != nil {
+				return err
+			}
+			if status != domain.StatusWaiting {
+				freed = status
+			}
+		}
+
+		if err := compactPositions(ctx, tx, game_id); err != nil {
+			return err
+		}
+
+		calledID, err = callFreed(ctx, tx, 0, game_id, freed, pick, open)
 		return err
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	return tr.Commit()
+	return calledID, nil
 }
 
-func (q *Queues) AddPlayerToQueue(ctx context.Context, userID, gameID int) (int, error) {
-    var position int
+func (q *Queues) AddPlayerToQueue(ctx context.Context, userID, gameID int, open domain.OpenFunc) (int, error) {
+	var position int
 
-    err := q.db.QueryRowContext(ctx, `
-        SELECT COALESCE(MAX(position), 0) + 1
-        FROM queue
-        WHERE game_id = $1
-    `, gameID).Scan(&position)
-    if err != nil {
-        return 0, err
-    }
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+		if err := checkOpen(ctx, tx, gameID, open); err != nil {
+			return err
+		}
+		if err := ensureNotQueued(ctx, tx, gameID, []int{userID}); err != nil {
+			return err
+		}
+		if err := checkBan(ctx, tx, userID, gameID); err != nil {
+			return err
+		}
 
-    // вставляем нового игрока
-    _, err = q.db.ExecContext(ctx, `
-        INSERT INTO queue (user_id, game_id, position, status)
-        VALUES ($1, $2, $3, 'waiting')
-    `, userID, gameID, position)
-    if err != nil {
-        return 0, err
-    }
+		priority, err := userPriority(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+
+		position, err = placeEntry(ctx, tx, gameID, priority, 0)
+		if err != nil {
+			return err
+		}
 
-    return position, nil
+		var entryID int
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO queue (user_id, game_id, position, status, priority)
+			VALUES ($1, $2, $3, 'waiting', $4)
+			RETURNING id
+		`, userID, gameID, position, priority).Scan(&entryID); err != nil {
+			return err
+		}
+
+		return writeEvent(ctx, tx, 0, "joined", gameID, entryID, map[string]int{"position": position})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return position, nil
 }
 
 func (q *Queues) GetIdByLogin(ctx context.Context, login string) (int, error) {
@@ -310,27 +453,127 @@ func (q *Queues) GetIdByLogin(ctx context.Context, login string) (int, error) {
     return id, nil
 }
 
-func (q *Queues) GetPlayersByGameID(ctx context.Context, gameID int, listUsers *domain.ListUsers) error {
-    rows, err := q.db.QueryContext(ctx, `
-        SELECT u.id, u.login
-        FROM queue q
-        JOIN users u ON q.user_id = u.id
-        WHERE q.game_id = $1
-    `, gameID)
-    if err != nil {
-        return err
-    }
-    defer rows.Close()
+var playerSorts = map[string]sortColumn{
+	"position":  {"position", "int"},
+	"joined_at": {"joined_at", "timestamptz"},
+	"login":     {"login", "text"},
+}
 
-    var users domain.ListUsers
-    for rows.Next() {
-        var u domain.User
-        if err := rows.Scan(&u.ID, &u.Login); err != nil {
-            return err
-        }
-        users = append(users, u)
-    }
+// GetPlayersByGameID lists queue entries of a game. Without a status filter
+// only entries still in play (waiting, called or active) are returned.
+func (q *Queues) GetPlayersByGameID(ctx context.Context, gameID int, filter domain.ListFilter, listEntries *domain.ListQueueEntries) (*domain.Cursor, error) {
+	var l listQuery
+	gameArg := l.arg(gameID)
+	if filter.Status != "" {
+		l.where("t.status = ANY(" + l.arg(pq.Array(strings.Split(filter.Status, ","))) + ")")
+	} else {
+		l.where("t.status IN ('waiting', 'called', 'active')")
+	}
+	if filter.JoinedAfter != nil {
+		l.where("t.joined_at > " + l.arg(*filter.JoinedAfter))
+	}
+	if filter.Search != "" {
+		pattern := l.arg(searchPattern(filter.Search))
+		l.where("(t.login ILIKE " + pattern + " OR t.ticket ILIKE " + pattern + " OR t.nickname ILIKE " + pattern + ")")
+	}
+
+	query, err := l.build(`
+		SELECT `+entryColumns+`
+		FROM queue q
+		LEFT JOIN users u ON q.user_id = u.id
+		WHERE q.game_id = `+gameArg,
+		entryFields, filter, playerSorts, "position", "id")
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.db.QueryContext(ctx, query, l.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries domain.ListQueueEntries
+	var keys []string
+	var ids []int
+	for rows.Next() {
+		var entry domain.QueueEntry
+		var key string
+		var id int
+		if err := scanEntry(rows, &entry, &key, &id); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+		keys = append(keys, key)
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor, n := nextCursor(filter, "position", len(ids), keys, ids)
+	*listEntries = entries[:n]
+	return cursor, nil
+}
+
+func (q *Queues) CountBusySlots(ctx context.Context, gameID int) (int, error) {
+	var busy int
+	err := q.db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(slots), 0) FROM queue WHERE game_id = $1 AND status IN ('called', 'active')
+	`, gameID).Scan(&busy)
+	return busy, err
+}
+
+// GetWaitingSlots returns the slots of the waiting entries of a game in
+// queue order.
+func (q *Queues) GetWaitingSlots(ctx context.Context, gameID int) ([]int, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT slots FROM queue WHERE game_id = $1 AND status = 'waiting' ORDER BY position, id
+	`, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var slots []int
+	for rows.Next() {
+		var n int
+		if err := rows.Scan(&n); err != nil {
+			return nil, err
+		}
+		slots = append(slots, n)
+	}
+	return slots, rows.Err()
+}
+
+func (q *Queues) GetUserByLogin(ctx context.Context, login string) (*domain.User, error) {
+	var user domain.User
+	err := q.db.QueryRowContext(ctx,
+		`SELECT id, login, role FROM users WHERE login = $1`,
+		login,
+	).Scan(&user.ID, &user.Login, &user.Role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (q *Queues) GetUserByID(ctx context.Context, id int) (*domain.User, error) {
+	var user domain.User
+	err := q.db.QueryRowContext(ctx,
+		`SELECT id, login, role FROM users WHERE id = $1`,
+		id,
+	).Scan(&user.ID, &user.Login, &user.Role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrUserNotFound
+		}
+		return nil, err
+	}
 
-    *listUsers = users
-    return rows.Err()
+	return &user, nil
 }
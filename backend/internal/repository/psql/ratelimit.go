@@ -0,0 +1,81 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/ratelimit"
+)
+
+const rateLimitSweepInterval = time.Minute
+
+// RateLimits is a ratelimit.Store shared by every instance of the server.
+// Buckets age by the database clock, so instances need not agree on time.
+type RateLimits struct {
+	db *sql.DB
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewRateLimits(db *sql.DB) *RateLimits {
+	return &RateLimits{db: db, lastSweep: time.Now()}
+}
+
+func (s *RateLimits) Allow(ctx context.Context, key string, limit ratelimit.Limit) (time.Duration, bool, error) {
+	if err := s.sweep(ctx); err != nil {
+		return 0, false, err
+	}
+
+	var retryAfter time.Duration
+	var ok bool
+	err := runTx(ctx, s.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO rate_limits (key, tokens, idle_seconds, updated_at)
+			VALUES ($1, $2, $3, NOW())
+			ON CONFLICT (key) DO NOTHING
+		`, key, limit.Requests, limit.Per.Seconds()); err != nil {
+			return err
+		}
+
+		var tokens, elapsed float64
+		if err := tx.QueryRowContext(ctx, `
+			SELECT tokens, EXTRACT(EPOCH FROM NOW() - updated_at)
+			FROM rate_limits WHERE key = $1
+			FOR UPDATE
+		`, key).Scan(&tokens, &elapsed); err != nil {
+			return err
+		}
+
+		var left float64
+		left, retryAfter, ok = ratelimit.Spend(tokens, elapsed, limit)
+		_, err := tx.ExecContext(ctx, `
+			UPDATE rate_limits SET tokens = $2, idle_seconds = $3, updated_at = NOW() WHERE key = $1
+		`, key, left, limit.Per.Seconds())
+		return err
+	})
+	return retryAfter, ok, err
+}
+
+// sweep drops the buckets that have refilled completely, like the memory
+// store does, at most once per rateLimitSweepInterval per instance. Each
+// bucket keeps the period of the limit it was last used with, so no limit
+// outlives its bucket however long it is.
+func (s *RateLimits) sweep(ctx context.Context) error {
+	s.mu.Lock()
+	due := time.Since(s.lastSweep) > rateLimitSweepInterval
+	if due {
+		s.lastSweep = time.Now()
+	}
+	s.mu.Unlock()
+	if !due {
+		return nil
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		DELETE FROM rate_limits WHERE updated_at + make_interval(secs => idle_seconds) < NOW()
+	`)
+	return err
+}
@@ -0,0 +1,48 @@
+package psql
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimitSweep(t *testing.T) {
+	_, db := testQueues(t)
+	ctx := context.Background()
+	s := NewRateLimits(db)
+
+	tests := []struct {
+		key     string
+		idle    time.Duration
+		age     time.Duration
+		dropped bool
+	}{
+		{"short:refilled", time.Minute, 5 * time.Minute, true},
+		{"short:fresh", time.Minute, 30 * time.Second, false},
+		{"long:refilling", 2 * time.Hour, 90 * time.Minute, false},
+		{"long:refilled", 2 * time.Hour, 3 * time.Hour, true},
+	}
+	for _, tt := range tests {
+		if _, err := db.Exec(`
+			INSERT INTO rate_limits (key, tokens, idle_seconds, updated_at)
+			VALUES ($1, 0, $2, NOW() - make_interval(secs => $3))
+		`, tt.key, tt.idle.Seconds(), tt.age.Seconds()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.lastSweep = time.Time{}
+	if err := s.sweep(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		var exists bool
+		if err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM rate_limits WHERE key = $1)`, tt.key).Scan(&exists); err != nil {
+			t.Fatal(err)
+		}
+		if exists == tt.dropped {
+			t.Errorf("%s: kept = %v, want dropped = %v", tt.key, exists, tt.dropped)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/DexScen/Queue/backend/internal/errors"
+)
+
+// gameRatingColumns are the rating aggregates of the game aliased g.
+const gameRatingColumns = `(SELECT ROUND(AVG(r.stars), 2)::float8 FROM ratings r WHERE r.game_id = g.id) AS rating,
+			(SELECT COUNT(*) FROM ratings r WHERE r.game_id = g.id) AS rating_count`
+
+// RateGame rates a finished session of the user, alone or with a party
+// they were in when it was called.
+// Unrated sessions are picked before rated ones so that a second rating
+// of the same session is reported as such.
+func (q *Queues) RateGame(ctx context.Context, userID, gameID int, info domain.RatingInfo) (int, error) {
+	var ratingID int
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		var entryID int
+		err := tx.QueryRowContext(ctx, `
+			SELECT q.id
+			FROM queue q
+			WHERE q.game_id = $1 AND q.status = 'finished'
+				AND ($3 = 0 OR q.id = $3)
+				AND (q.user_id = $2 OR q.id IN (
+					SELECT entry_id FROM entry_members WHERE user_id = $2
+				))
+			ORDER BY EXISTS (SELECT 1 FROM ratings r WHERE r.entry_id = q.id AND r.user_id = $2), q.finished_at DESC
+			LIMIT 1
+		`, gameID, userID, info.EntryID).Scan(&entryID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return errors.ErrNotFinished
+			}
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO ratings (game_id, entry_id, user_id, stars, comment)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (entry_id, user_id) DO NOTHING
+			RETURNING id
+		`, gameID, entryID, userID, info.Stars, info.Comment).Scan(&ratingID)
+		if err == sql.ErrNoRows {
+			return errors.ErrAlreadyRated
+		}
+		return err
+	})
+	return ratingID, err
+}
+
+var ratingSorts = map[string]sortColumn{
+	"created_at": {"created_at", "timestamptz"},
+	"stars":      {"stars", "int"},
+}
+
+// GetRatings lists the ratings of a game, newest first unless sorted
+// otherwise. Hidden comments are blanked unless withHidden is set.
+func (q *Queues) GetRatings(ctx context.Context, gameID int, withHidden bool, filter domain.ListFilter, list *domain.ListRatings) (*domain.Cursor, error) {
+	if filter.Sort == "" {
+		filter.Sort, filter.Desc = "created_at", true
+	}
+
+	var l listQuery
+	query, err := l.build(`
+		SELECT
+			r.id,
+			r.game_id,
+			COALESCE(r.entry_id, 0) AS entry_id,
+			u.login,
+			r.stars,
+			CASE WHEN r.hidden AND NOT `+l.arg(withHidden)+` THEN '' ELSE r.comment END AS comment,
+			r.hidden,
+			r.created_at
+		FROM ratings r
+		JOIN users u ON u.id = r.user_id
+		WHERE r.game_id = `+l.arg(gameID),
+		[]string{"id", "game_id", "entry_id", "login", "stars", "comment", "hidden", "created_at"},
+		filter, ratingSorts, "created_at", "id")
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.db.QueryContext(ctx, query, l.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	var ids []int
+	for rows.Next() {
+		var rating domain.Rating
+		var key string
+		var id int
+		if err := rows.Scan(
+			&rating.ID,
+			&rating.GameID,
+			&rating.EntryID,
+			&rating.Login,
+			&rating.Stars,
+			&rating.Comment,
+			&rating.Hidden,
+			&rating.CreatedAt,
+			&key,
+			&id,
+		); err != nil {
+			return nil, err
+		}
+		*list = append(*list, rating)
+		keys = append(keys, key)
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	cursor, n := nextCursor(filter, "created_at", len(ids), keys, ids)
+	*list = (*list)[:n]
+	return cursor, nil
+}
+
+// SetRatingHidden hides an abusive comment from the public list or shows
+// it again. The stars keep counting towards the game's rating.
+func (q *Queues) SetRatingHidden(ctx context.Context, actorID, gameID, ratingID int, hidden bool) error {
+	return q.withTx(ctx, func(tx *sql.Tx) error {
+		var before bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT hidden FROM ratings
+			WHERE game_id = $1 AND id = $2
+			FOR UPDATE
+		`, gameID, ratingID).Scan(&before)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return errors.ErrRatingNotFound
+			}
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE ratings SET hidden = $2 WHERE id = $1`, ratingID, hidden); err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, actorID, "rating.moderate", gameID, ratingTarget(ratingID),
+			map[string]bool{"hidden": before},
+			map[string]bool{"hidden": hidden})
+	})
+}
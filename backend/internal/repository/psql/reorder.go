@@ -0,0 +1,176 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/DexScen/Queue/backend/internal/errors"
+)
+
+// waitingPosition returns the position of a waiting entry of the game and
+// the number of waiting entries in total. The game must already be locked.
+func waitingPosition(ctx context.Context, tx *sql.Tx, gameID, entryID int) (int, int, error) {
+	var position, total int
+	err := tx.QueryRowContext(ctx, `
+		SELECT
+			q.position,
+			(SELECT COUNT(*) FROM queue w WHERE w.game_id = $1 AND w.status = 'waiting')
+		FROM queue q
+		WHERE q.game_id = $1 AND q.id = $2 AND q.status = 'waiting'
+	`, gameID, entryID).Scan(&position, &total)
+	if err == sql.ErrNoRows {
+		return 0, 0, errors.ErrEntryNotFound
+	}
+	return position, total, err
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// MoveEntry moves a waiting entry to the given position, shifting the
+// entries in between by one place.
+func (q *Queues) MoveEntry(ctx context.Context, actorID, gameID, entryID, position int) error {
+	return q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+
+		from, total, err := waitingPosition(ctx, tx, gameID, entryID)
+		if err != nil {
+			return err
+		}
+		to := clamp(position, 1, total)
+
+		if to < from {
+			_, err = tx.ExecContext(ctx, `
+				UPDATE queue SET position = position + 1
+				WHERE game_id = $1 AND status = 'waiting' AND position >= $2 AND position < $3
+			`, gameID, to, from)
+		} else if to > from {
+			_, err = tx.ExecContext(ctx, `
+				UPDATE queue SET position = position - 1
+				WHERE game_id = $1 AND status = 'waiting' AND position > $2 AND position <= $3
+			`, gameID, from, to)
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE queue SET position = $1 WHERE id = $2
+		`, to, entryID); err != nil {
+			return err
+		}
+
+		if err := compactPositions(ctx, tx, gameID); err != nil {
+			return err
+		}
+
+		if err := writeEvent(ctx, tx, actorID, "moved", gameID, entryID, map[string]int{
+			"from": from,
+			"to":   to,
+		}); err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, actorID, "queue.move", gameID, entryTarget(entryID),
+			map[string]int{"position": from},
+			map[string]int{"position": to})
+	})
+}
+
+// SwapEntries exchanges the positions of two waiting entries of a game.
+func (q *Queues) SwapEntries(ctx context.Context, actorID, gameID, entryID, otherEntryID int) error {
+	return q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+
+		first, _, err := waitingPosition(ctx, tx, gameID, entryID)
+		if err != nil {
+			return err
+		}
+		second, _, err := waitingPosition(ctx, tx, gameID, otherEntryID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE queue
+			SET position = CASE id WHEN $1 THEN $3 ELSE $4 END
+			WHERE id IN ($1, $2)
+		`, entryID, otherEntryID, second, first); err != nil {
+			return err
+		}
+
+		if err := writeEvent(ctx, tx, actorID, "moved", gameID, entryID, map[string]int{
+			"from": first,
+			"to":   second,
+		}); err != nil {
+			return err
+		}
+		if err := writeEvent(ctx, tx, actorID, "moved", gameID, otherEntryID, map[string]int{
+			"from": second,
+			"to":   first,
+		}); err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, actorID, "queue.swap", gameID, entryTarget(entryID),
+			map[string]int{"position": first, "other_entry_id": otherEntryID, "other_position": second},
+			map[string]int{"position": second, "other_entry_id": otherEntryID, "other_position": first})
+	})
+}
+
+// InsertPlayerAt adds a user to a game's queue at the given position,
+// shifting everyone from that position onwards back by one place.
+func (q *Queues) InsertPlayerAt(ctx context.Context, actorID, gameID, userID, position int) (int, int, error) {
+	var entryID, to int
+
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+		if err := ensureNotQueued(ctx, tx, gameID, []int{userID}); err != nil {
+			return err
+		}
+		if err := checkBan(ctx, tx, userID, gameID); err != nil {
+			return err
+		}
+
+		var err error
+		to, err = placeEntryAt(ctx, tx, gameID, position, 0)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO queue (user_id, game_id, position, status)
+			VALUES ($1, $2, $3, 'waiting')
+			RETURNING id
+		`, userID, gameID, to).Scan(&entryID); err != nil {
+			return err
+		}
+
+		if err := writeEvent(ctx, tx, actorID, "joined", gameID, entryID, map[string]int{"position": to}); err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, actorID, "queue.insert", gameID, entryTarget(entryID), nil, map[string]int{
+			"user_id":  userID,
+			"position": to,
+		})
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return entryID, to, nil
+}
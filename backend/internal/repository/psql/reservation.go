@@ -0,0 +1,505 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/DexScen/Queue/backend/internal/errors"
+)
+
+const reservationColumns = `
+	r.id,
+	COALESCE(r.game_id, 0),
+	r.user_id,
+	r.slot_start,
+	r.slot_start + make_interval(secs => COALESCE(g.duration_seconds, 0)),
+	r.status,
+	COALESCE(r.entry_id, 0),
+	r.created_at
+`
+
+func scanReservation(row interface{ Scan(...any) error }, r *domain.Reservation) error {
+	return row.Scan(&r.ID, &r.GameID, &r.UserID, &r.Start, &r.End, &r.Status, &r.EntryID, &r.CreatedAt)
+}
+
+func getReservation(ctx context.Context, tx *sql.Tx, reservationID int) (*domain.Reservation, error) {
+	var r domain.Reservation
+	err := scanReservation(tx.QueryRowContext(ctx, `
+		SELECT `+reservationColumns+`
+		FROM reservations r
+		LEFT JOIN games g ON g.id = r.game_id
+		WHERE r.id = $1
+	`, reservationID), &r)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// checkSlot makes sure a slot still has room, not counting the reservation
+// being moved (excludeID). The game must already be locked.
+func checkSlot(ctx context.Context, tx *sql.Tx, gameID int, start time.Time, excludeID int) error {
+	var capacity, booked int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT g.reservation_capacity, (
+			SELECT COUNT(*) FROM reservations
+			WHERE game_id = $1 AND slot_start = $2 AND status <> 'cancelled' AND id <> $3
+		)
+		FROM games g
+		WHERE g.id = $1
+	`, gameID, start, excludeID).Scan(&capacity, &booked); err != nil {
+		return err
+	}
+	if booked >= capacity {
+		return errors.ErrSlotFull
+	}
+	return nil
+}
+
+// dueReservation turns the earliest reservation whose slot is running now
+// into the entry to call, ahead of the walk-in queue. A reservation whose
+// holder is banned or queued as a party member, or whose slot the game no
+// longer keeps open, is passed over; it stays booked until it can be used
+// or expires. It returns 0 when no reservation is due. The game must
+// already be locked.
+func dueReservation(ctx context.Context, tx *sql.Tx, gameID int, open domain.OpenFunc) (int, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT r.id, r.user_id, r.slot_start
+		FROM reservations r
+		JOIN games g ON g.id = r.game_id
+		WHERE r.game_id = $1 AND r.status = 'booked'
+			AND r.slot_start <= NOW()
+			AND r.slot_start + make_interval(secs => g.duration_seconds) > NOW()
+		ORDER BY r.slot_start, r.id
+		FOR UPDATE OF r
+	`, gameID)
+	if err != nil {
+		return 0, err
+	}
+	var due []domain.Reservation
+	for rows.Next() {
+		r := domain.Reservation{GameID: gameID}
+		if err := rows.Scan(&r.ID, &r.UserID, &r.Start); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, r := range due {
+		entryID, used, err := useReservation(ctx, tx, &r, open)
+		if err != nil || used {
+			return entryID, err
+		}
+	}
+	return 0, nil
+}
+
+// useReservation marks a due reservation used and returns the entry to
+// call for it. A reserved user who is already waiting keeps their entry;
+// one already called or playing has nothing left to call. Otherwise the
+// user joins at the head of the line, if nothing keeps them out.
+func useReservation(ctx context.Context, tx *sql.Tx, r *domain.Reservation, open domain.OpenFunc) (int, bool, error) {
+	var entryID int
+	var status string
+	err := tx.QueryRowContext(ctx, `
+		SELECT id, status FROM queue
+		WHERE game_id = $1 AND user_id = $2 AND status IN ('waiting', 'called', 'active')
+	`, r.GameID, r.UserID).Scan(&entryID, &status)
+	switch {
+	case err == sql.ErrNoRows:
+		switch _, err := loadActiveBan(ctx, tx, r.UserID, r.GameID); err {
+		case nil:
+			return 0, false, nil
+		case errors.ErrBanNotFound:
+		default:
+			return 0, false, err
+		}
+		switch err := ensureNotQueued(ctx, tx, r.GameID, []int{r.UserID}); err {
+		case nil:
+		case errors.ErrAlreadyInQueue:
+			return 0, false, nil
+		default:
+			return 0, false, err
+		}
+		if open != nil {
+			state, err := loadOpenState(ctx, tx, r.GameID, r.Start)
+			if err != nil {
+				return 0, false, err
+			}
+			if open(*state) != nil {
+				return 0, false, nil
+			}
+		}
+
+		priority, err := userPriority(ctx, tx, r.UserID)
+		if err != nil {
+			return 0, false, err
+		}
+		position, err := placeEntryAt(ctx, tx, r.GameID, 1, 0)
+		if err != nil {
+			return 0, false, err
+		}
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO queue (user_id, game_id, position, status, priority)
+			VALUES ($1, $2, $3, 'waiting', $4)
+			RETURNING id
+		`, r.UserID, r.GameID, position, priority).Scan(&entryID); err != nil {
+			return 0, false, err
+		}
+		if err := writeEvent(ctx, tx, 0, "joined", r.GameID, entryID, map[string]int{
+			"position":       position,
+			"reservation_id": r.ID,
+		}); err != nil {
+			return 0, false, err
+		}
+	case err != nil:
+		return 0, false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE reservations SET status = 'used', entry_id = $2 WHERE id = $1
+	`, r.ID, entryID); err != nil {
+		return 0, false, err
+	}
+
+	if status != "" && status != domain.StatusWaiting {
+		return 0, true, nil
+	}
+	return entryID, true, nil
+}
+
+// writeReservationEvent appends a change of a reservation to the game's
+// history. It belongs to no queue entry. Booking is not a privileged
+// action, so this is the only record of it; nothing goes to the audit log.
+func writeReservationEvent(ctx context.Context, tx *sql.Tx, actorID int, kind string, r *domain.Reservation) error {
+	raw, err := json.Marshal(map[string]any{
+		"reservation_id": r.ID,
+		"start":          r.Start,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO queue_events (game_id, user_id, type, actor_id, details)
+		VALUES ($1, $2, $3, NULLIF($4, 0), $5)
+	`, r.GameID, r.UserID, kind, actorID, raw)
+	return err
+}
+
+// ExpireReservations marks the booked reservations whose slot has ended
+// without them being used as expired.
+func (q *Queues) ExpireReservations(ctx context.Context) (domain.ListReservations, error) {
+	var expired domain.ListReservations
+
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			UPDATE reservations r SET status = 'expired'
+			FROM games g
+			WHERE g.id = r.game_id AND r.status = 'booked'
+				AND r.slot_start + make_interval(secs => g.duration_seconds) <= NOW()
+			RETURNING `+reservationColumns+`
+		`)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var r domain.Reservation
+			if err := scanReservation(rows, &r); err != nil {
+				rows.Close()
+				return err
+			}
+			expired = append(expired, r)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for i := range expired {
+			if err := writeReservationEvent(ctx, tx, 0, "expired", &expired[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return expired, nil
+}
+
+// CountReservations returns the number of live reservations per slot
+// start in [from, to).
+func (q *Queues) CountReservations(ctx context.Context, gameID int, from, to time.Time) (map[int64]int, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT slot_start, COUNT(*)
+		FROM reservations
+		WHERE game_id = $1 AND slot_start >= $2 AND slot_start < $3 AND status <> 'cancelled'
+		GROUP BY slot_start
+	`, gameID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[int64]int{}
+	for rows.Next() {
+		var start time.Time
+		var n int
+		if err := rows.Scan(&start, &n); err != nil {
+			return nil, err
+		}
+		counts[start.Unix()] = n
+	}
+
+	return counts, rows.Err()
+}
+
+func (q *Queues) GetReservation(ctx context.Context, reservationID int) (*domain.Reservation, error) {
+	var r domain.Reservation
+	err := scanReservation(q.db.QueryRowContext(ctx, `
+		SELECT `+reservationColumns+`
+		FROM reservations r
+		LEFT JOIN games g ON g.id = r.game_id
+		WHERE r.id = $1
+	`, reservationID), &r)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrReservationNotFound
+		}
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// GetReservationsByUser lists a user's reservations, the latest slot
+// first, only at the games of eventID unless it is 0.
+func (q *Queues) GetReservationsByUser(ctx context.Context, userID, eventID int) (domain.ListReservations, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT `+reservationColumns+`
+		FROM reservations r
+		LEFT JOIN games g ON g.id = r.game_id
+		WHERE r.user_id = $1
+		  AND ($2 = 0 OR g.event_id = $2)
+		ORDER BY r.slot_start DESC, r.id DESC
+	`, userID, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reservations := domain.ListReservations{}
+	for rows.Next() {
+		var r domain.Reservation
+		if err := scanReservation(rows, &r); err != nil {
+			return nil, err
+		}
+		reservations = append(reservations, r)
+	}
+
+	return reservations, rows.Err()
+}
+
+// BookReservation books a slot for a user. A user holds at most one
+// upcoming reservation per game.
+func (q *Queues) BookReservation(ctx context.Context, userID, gameID int, start time.Time, open domain.OpenFunc) (*domain.Reservation, error) {
+	var reservation *domain.Reservation
+
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+		if err := checkOpenAt(ctx, tx, gameID, start, open); err != nil {
+			return err
+		}
+		if err := checkBan(ctx, tx, userID, gameID); err != nil {
+			return err
+		}
+
+		var booked bool
+		if err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM reservations r
+				JOIN games g ON g.id = r.game_id
+				WHERE r.game_id = $1 AND r.user_id = $2 AND r.status = 'booked'
+					AND r.slot_start + make_interval(secs => g.duration_seconds) > NOW()
+			)
+		`, gameID, userID).Scan(&booked); err != nil {
+			return err
+		}
+		if booked {
+			return errors.ErrAlreadyBooked
+		}
+
+		if err := checkSlot(ctx, tx, gameID, start, 0); err != nil {
+			return err
+		}
+
+		var id int
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO reservations (game_id, user_id, slot_start)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		`, gameID, userID, start).Scan(&id); err != nil {
+			return err
+		}
+
+		var err error
+		reservation, err = getReservation(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		return writeReservationEvent(ctx, tx, userID, "reserved", reservation)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+// lockReservation locks a user's booked reservation and its game.
+func lockReservation(ctx context.Context, tx *sql.Tx, userID, reservationID int) (*domain.Reservation, error) {
+	var gameID int
+	err := tx.QueryRowContext(ctx, `
+		SELECT game_id FROM reservations
+		WHERE id = $1 AND user_id = $2 AND status = 'booked'
+	`, reservationID, userID).Scan(&gameID)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lockGame(ctx, tx, gameID); err != nil {
+		return nil, err
+	}
+	// used or cancelled while we waited for the lock
+	reservation, err := getReservation(ctx, tx, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	if reservation.Status != domain.ReservationBooked {
+		return nil, errors.ErrReservationNotFound
+	}
+	return reservation, nil
+}
+
+func (q *Queues) RescheduleReservation(ctx context.Context, userID, reservationID int, start time.Time, open domain.OpenFunc) (*domain.Reservation, error) {
+	var reservation *domain.Reservation
+
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		before, err := lockReservation(ctx, tx, userID, reservationID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkOpenAt(ctx, tx, before.GameID, start, open); err != nil {
+			return err
+		}
+		if err := checkSlot(ctx, tx, before.GameID, start, reservationID); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE reservations SET slot_start = $2 WHERE id = $1
+		`, reservationID, start); err != nil {
+			return err
+		}
+
+		reservation, err = getReservation(ctx, tx, reservationID)
+		if err != nil {
+			return err
+		}
+
+		return writeReservationEvent(ctx, tx, userID, "rescheduled", reservation)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+// CancelReservation cancels a user's booked reservation and returns its
+// game.
+func (q *Queues) CancelReservation(ctx context.Context, userID, reservationID int) (int, error) {
+	var gameID int
+
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		reservation, err := lockReservation(ctx, tx, userID, reservationID)
+		if err != nil {
+			return err
+		}
+		gameID = reservation.GameID
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE reservations SET status = 'cancelled' WHERE id = $1
+		`, reservationID); err != nil {
+			return err
+		}
+
+		return writeReservationEvent(ctx, tx, userID, "unreserved", reservation)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return gameID, nil
+}
+
+func (q *Queues) GetReservationPolicy(ctx context.Context, gameID int) (*domain.ReservationPolicy, error) {
+	return loadReservationPolicy(ctx, q.db, gameID)
+}
+
+func loadReservationPolicy(ctx context.Context, db querier, gameID int) (*domain.ReservationPolicy, error) {
+	var policy domain.ReservationPolicy
+	err := db.QueryRowContext(ctx, `
+		SELECT reservation_capacity FROM games WHERE id = $1
+	`, gameID).Scan(&policy.Capacity)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrGameNotFound
+		}
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+func (q *Queues) SetReservationPolicy(ctx context.Context, actorID, gameID int, policy domain.ReservationPolicy) error {
+	return q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+
+		before, err := loadReservationPolicy(ctx, tx, gameID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE games SET reservation_capacity = $2 WHERE id = $1
+		`, gameID, policy.Capacity); err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, actorID, "game.reservation_policy", gameID, gameTarget(gameID), before, policy)
+	})
+}
@@ -0,0 +1,167 @@
+package psql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+func TestDueReservation(t *testing.T) {
+	tests := []struct {
+		name   string
+		banned bool
+		closed bool
+		called string // who the call goes to
+		status string // of the reservation afterwards
+	}{
+		{"goes first", false, false, "holder", domain.ReservationUsed},
+		{"banned holder is passed over", true, false, "walkin", domain.ReservationBooked},
+		{"closed slot is passed over", false, true, "walkin", domain.ReservationBooked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, db := testQueues(t)
+			ctx := context.Background()
+			gameID := newTestGame(t, db, 1)
+			users := map[string]int{
+				"holder": newTestUser(t, db, "holder"),
+				"walkin": newTestUser(t, db, "walkin"),
+			}
+
+			if _, err := q.AddPlayerToQueue(ctx, users["walkin"], gameID, nil); err != nil {
+				t.Fatal(err)
+			}
+			var reservationID int
+			if err := db.QueryRow(`
+				INSERT INTO reservations (game_id, user_id, slot_start)
+				VALUES ($1, $2, NOW() - INTERVAL '1 minute')
+				RETURNING id
+			`, gameID, users["holder"]).Scan(&reservationID); err != nil {
+				t.Fatal(err)
+			}
+			if tt.banned {
+				if _, err := db.Exec(`
+					INSERT INTO bans (user_id, game_id, reason) VALUES ($1, $2, 'test')
+				`, users["holder"], gameID); err != nil {
+					t.Fatal(err)
+				}
+			}
+			open := func(domain.OpenState) error { return nil }
+			if tt.closed {
+				open = func(domain.OpenState) error { return context.Canceled }
+			}
+
+			calledID, err := q.CallNext(ctx, 0, gameID, 0, pickFirst, open)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var called int
+			if err := db.QueryRow(`SELECT user_id FROM queue WHERE id = $1`, calledID).Scan(&called); err != nil {
+				t.Fatal(err)
+			}
+			if called != users[tt.called] {
+				t.Fatalf("called user %d, want %s", called, tt.called)
+			}
+
+			var status string
+			if err := db.QueryRow(`SELECT status FROM reservations WHERE id = $1`, reservationID).Scan(&status); err != nil {
+				t.Fatal(err)
+			}
+			if status != tt.status {
+				t.Fatalf("reservation %s, want %s", status, tt.status)
+			}
+			waitingUsers(t, db, gameID)
+		})
+	}
+}
+
+func TestExpireReservations(t *testing.T) {
+	q, db := testQueues(t)
+	ctx := context.Background()
+	gameID := newTestGame(t, db, 1)
+	userID := newTestUser(t, db, "holder")
+
+	// the test game plays 600 s sessions, so only the first slot is over
+	var ids []int
+	for _, start := range []string{"NOW() - INTERVAL '1 hour'", "NOW() - INTERVAL '1 minute'", "NOW() + INTERVAL '1 hour'"} {
+		var id int
+		if err := db.QueryRow(`
+			INSERT INTO reservations (game_id, user_id, slot_start) VALUES ($1, $2, `+start+`)
+			RETURNING id
+		`, gameID, userID).Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	expired, err := q.ExpireReservations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expired) != 1 || expired[0].ID != ids[0] || expired[0].Status != domain.ReservationExpired {
+		t.Fatalf("expired = %+v, want reservation %d", expired, ids[0])
+	}
+
+	var events int
+	if err := db.QueryRow(`
+		SELECT COUNT(*) FROM queue_events WHERE type = 'expired' AND entry_id IS NULL AND user_id = $1
+	`, userID).Scan(&events); err != nil {
+		t.Fatal(err)
+	}
+	if events != 1 {
+		t.Fatalf("%d expiry events, want 1", events)
+	}
+}
+
+func TestBookReservationPaused(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   time.Duration // from now
+		wantErr bool
+	}{
+		{"later slot ignores the pause", time.Hour, false},
+		{"started slot is paused", -time.Minute, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, db := testQueues(t)
+			ctx := context.Background()
+			gameID := newTestGame(t, db, 1)
+			userID := newTestUser(t, db, "holder")
+
+			if _, err := db.Exec(`UPDATE games SET paused = TRUE WHERE id = $1`, gameID); err != nil {
+				t.Fatal(err)
+			}
+			open := func(state domain.OpenState) error {
+				if state.Hours.Paused {
+					return context.Canceled
+				}
+				return nil
+			}
+
+			_, err := q.BookReservation(ctx, userID, gameID, time.Now().Add(tt.start), open)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			// bookings are history, not privileged actions
+			var audits, events int
+			if err := db.QueryRow(`SELECT COUNT(*) FROM audit_log`).Scan(&audits); err != nil {
+				t.Fatal(err)
+			}
+			if err := db.QueryRow(`
+				SELECT COUNT(*) FROM queue_events WHERE type = 'reserved' AND user_id = $1
+			`, userID).Scan(&events); err != nil {
+				t.Fatal(err)
+			}
+			if audits != 0 || events != 1 {
+				t.Fatalf("%d audit rows and %d events, want 0 and 1", audits, events)
+			}
+		})
+	}
+}
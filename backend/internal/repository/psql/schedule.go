@@ -0,0 +1,133 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/DexScen/Queue/backend/internal/errors"
+)
+
+const schedulingColumns = `scheduling_policy, priority_boost, lottery_cutoff, lottery_seed`
+
+func scanSchedulingPolicy(row interface{ Scan(...any) error }, policy *domain.SchedulingPolicy) error {
+	return row.Scan(&policy.Name, &policy.PriorityBoost, &policy.LotteryCutoff, &policy.Seed)
+}
+
+// scheduleState loads the game's policy, its waiting line and the group of
+// the entry called last. The game must already be locked.
+func scheduleState(ctx context.Context, tx *sql.Tx, gameID int) (*domain.ScheduleState, error) {
+	var state domain.ScheduleState
+	err := scanSchedulingPolicy(tx.QueryRowContext(ctx, `
+		SELECT `+schedulingColumns+` FROM games WHERE id = $1
+	`, gameID), &state.Policy)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrGameNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+entryColumns+`
+		FROM queue q
+		LEFT JOIN users u ON u.id = q.user_id
+		WHERE q.game_id = $1 AND q.status = 'waiting'
+		ORDER BY q.position
+	`, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry domain.QueueEntry
+		if err := scanEntry(rows, &entry); err != nil {
+			return nil, err
+		}
+		state.Waiting = append(state.Waiting, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(u.user_group, '')
+		FROM queue q
+		LEFT JOIN users u ON u.id = q.user_id
+		WHERE q.game_id = $1 AND q.called_at IS NOT NULL
+		ORDER BY q.called_at DESC, q.id DESC
+		LIMIT 1
+	`, gameID).Scan(&state.LastGroup)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+func (q *Queues) GetSchedulingPolicy(ctx context.Context, gameID int) (*domain.SchedulingPolicy, error) {
+	return loadSchedulingPolicy(ctx, q.db, gameID)
+}
+
+func loadSchedulingPolicy(ctx context.Context, db querier, gameID int) (*domain.SchedulingPolicy, error) {
+	var policy domain.SchedulingPolicy
+	err := scanSchedulingPolicy(db.QueryRowContext(ctx, `
+		SELECT `+schedulingColumns+` FROM games WHERE id = $1
+	`, gameID), &policy)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrGameNotFound
+		}
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+func (q *Queues) SetSchedulingPolicy(ctx context.Context, actorID, gameID int, policy domain.SchedulingPolicy) error {
+	return q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+
+		before, err := loadSchedulingPolicy(ctx, tx, gameID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE games
+			SET scheduling_policy = $2, priority_boost = $3, lottery_cutoff = $4, lottery_seed = $5
+			WHERE id = $1
+		`, gameID, policy.Name, policy.PriorityBoost, policy.LotteryCutoff, policy.Seed); err != nil {
+			return err
+		}
+
+		// the seed stays out of the audit log like out of every read
+		before.Seed, policy.Seed = 0, 0
+		return writeAudit(ctx, tx, actorID, "game.scheduling_policy", gameID, gameTarget(gameID), before, policy)
+	})
+}
+
+func (q *Queues) SetUserGroup(ctx context.Context, actorID, userID int, group string) error {
+	return q.withTx(ctx, func(tx *sql.Tx) error {
+		var was string
+		err := tx.QueryRowContext(ctx, `
+			UPDATE users u SET user_group = $2
+			FROM (SELECT id, user_group FROM users WHERE id = $1) old
+			WHERE u.id = old.id
+			RETURNING old.user_group
+		`, userID, group).Scan(&was)
+		if err == sql.ErrNoRows {
+			return errors.ErrUserNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, actorID, "user.group", 0, userTarget(userID),
+			map[string]string{"group": was},
+			map[string]string{"group": group})
+	})
+}
@@ -0,0 +1,147 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/DexScen/Queue/backend/internal/errors"
+)
+
+// SetEntryScore corrects the score and result of a finished session.
+func (q *Queues) SetEntryScore(ctx context.Context, actorID, gameID, entryID int, res domain.ResultInfo) error {
+	return q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+
+		var status string
+		var before domain.ResultInfo
+		err := tx.QueryRowContext(ctx, `
+			SELECT status, score, result FROM queue
+			WHERE game_id = $1 AND id = $2
+			FOR UPDATE
+		`, gameID, entryID).Scan(&status, &before.Score, &before.Result)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return errors.ErrEntryNotFound
+			}
+			return err
+		}
+		if status != domain.StatusFinished {
+			return errors.ErrNotFinished
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE queue SET score = $2, result = $3 WHERE id = $1
+		`, entryID, res.Score, res.Result); err != nil {
+			return err
+		}
+		if err := writeEvent(ctx, tx, actorID, "scored", gameID, entryID, res); err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, actorID, "queue.score", gameID, entryTarget(entryID), before, res)
+	})
+}
+
+func (q *Queues) GetScoringPolicy(ctx context.Context, gameID int) (*domain.ScoringPolicy, error) {
+	return loadScoringPolicy(ctx, q.db, gameID)
+}
+
+func loadScoringPolicy(ctx context.Context, db querier, gameID int) (*domain.ScoringPolicy, error) {
+	var policy domain.ScoringPolicy
+	err := db.QueryRowContext(ctx, `SELECT scoring_mode FROM games WHERE id = $1`, gameID).Scan(&policy.Mode)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.ErrGameNotFound
+		}
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+func (q *Queues) SetScoringPolicy(ctx context.Context, actorID, gameID int, policy domain.ScoringPolicy) error {
+	return q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+
+		before, err := loadScoringPolicy(ctx, tx, gameID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE games SET scoring_mode = $2 WHERE id = $1`, gameID, policy.Mode); err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, actorID, "game.scoring_policy", gameID, gameTarget(gameID), before, policy)
+	})
+}
+
+// leaderboardTotals adds up the scored attempts of every player per game
+// following the game's scoring mode. Registered players are keyed by
+// user, walk-ins by their ticket, which is unique within a game. With
+// "best" the achieved time is that of the earliest best attempt, with
+// "sum" that of the last attempt.
+const leaderboardTotals = `
+	WITH attempts AS (
+		SELECT
+			q.game_id,
+			g.scoring_mode,
+			COALESCE(q.user_id::text, 'ticket:' || q.game_id || ':' || q.ticket) AS player,
+			COALESCE(q.user_id, 0) AS user_id,
+			COALESCE(u.login, q.nickname, q.ticket) AS name,
+			q.score,
+			q.finished_at
+		FROM queue q
+		JOIN games g ON g.id = q.game_id
+		LEFT JOIN users u ON u.id = q.user_id
+		WHERE q.status = 'finished' AND q.score IS NOT NULL
+			AND ($1 = 0 OR q.game_id = $1)
+			AND ($2 = 0 OR g.event_id = $2)
+	),
+	totals AS (
+		SELECT
+			game_id,
+			player,
+			MAX(user_id) AS user_id,
+			MAX(name) AS name,
+			CASE WHEN scoring_mode = 'sum' THEN SUM(score) ELSE MAX(score) END AS score,
+			COUNT(*) AS attempts,
+			CASE WHEN scoring_mode = 'sum' THEN MAX(finished_at)
+				ELSE (array_agg(finished_at ORDER BY score DESC, finished_at))[1]
+			END AS achieved_at
+		FROM attempts
+		GROUP BY game_id, player, scoring_mode
+	)
+`
+
+// GetLeaderboard ranks the players of a game, or with gameID 0 the
+// players over every game of the event (every game for eventID 0) by the
+// sum of their per-game totals. Ties go to whoever got there first.
+func (q *Queues) GetLeaderboard(ctx context.Context, gameID, eventID, limit int) ([]domain.LeaderboardEntry, error) {
+	rows, err := q.db.QueryContext(ctx, leaderboardTotals+`
+		SELECT MAX(user_id), MAX(name), SUM(score), SUM(attempts), MAX(achieved_at)
+		FROM totals
+		GROUP BY player
+		ORDER BY SUM(score) DESC, MAX(achieved_at), player
+		LIMIT $3
+	`, gameID, eventID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []domain.LeaderboardEntry
+	for rows.Next() {
+		entry := domain.LeaderboardEntry{Rank: len(entries) + 1}
+		if err := rows.Scan(&entry.UserID, &entry.Name, &entry.Score, &entry.Attempts, &entry.AchievedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
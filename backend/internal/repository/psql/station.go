@@ -0,0 +1,183 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/DexScen/Queue/backend/internal/errors"
+)
+
+// Stations are optional. A game without any keeps the plain max_slots
+// capacity; once it has stations, a player is only called when an enabled
+// station is free as well, so disabling a broken one shrinks the capacity
+// without touching max_slots.
+
+// freeStation picks the station the next player is sent to: the requested
+// one, or else the free enabled station that comes first by name. It
+// returns 0 for a game without stations, and ok = false when the game has
+// stations but none is free. The game must already be locked.
+func freeStation(ctx context.Context, tx *sql.Tx, gameID, stationID int) (int, bool, error) {
+	if stationID != 0 {
+		var enabled, busy bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT s.enabled, EXISTS (
+				SELECT 1 FROM queue q
+				WHERE q.station_id = s.id AND q.status IN ('called', 'active')
+			)
+			FROM stations s
+			WHERE s.id = $1 AND s.game_id = $2
+		`, stationID, gameID).Scan(&enabled, &busy)
+		if err == sql.ErrNoRows {
+			return 0, false, errors.ErrStationNotFound
+		}
+		if err != nil {
+			return 0, false, err
+		}
+		if !enabled || busy {
+			return 0, false, errors.ErrStationBusy
+		}
+		return stationID, true, nil
+	}
+
+	var id sql.NullInt64
+	var hasStations bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT
+			(SELECT s.id FROM stations s
+				WHERE s.game_id = $1 AND s.enabled AND NOT EXISTS (
+					SELECT 1 FROM queue q
+					WHERE q.station_id = s.id AND q.status IN ('called', 'active')
+				)
+				ORDER BY s.name, s.id
+				LIMIT 1),
+			EXISTS (SELECT 1 FROM stations WHERE game_id = $1)
+	`, gameID).Scan(&id, &hasStations)
+	if err != nil {
+		return 0, false, err
+	}
+	if !hasStations {
+		return 0, true, nil
+	}
+	return int(id.Int64), id.Valid, nil
+}
+
+func (q *Queues) GetStations(ctx context.Context, gameID int) (domain.ListStations, error) {
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT g.id, s.id, s.name, s.enabled, COALESCE(q.id, 0)
+		FROM games g
+		LEFT JOIN stations s ON s.game_id = g.id
+		LEFT JOIN queue q ON q.station_id = s.id AND q.status IN ('called', 'active')
+		WHERE g.id = $1
+		ORDER BY s.name, s.id
+	`, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := false
+	stations := domain.ListStations{}
+	for rows.Next() {
+		var station domain.Station
+		var id sql.NullInt64
+		var name sql.NullString
+		var enabled sql.NullBool
+		if err := rows.Scan(&station.GameID, &id, &name, &enabled, &station.EntryID); err != nil {
+			return nil, err
+		}
+		found = true
+		if !id.Valid {
+			continue
+		}
+		station.ID, station.Name, station.Enabled = int(id.Int64), name.String, enabled.Bool
+		stations = append(stations, station)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.ErrGameNotFound
+	}
+
+	return stations, nil
+}
+
+func (q *Queues) CreateStation(ctx context.Context, actorID, gameID int, name string, enabled bool) (int, error) {
+	var stationID int
+
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS (SELECT 1 FROM stations WHERE game_id = $1 AND name = $2)
+		`, gameID, name).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return errors.ErrStationExists
+		}
+
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO stations (game_id, name, enabled) VALUES ($1, $2, $3)
+			RETURNING id
+		`, gameID, name, enabled).Scan(&stationID); err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, actorID, "station.create", gameID, stationTarget(stationID), nil, domain.Station{
+			ID:      stationID,
+			GameID:  gameID,
+			Name:    name,
+			Enabled: enabled,
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return stationID, nil
+}
+
+// UpdateStation renames a station or switches it on and off. A player
+// already at a disabled station finishes normally; nobody new is sent there.
+func (q *Queues) UpdateStation(ctx context.Context, actorID, gameID, stationID int, info domain.StationInfo) error {
+	return q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+
+		if info.Name != "" {
+			var exists bool
+			if err := tx.QueryRowContext(ctx, `
+				SELECT EXISTS (SELECT 1 FROM stations WHERE game_id = $1 AND name = $2 AND id <> $3)
+			`, gameID, info.Name, stationID).Scan(&exists); err != nil {
+				return err
+			}
+			if exists {
+				return errors.ErrStationExists
+			}
+		}
+
+		before := domain.Station{ID: stationID, GameID: gameID}
+		after := before
+		err := tx.QueryRowContext(ctx, `
+			UPDATE stations s
+			SET name = COALESCE(NULLIF($3, ''), s.name), enabled = COALESCE($4, s.enabled)
+			FROM (SELECT id, name, enabled FROM stations WHERE id = $1 AND game_id = $2) old
+			WHERE s.id = old.id
+			RETURNING old.name, old.enabled, s.name, s.enabled
+		`, stationID, gameID, info.Name, info.Enabled).Scan(&before.Name, &before.Enabled, &after.Name, &after.Enabled)
+		if err == sql.ErrNoRows {
+			return errors.ErrStationNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, actorID, "station.update", gameID, stationTarget(stationID), before, after)
+	})
+}
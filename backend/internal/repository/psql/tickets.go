@@ -0,0 +1,167 @@
+package psql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/DexScen/Queue/backend/internal/errors"
+)
+
+// IssueTicket queues a walk-in visitor under the next ticket number of the
+// game, e.g. VR-042. Tickets are issued by staff at the game, so like
+// InsertPlayerAt it deliberately ignores opening hours and last call.
+func (q *Queues) IssueTicket(ctx context.Context, actorID, gameID int, info domain.TicketInfo, claimCode string) (*domain.Ticket, error) {
+	ticket := domain.Ticket{ClaimCode: claimCode}
+
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+
+		var prefix string
+		var seq int
+		if err := tx.QueryRowContext(ctx, `
+			UPDATE games SET ticket_seq = ticket_seq + 1
+			WHERE id = $1
+			RETURNING ticket_prefix, ticket_seq
+		`, gameID).Scan(&prefix, &seq); err != nil {
+			return err
+		}
+		ticket.Number = fmt.Sprintf("%s-%03d", prefix, seq)
+
+		var err error
+		ticket.Position, err = placeEntry(ctx, tx, gameID, 0, 0)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO queue (game_id, position, status, ticket, claim_code, nickname, phone)
+			VALUES ($1, $2, 'waiting', $3, $4, NULLIF($5, ''), NULLIF($6, ''))
+			RETURNING id
+		`, gameID, ticket.Position, ticket.Number, claimCode, info.Nickname, info.Phone).Scan(&ticket.EntryID); err != nil {
+			return err
+		}
+
+		if err := writeEvent(ctx, tx, actorID, "joined", gameID, ticket.EntryID, map[string]any{
+			"position": ticket.Position,
+			"ticket":   ticket.Number,
+		}); err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, actorID, "queue.ticket", gameID, entryTarget(ticket.EntryID), nil, map[string]any{
+			"ticket":   ticket.Number,
+			"position": ticket.Position,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ticket, nil
+}
+
+// ClaimTicket attaches an unclaimed walk-in entry that is still in play to
+// a registered user, unless the user is already queued for the game.
+// Claiming keeps the place staff already gave the ticket, so opening hours
+// are not checked again.
+func (q *Queues) ClaimTicket(ctx context.Context, userID int, info domain.ClaimInfo) (*domain.QueueEntry, error) {
+	var entryID int
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		var gameID int
+		err := tx.QueryRowContext(ctx, `
+			SELECT id, game_id FROM queue
+			WHERE ticket = $1 AND claim_code = $2 AND user_id IS NULL
+				AND status IN ('waiting', 'called', 'active')
+		`, info.Ticket, info.ClaimCode).Scan(&entryID, &gameID)
+		if err == sql.ErrNoRows {
+			return errors.ErrEntryNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+		if err := ensureNotQueued(ctx, tx, gameID, []int{userID}); err != nil {
+			return err
+		}
+		if err := checkBan(ctx, tx, userID, gameID); err != nil {
+			return err
+		}
+
+		// claimed by someone else while we waited for the lock
+		res, err := tx.ExecContext(ctx, `
+			UPDATE queue SET user_id = $1, claim_code = NULL
+			WHERE id = $2 AND user_id IS NULL AND status IN ('waiting', 'called', 'active')
+		`, userID, entryID)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return errors.ErrEntryNotFound
+		}
+
+		return writeEvent(ctx, tx, 0, "claimed", gameID, entryID, map[string]string{"ticket": info.Ticket})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return q.GetEntryByID(ctx, entryID)
+}
+
+// RemoveEntry takes any entry still in play, registered or walk-in, out of
+// a game's queue. The row stays as history with status left. A slot the
+// entry held goes to the next player; the called entry, or 0, is returned.
+func (q *Queues) RemoveEntry(ctx context.Context, actorID, gameID, entryID int, pick domain.PickFunc, open domain.OpenFunc) (int, error) {
+	var calledID int
+
+	err := q.withTx(ctx, func(tx *sql.Tx) error {
+		if err := lockGame(ctx, tx, gameID); err != nil {
+			return err
+		}
+
+		var status string
+		var position int
+		err := tx.QueryRowContext(ctx, `
+			UPDATE queue q SET status = 'left', left_at = NOW(), position = 0
+			FROM (SELECT id, status, position FROM queue WHERE game_id = $1 AND id = $2) old
+			WHERE q.id = old.id AND old.status IN ('waiting', 'called', 'active')
+			RETURNING old.status, old.position
+		`, gameID, entryID).Scan(&status, &position)
+		if err == sql.ErrNoRows {
+			return errors.ErrEntryNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := compactPositions(ctx, tx, gameID); err != nil {
+			return err
+		}
+		if err := writeEvent(ctx, tx, actorID, "removed", gameID, entryID, nil); err != nil {
+			return err
+		}
+
+		if err := writeAudit(ctx, tx, actorID, "queue.remove", gameID, entryTarget(entryID),
+			map[string]any{"status": status, "position": position},
+			map[string]any{"status": domain.StatusLeft}); err != nil {
+			return err
+		}
+
+		calledID, err = callFreed(ctx, tx, actorID, gameID, status, pick, open)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return calledID, nil
+}
@@ -0,0 +1,52 @@
+package psql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+func TestClaimTicket(t *testing.T) {
+	tests := []struct {
+		name   string
+		queued bool
+		err    error
+	}{
+		{"not queued", false, nil},
+		{"already queued", true, e.ErrAlreadyInQueue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, db := testQueues(t)
+			ctx := context.Background()
+			gameID := newTestGame(t, db, 1)
+			userID := newTestUser(t, db, "claimer")
+
+			if tt.queued {
+				if _, err := q.AddPlayerToQueue(ctx, userID, gameID, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			ticket, err := q.IssueTicket(ctx, 0, gameID, domain.TicketInfo{Nickname: "guest"}, "code")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = q.ClaimTicket(ctx, userID, domain.ClaimInfo{Ticket: ticket.Number, ClaimCode: "code"})
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+
+			var owner int
+			if err := db.QueryRow(`SELECT COALESCE(user_id, 0) FROM queue WHERE id = $1`, ticket.EntryID).Scan(&owner); err != nil {
+				t.Fatal(err)
+			}
+			if tt.err == nil && owner != userID || tt.err != nil && owner != 0 {
+				t.Fatalf("ticket owner = %d", owner)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+const (
+	defaultAnalyticsSpan = 24 * time.Hour
+	maxAnalyticsSpan     = 366 * 24 * time.Hour
+	maxHourBuckets       = 31 * 24
+)
+
+var bucketLengths = map[string]time.Duration{
+	domain.BucketHour: time.Hour,
+	domain.BucketDay:  24 * time.Hour,
+}
+
+// analyticsRange fills in the defaults (the last day) and bounds the range
+// so a single request cannot aggregate years of hourly buckets.
+func analyticsRange(r domain.AnalyticsRange) (domain.AnalyticsRange, error) {
+	if r.To.IsZero() {
+		r.To = time.Now()
+	}
+	if r.From.IsZero() {
+		r.From = r.To.Add(-defaultAnalyticsSpan)
+	}
+	if !r.From.Before(r.To) {
+		return r, fmt.Errorf("%w: from must be before to", e.ErrInvalidParams)
+	}
+	if r.To.Sub(r.From) > maxAnalyticsSpan {
+		return r, fmt.Errorf("%w: range is longer than %d days", e.ErrInvalidParams, int(maxAnalyticsSpan.Hours()/24))
+	}
+	if r.Bucket != "" {
+		if _, ok := bucketLengths[r.Bucket]; !ok {
+			return r, fmt.Errorf("%w: bucket must be hour or day", e.ErrInvalidParams)
+		}
+	}
+	if r.Bucket == domain.BucketHour && r.To.Sub(r.From) > maxHourBuckets*time.Hour {
+		return r, fmt.Errorf("%w: hourly buckets cover at most %d days", e.ErrInvalidParams, maxHourBuckets/24)
+	}
+	return r, nil
+}
+
+// withRates derives the ratios from the counts. Utilisation divides the
+// busy time by the slot time available in the bucket, clipped to the
+// requested range at its edges.
+func withRates(s domain.GameStats, r domain.AnalyticsRange) domain.GameStats {
+	if s.Joined > 0 {
+		s.AbandonmentRate = float64(s.Abandoned) / float64(s.Joined)
+	}
+	if s.Called > 0 {
+		s.NoShowRate = float64(s.NoShows) / float64(s.Called)
+	}
+
+	start, end := r.From, r.To
+	if s.Bucket != nil {
+		start, end = *s.Bucket, s.Bucket.Add(bucketLengths[r.Bucket])
+		if start.Before(r.From) {
+			start = r.From
+		}
+		if end.After(r.To) {
+			end = r.To
+		}
+	}
+	if span := end.Sub(start).Seconds(); span > 0 && s.MaxSlots > 0 {
+		s.Utilisation = s.BusySeconds / (span * float64(s.MaxSlots))
+	}
+	return s
+}
+
+func (q *Queues) GetAnalytics(ctx context.Context, r domain.AnalyticsRange) (domain.ListGameStats, error) {
+	return q.gameStats(ctx, 0, r)
+}
+
+func (q *Queues) GetGameAnalytics(ctx context.Context, game_id int, r domain.AnalyticsRange) (domain.ListGameStats, error) {
+	if _, err := q.repo.GetGameInfoByID(ctx, game_id); err != nil {
+		return nil, err
+	}
+	return q.gameStats(ctx, game_id, r)
+}
+
+func (q *Queues) gameStats(ctx context.Context, game_id int, r domain.AnalyticsRange) (domain.ListGameStats, error) {
+	r, err := analyticsRange(r)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := q.repo.GetGameStats(ctx, game_id, r)
+	if err != nil {
+		return nil, err
+	}
+	for i := range stats {
+		stats[i] = withRates(stats[i], r)
+	}
+	return stats, nil
+}
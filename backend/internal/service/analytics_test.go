@@ -0,0 +1,92 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+func TestAnalyticsRange(t *testing.T) {
+	to := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name     string
+		r        domain.AnalyticsRange
+		wantFrom time.Time
+		ok       bool
+	}{
+		{"default span", domain.AnalyticsRange{To: to}, to.Add(-day), true},
+		{"explicit", domain.AnalyticsRange{From: to.Add(-3 * day), To: to}, to.Add(-3 * day), true},
+		{"empty", domain.AnalyticsRange{From: to, To: to}, to, false},
+		{"reversed", domain.AnalyticsRange{From: to.Add(time.Hour), To: to}, to.Add(time.Hour), false},
+		{"longest span", domain.AnalyticsRange{From: to.Add(-366 * day), To: to}, to.Add(-366 * day), true},
+		{"too long", domain.AnalyticsRange{From: to.Add(-367 * day), To: to}, to.Add(-367 * day), false},
+		{"day buckets", domain.AnalyticsRange{From: to.Add(-100 * day), To: to, Bucket: domain.BucketDay}, to.Add(-100 * day), true},
+		{"unknown bucket", domain.AnalyticsRange{To: to, Bucket: "week"}, to.Add(-day), false},
+		{"most hourly buckets", domain.AnalyticsRange{From: to.Add(-31 * day), To: to, Bucket: domain.BucketHour}, to.Add(-31 * day), true},
+		{"too many hourly buckets", domain.AnalyticsRange{From: to.Add(-32 * day), To: to, Bucket: domain.BucketHour}, to.Add(-32 * day), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := analyticsRange(tt.r)
+			if tt.ok != (err == nil) {
+				t.Fatalf("err = %v, want ok %v", err, tt.ok)
+			}
+			if err != nil {
+				if !errors.Is(err, e.ErrInvalidParams) {
+					t.Fatalf("err = %v, want ErrInvalidParams", err)
+				}
+				return
+			}
+			if !r.From.Equal(tt.wantFrom) || !r.To.Equal(to) {
+				t.Fatalf("range = [%v, %v), want [%v, %v)", r.From, r.To, tt.wantFrom, to)
+			}
+		})
+	}
+}
+
+func TestWithRates(t *testing.T) {
+	// 10:30 to 13:30 UTC, cut into hour buckets starting at 10:00
+	from := time.Date(2026, 5, 1, 10, 30, 0, 0, time.UTC)
+	to := from.Add(3 * time.Hour)
+	hourly := domain.AnalyticsRange{From: from, To: to, Bucket: domain.BucketHour}
+	bucket := func(hour int) *time.Time {
+		b := time.Date(2026, 5, 1, hour, 0, 0, 0, time.UTC)
+		return &b
+	}
+
+	tests := []struct {
+		name        string
+		stats       domain.GameStats
+		r           domain.AnalyticsRange
+		abandonment float64
+		noShow      float64
+		utilisation float64
+	}{
+		{"no entries", domain.GameStats{MaxSlots: 2}, hourly, 0, 0, 0},
+		{"rates", domain.GameStats{Joined: 4, Abandoned: 1, Called: 2, NoShows: 1}, hourly, 0.25, 0.5, 0},
+		{"no slots", domain.GameStats{Bucket: bucket(11), BusySeconds: 3600}, hourly, 0, 0, 0},
+		{"whole range", domain.GameStats{MaxSlots: 2, BusySeconds: 3 * 3600}, domain.AnalyticsRange{From: from, To: to}, 0, 0, 0.5},
+		{"inner bucket", domain.GameStats{Bucket: bucket(11), MaxSlots: 2, BusySeconds: 3600}, hourly, 0, 0, 0.5},
+		// the first bucket starts before the range and only has half an hour of it
+		{"first bucket clipped", domain.GameStats{Bucket: bucket(10), MaxSlots: 2, BusySeconds: 1800}, hourly, 0, 0, 0.5},
+		{"last bucket clipped", domain.GameStats{Bucket: bucket(13), MaxSlots: 1, BusySeconds: 1800}, hourly, 0, 0, 1},
+		{"day bucket", domain.GameStats{Bucket: bucket(0), MaxSlots: 1, BusySeconds: 3600},
+			domain.AnalyticsRange{From: bucket(0).Add(-time.Hour), To: bucket(0).Add(48 * time.Hour), Bucket: domain.BucketDay}, 0, 0, 1.0 / 24},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := withRates(tt.stats, tt.r)
+			if s.AbandonmentRate != tt.abandonment || s.NoShowRate != tt.noShow {
+				t.Fatalf("abandonment %v, no-show %v, want %v, %v", s.AbandonmentRate, s.NoShowRate, tt.abandonment, tt.noShow)
+			}
+			if diff := s.Utilisation - tt.utilisation; diff > 1e-9 || diff < -1e-9 {
+				t.Fatalf("utilisation = %v, want %v", s.Utilisation, tt.utilisation)
+			}
+		})
+	}
+}
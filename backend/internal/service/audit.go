@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+func (q *Queues) GetAuditLog(ctx context.Context, filter domain.AuditFilter, page domain.ListFilter, list *domain.ListAuditRecords) (*domain.Cursor, error) {
+	return q.repo.GetAuditLog(ctx, filter, page, list)
+}
+
+func (q *Queues) ExportAuditLog(ctx context.Context, filter domain.AuditFilter, fn func(*domain.AuditRecord) error) error {
+	return q.repo.ExportAuditLog(ctx, filter, fn)
+}
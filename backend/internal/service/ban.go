@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+// A user who leaves the same queue rejoinLimit times within rejoinWindow
+// is kept out of it for rejoinCooldown.
+const (
+	rejoinLimit    = 3
+	rejoinWindow   = 30 * time.Minute
+	rejoinCooldown = 15 * time.Minute
+)
+
+// checkBan fails with ErrBanned while a ban keeps the user out of the
+// game, or out of everything when game_id is 0.
+func (q *Queues) checkBan(ctx context.Context, user_id, game_id int) error {
+	ban, err := q.repo.GetActiveBan(ctx, user_id, game_id)
+	if errors.Is(err, e.ErrBanNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return e.Banned(ban.Reason, ban.ExpiresAt)
+}
+
+// applyRejoinCooldown bans the user from the game for rejoinCooldown once
+// they have left its queue too often, so that joining and leaving cannot
+// be used to spam the board.
+func (q *Queues) applyRejoinCooldown(ctx context.Context, user_id, game_id int) error {
+	n, err := q.repo.CountLeaves(ctx, user_id, game_id, rejoinWindow)
+	if err != nil || n < rejoinLimit {
+		return err
+	}
+
+	expires := time.Now().Add(rejoinCooldown)
+	_, err = q.repo.CreateBan(ctx, 0, domain.Ban{
+		UserID:    user_id,
+		GameID:    game_id,
+		Reason:    fmt.Sprintf("left the queue %d times in %s", n, rejoinWindow),
+		ExpiresAt: &expires,
+	})
+	return err
+}
+
+func (q *Queues) GetBans(ctx context.Context, event_id int) (domain.ListBans, error) {
+	return q.repo.GetBans(ctx, event_id)
+}
+
+func (q *Queues) CreateBan(ctx context.Context, actorID int, info domain.BanInfo) (int, error) {
+	info.Reason = strings.TrimSpace(info.Reason)
+	if info.Reason == "" {
+		return 0, fmt.Errorf("%w: reason is required", e.ErrInvalidParams)
+	}
+	if info.ExpiresAt != nil && !info.ExpiresAt.After(time.Now()) {
+		return 0, fmt.Errorf("%w: expires_at is in the past", e.ErrInvalidParams)
+	}
+
+	user_id, err := q.repo.GetIdByLogin(ctx, info.Login)
+	if err != nil {
+		return 0, err
+	}
+	if info.GameID != 0 {
+		if _, err := q.repo.GetGameInfoByID(ctx, info.GameID); err != nil {
+			return 0, err
+		}
+	}
+
+	return q.repo.CreateBan(ctx, actorID, domain.Ban{
+		UserID:    user_id,
+		GameID:    info.GameID,
+		Reason:    info.Reason,
+		ExpiresAt: info.ExpiresAt,
+	})
+}
+
+func (q *Queues) LiftBan(ctx context.Context, actorID, ban_id int) error {
+	return q.repo.LiftBan(ctx, actorID, ban_id)
+}
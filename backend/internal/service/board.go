@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	boardTTL = 2 * time.Second
+	// a board outdated by a queue update is still served this long, so a
+	// burst of updates costs one rebuild
+	boardMinAge = 250 * time.Millisecond
+)
+
+// boardCache keeps the last built board per "next" size so that many wall
+// screens polling at once cost one pair of queries every boardTTL. Queue
+// updates mark the boards outdated rather than dropping them, and
+// concurrent rebuilds of the same board are coalesced into one.
+type boardCache struct {
+	mu      sync.Mutex
+	boards  map[int]*domain.Board
+	changed time.Time // last queue update
+	group   singleflight.Group
+}
+
+func (c *boardCache) get(next int, now time.Time) *domain.Board {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	board := c.boards[next]
+	if board == nil {
+		return nil
+	}
+	age := now.Sub(board.GeneratedAt)
+	if age > boardTTL || (c.changed.After(board.GeneratedAt) && age > boardMinAge) {
+		return nil
+	}
+	return board
+}
+
+func (c *boardCache) put(next int, board *domain.Board) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.boards == nil {
+		c.boards = map[int]*domain.Board{}
+	}
+	c.boards[next] = board
+}
+
+func (c *boardCache) touch(now time.Time) {
+	c.mu.Lock()
+	c.changed = now
+	c.mu.Unlock()
+}
+
+// GetBoard returns the board of the event's games, or of every game when
+// event_id is 0. The cache holds the whole board and is scoped per call.
+func (q *Queues) GetBoard(ctx context.Context, event_id, next int) (*domain.Board, error) {
+	if board := q.board.get(next, time.Now()); board != nil {
+		return scopeBoard(board, event_id), nil
+	}
+
+	// the build is shared by every caller waiting for it, so it must not
+	// fail because the first one went away
+	v, err, _ := q.board.group.Do(strconv.Itoa(next), func() (any, error) {
+		return q.buildBoard(context.WithoutCancel(ctx), next)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return scopeBoard(v.(*domain.Board), event_id), nil
+}
+
+func (q *Queues) buildBoard(ctx context.Context, next int) (*domain.Board, error) {
+	now := time.Now()
+	games, entries, err := q.repo.GetBoard(ctx, next)
+	if err != nil {
+		return nil, err
+	}
+
+	byGame := map[int]*domain.BoardGame{}
+	for i := range games {
+		games[i].Playing = []domain.BoardEntry{}
+		games[i].Next = []domain.BoardEntry{}
+		byGame[games[i].ID] = &games[i]
+	}
+
+	for _, entry := range entries {
+		game := byGame[entry.GameID]
+		if game == nil {
+			continue
+		}
+
+		item := domain.BoardEntry{
+			Name:    boardName(entry),
+			Status:  entry.Status,
+			Station: entry.Station,
+		}
+		switch entry.Status {
+		case domain.StatusWaiting:
+			item.Position = entry.Position
+			game.Next = append(game.Next, item)
+		case domain.StatusActive:
+			if entry.StartedAt != nil {
+				left := entry.StartedAt.Add(time.Duration(game.DurationSeconds)*time.Second).Sub(now) / time.Second
+				remaining := max(int(left), 0)
+				item.RemainingSeconds = &remaining
+			}
+			game.Playing = append(game.Playing, item)
+		default:
+			game.Playing = append(game.Playing, item)
+		}
+	}
+
+	board := &domain.Board{Games: games, GeneratedAt: now}
+	q.board.put(next, board)
+	return board, nil
+}
+
+func scopeBoard(board *domain.Board, event_id int) *domain.Board {
+	if event_id == 0 {
+		return board
+	}
+	scoped := &domain.Board{Games: []domain.BoardGame{}, GeneratedAt: board.GeneratedAt}
+	for _, game := range board.Games {
+		if game.EventID == event_id {
+			scoped.Games = append(scoped.Games, game)
+		}
+	}
+	return scoped
+}
+
+// SubscribeAll returns updates of every game's queue.
+func (q *Queues) SubscribeAll() (<-chan domain.QueueUpdate, func()) {
+	return q.events.subscribe(allGames)
+}
+
+func boardName(entry domain.QueueEntry) string {
+	switch {
+	case entry.Ticket != "" && entry.Nickname != "":
+		return entry.Ticket + " " + maskName(entry.Nickname)
+	case entry.Ticket != "":
+		return entry.Ticket
+	}
+	return maskName(entry.Login)
+}
+
+// maskName keeps the first two letters of a name, "alice" -> "al***".
+func maskName(name string) string {
+	if utf8.RuneCountInString(name) <= 2 {
+		return strings.Repeat("*", 3)
+	}
+	runes := []rune(name)
+	return string(runes[:2]) + "***"
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+func TestBoardCacheGet(t *testing.T) {
+	built := time.Unix(1_700_000_000, 0)
+
+	tests := []struct {
+		name    string
+		age     time.Duration
+		changed time.Duration // since built; 0 for no change
+		hit     bool
+	}{
+		{"fresh", time.Second, 0, true},
+		{"expired", boardTTL + time.Millisecond, 0, false},
+		{"changed but young", boardMinAge / 2, time.Millisecond, true},
+		{"changed and old enough", boardMinAge + time.Millisecond, time.Millisecond, false},
+		{"changed before build", time.Second, -time.Millisecond, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c boardCache
+			c.put(5, &domain.Board{GeneratedAt: built})
+			if tt.changed != 0 {
+				c.touch(built.Add(tt.changed))
+			}
+			if got := c.get(5, built.Add(tt.age)) != nil; got != tt.hit {
+				t.Fatalf("hit = %v, want %v", got, tt.hit)
+			}
+			if c.get(3, built) != nil {
+				t.Fatal("hit for a size never built")
+			}
+		})
+	}
+}
+
+// boardRepo counts board queries and holds them until released.
+type boardRepo struct {
+	QueuesRepository
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (r *boardRepo) GetBoard(ctx context.Context, next int) ([]domain.BoardGame, domain.ListQueueEntries, error) {
+	r.calls.Add(1)
+	<-r.release
+	return []domain.BoardGame{{ID: 1}}, nil, nil
+}
+
+func TestGetBoardCoalesces(t *testing.T) {
+	repo := &boardRepo{release: make(chan struct{})}
+	q := NewQueues(repo, nil, nil)
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := q.GetBoard(context.Background(), 0, 5); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	// let the callers pile up behind the first build
+	time.Sleep(50 * time.Millisecond)
+	close(repo.release)
+	wg.Wait()
+
+	if n := repo.calls.Load(); n != 1 {
+		t.Fatalf("built %d times, want 1", n)
+	}
+
+	q.notify(1, "joined", 0)
+	if _, err := q.GetBoard(context.Background(), 0, 5); err != nil {
+		t.Fatal(err)
+	}
+	if n := repo.calls.Load(); n != 1 {
+		t.Fatalf("an update right after a build rebuilt the board")
+	}
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"sync"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+// broker fans queue updates out to live subscribers of a game, and to
+// subscribers of allGames. Slow subscribers miss updates rather than
+// blocking the publisher.
+const allGames = 0
+
+type broker struct {
+	mu   sync.Mutex
+	subs map[int]map[chan domain.QueueUpdate]struct{}
+}
+
+func newBroker() *broker {
+	return &broker{subs: map[int]map[chan domain.QueueUpdate]struct{}{}}
+}
+
+func (b *broker) subscribe(gameID int) (<-chan domain.QueueUpdate, func()) {
+	ch := make(chan domain.QueueUpdate, 16)
+
+	b.mu.Lock()
+	if b.subs[gameID] == nil {
+		b.subs[gameID] = map[chan domain.QueueUpdate]struct{}{}
+	}
+	b.subs[gameID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subs[gameID], ch)
+		if len(b.subs[gameID]) == 0 {
+			delete(b.subs, gameID)
+		}
+		b.mu.Unlock()
+		close(ch)
+	}
+}
+
+func (b *broker) publish(update domain.QueueUpdate) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, gameID := range []int{update.GameID, allGames} {
+		for ch := range b.subs[gameID] {
+			select {
+			case ch <- update:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+const checkinTokenTTL = 2 * time.Minute
+
+// Check-in tokens have the form <entry>.<game>.<expires>.<signature>, where
+// the signature is an HMAC-SHA256 over the first three parts. They are
+// short-lived, so the user's page fetches a fresh QR code periodically.
+
+func (q *Queues) signCheckin(entry_id, game_id int, expires time.Time) string {
+	payload := fmt.Sprintf("%d.%d.%d", entry_id, game_id, expires.Unix())
+	mac := hmac.New(sha256.New, q.checkinKey)
+	mac.Write([]byte(payload))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (q *Queues) verifyCheckin(token string, now time.Time) (int, int, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 {
+		return 0, 0, e.ErrInvalidToken
+	}
+
+	entry_id, err1 := strconv.Atoi(parts[0])
+	game_id, err2 := strconv.Atoi(parts[1])
+	expires, err3 := strconv.ParseInt(parts[2], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return 0, 0, e.ErrInvalidToken
+	}
+
+	expected := q.signCheckin(entry_id, game_id, time.Unix(expires, 0))
+	if !hmac.Equal([]byte(expected), []byte(token)) {
+		return 0, 0, e.ErrInvalidToken
+	}
+	if now.Unix() > expires {
+		return 0, 0, e.ErrTokenExpired
+	}
+
+	return entry_id, game_id, nil
+}
+
+// CheckinToken issues a check-in token for the user's current entry in a game.
+func (q *Queues) CheckinToken(ctx context.Context, user_id, game_id int) (string, error) {
+	entry, err := q.repo.GetEntryForUser(ctx, game_id, user_id)
+	if err != nil {
+		return "", err
+	}
+	return q.signCheckin(entry.ID, game_id, time.Now().Add(checkinTokenTTL)), nil
+}
+
+// ScanCheckin validates a token scanned at the stand of game_id and marks
+// the called player as arrived.
+func (q *Queues) ScanCheckin(ctx context.Context, actorID, game_id int, token string) (*domain.QueueEntry, error) {
+	entry_id, token_game, err := q.verifyCheckin(token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if token_game != game_id {
+		return nil, e.ErrWrongGame
+	}
+
+	entry, err := q.repo.GetEntryByID(ctx, entry_id)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Status != domain.StatusCalled {
+		return nil, e.ErrNotCalled
+	}
+
+	if err := q.MarkArrived(ctx, actorID, game_id, entry_id); err != nil {
+		return nil, err
+	}
+	entry.Status = domain.StatusActive
+	return entry, nil
+}
@@ -0,0 +1,19 @@
+package service
+
+import (
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+// estimateStart assumes every busy slot has just started a session and
+// that slots free up in whole rounds of duration_seconds. ahead is the
+// slots taken by the entries waiting in front, so a party counts for each
+// of its players; slots is what the entry itself needs.
+func estimateStart(game *domain.Game, busy, ahead, slots int, now time.Time) time.Time {
+	if game.Max_slots <= 0 {
+		return now
+	}
+	rounds := (busy + ahead + max(slots, 1) - 1) / game.Max_slots
+	return now.Add(time.Duration(rounds*game.Duration_seconds) * time.Second)
+}
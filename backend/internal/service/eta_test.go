@@ -0,0 +1,38 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+func TestEstimateStart(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	game := &domain.Game{Max_slots: 4, Duration_seconds: 600}
+
+	tests := []struct {
+		name   string
+		busy   int
+		ahead  int
+		slots  int
+		rounds int
+	}{
+		{"free slot", 0, 0, 1, 0},
+		{"slots left this round", 2, 1, 1, 0},
+		{"full", 4, 0, 1, 1},
+		{"party of four ahead", 0, 4, 1, 1},
+		{"behind a party and players", 2, 5, 1, 1},
+		{"two rounds ahead", 4, 4, 1, 2},
+		{"party needs room for all", 2, 0, 3, 1},
+		{"party fits", 1, 0, 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := estimateStart(game, tt.busy, tt.ahead, tt.slots, now)
+			if want := now.Add(time.Duration(tt.rounds*600) * time.Second); !got.Equal(want) {
+				t.Fatalf("start = %v, want %d rounds", got.Sub(now), tt.rounds)
+			}
+		})
+	}
+}
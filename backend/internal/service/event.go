@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+var eventStatuses = []string{domain.EventDraft, domain.EventActive, domain.EventFinished}
+
+// validateEvent checks an event and defaults its status to draft.
+func validateEvent(event *domain.Event) error {
+	if event.Name == "" {
+		return fmt.Errorf("%w: event name is required", e.ErrInvalidParams)
+	}
+	starts, err := time.Parse(time.DateOnly, event.StartsOn)
+	if err != nil {
+		return fmt.Errorf("%w: starts_on: %v", e.ErrInvalidParams, err)
+	}
+	ends, err := time.Parse(time.DateOnly, event.EndsOn)
+	if err != nil {
+		return fmt.Errorf("%w: ends_on: %v", e.ErrInvalidParams, err)
+	}
+	if ends.Before(starts) {
+		return fmt.Errorf("%w: event ends before it starts", e.ErrInvalidParams)
+	}
+	if event.Status == "" {
+		event.Status = domain.EventDraft
+	}
+	if !slices.Contains(eventStatuses, event.Status) {
+		return fmt.Errorf("%w: status must be draft, active or finished", e.ErrInvalidParams)
+	}
+	return nil
+}
+
+func (q *Queues) GetEvents(ctx context.Context) (domain.ListEvents, error) {
+	return q.repo.GetEvents(ctx)
+}
+
+func (q *Queues) GetEvent(ctx context.Context, event_id int) (*domain.Event, error) {
+	return q.repo.GetEvent(ctx, event_id)
+}
+
+func (q *Queues) GetActiveEvent(ctx context.Context) (*domain.Event, error) {
+	return q.repo.GetActiveEvent(ctx)
+}
+
+// ActiveEventID returns the id of the active event, or 0 between events.
+func (q *Queues) ActiveEventID(ctx context.Context) (int, error) {
+	event, err := q.repo.GetActiveEvent(ctx)
+	if err == e.ErrEventNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return event.ID, nil
+}
+
+func (q *Queues) CreateEvent(ctx context.Context, actorID int, event domain.Event) (*domain.Event, error) {
+	if err := validateEvent(&event); err != nil {
+		return nil, err
+	}
+	id, err := q.repo.CreateEvent(ctx, actorID, event)
+	if err != nil {
+		return nil, err
+	}
+	event.ID = id
+	return &event, nil
+}
+
+func (q *Queues) UpdateEvent(ctx context.Context, actorID, event_id int, event domain.Event) error {
+	if err := validateEvent(&event); err != nil {
+		return err
+	}
+	event.ID = event_id
+	return q.repo.UpdateEvent(ctx, actorID, event)
+}
+
+func (q *Queues) JoinEvent(ctx context.Context, user_id, event_id int) error {
+	return q.repo.JoinEvent(ctx, event_id, user_id)
+}
+
+func (q *Queues) LeaveEvent(ctx context.Context, user_id, event_id int) error {
+	return q.repo.LeaveEvent(ctx, event_id, user_id)
+}
+
+func (q *Queues) GetEventMembers(ctx context.Context, event_id int) (domain.ListEventMembers, error) {
+	return q.repo.GetEventMembers(ctx, event_id)
+}
+
+func (q *Queues) SetEventMember(ctx context.Context, actorID, event_id int, login, role string) error {
+	if !slices.Contains(userRoles, role) {
+		return fmt.Errorf("%w: role must be user, operator or admin", e.ErrInvalidParams)
+	}
+	user_id, err := q.repo.GetIdByLogin(ctx, login)
+	if err != nil {
+		return err
+	}
+	return q.repo.SetEventMember(ctx, actorID, event_id, user_id, role)
+}
+
+func (q *Queues) SetGameEvent(ctx context.Context, actorID, game_id, event_id int) error {
+	if event_id < 0 {
+		return e.ErrInvalidParams
+	}
+	return q.repo.SetGameEvent(ctx, actorID, game_id, event_id)
+}
+
+// EventRole returns the role the user holds in an event, "" if none.
+func (q *Queues) EventRole(ctx context.Context, event_id, user_id int) (string, error) {
+	return q.repo.GetEventRole(ctx, event_id, user_id)
+}
+
+// GameEventRole returns the role the user holds in the event of a game.
+func (q *Queues) GameEventRole(ctx context.Context, game_id, user_id int) (string, error) {
+	return q.repo.GetGameEventRole(ctx, game_id, user_id)
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+func (q *Queues) ExportGames(ctx context.Context, fn func(*domain.Game) error) error {
+	return q.repo.ExportGames(ctx, fn)
+}
+
+func (q *Queues) ExportUsers(ctx context.Context, fn func(*domain.UserRecord) error) error {
+	return q.repo.ExportUsers(ctx, fn)
+}
+
+// ExportQueues streams the entries currently in play.
+func (q *Queues) ExportQueues(ctx context.Context, fn func(*domain.QueueEntry) error) error {
+	return q.repo.ExportEntries(ctx, false, fn)
+}
+
+// ExportHistory streams every queue entry ever made, finished or not.
+func (q *Queues) ExportHistory(ctx context.Context, fn func(*domain.QueueEntry) error) error {
+	return q.repo.ExportEntries(ctx, true, fn)
+}
+
+func (q *Queues) ExportEvents(ctx context.Context, fn func(*domain.QueueEvent) error) error {
+	return q.repo.ExportEvents(ctx, fn)
+}
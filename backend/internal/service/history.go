@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+func (q *Queues) GetUserHistory(ctx context.Context, user_id int, filter domain.ListFilter, list *domain.ListHistoryEntries) (*domain.Cursor, error) {
+	return q.repo.GetUserHistory(ctx, user_id, filter, list)
+}
+
+func (q *Queues) GetGameTimeline(ctx context.Context, game_id int, filter domain.ListFilter, list *domain.ListQueueEvents) (*domain.Cursor, error) {
+	if _, err := q.repo.GetGameInfoByID(ctx, game_id); err != nil {
+		return nil, err
+	}
+	return q.repo.GetGameTimeline(ctx, game_id, filter, list)
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+const clockLayout = "15:04"
+
+// windowSpan places a window on the local calendar day that starts at day.
+func windowSpan(day time.Time, w domain.HoursWindow) (time.Time, time.Time, bool) {
+	opens, err := time.Parse(clockLayout, w.Opens)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	closes, err := time.Parse(clockLayout, w.Closes)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	y, m, d := day.Date()
+	start := time.Date(y, m, d, opens.Hour(), opens.Minute(), 0, 0, day.Location())
+	end := time.Date(y, m, d, closes.Hour(), closes.Minute(), 0, 0, day.Location())
+	if !end.After(start) {
+		end = time.Date(y, m, d+1, closes.Hour(), closes.Minute(), 0, 0, day.Location())
+	}
+	return start, end, true
+}
+
+// currentWindow returns the window now falls into. Windows that started
+// the day before are checked too, since they may run past midnight.
+func currentWindow(windows []domain.HoursWindow, now time.Time) (time.Time, time.Time, bool) {
+	y, m, d := now.Date()
+	for offset := -1; offset <= 0; offset++ {
+		day := time.Date(y, m, d+offset, 0, 0, 0, 0, now.Location())
+		for _, w := range windows {
+			if w.Weekday != int(day.Weekday()) {
+				continue
+			}
+			start, end, ok := windowSpan(day, w)
+			if ok && !now.Before(start) && now.Before(end) {
+				return start, end, true
+			}
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// nextOpening returns when the first window after now starts, looking one
+// week ahead.
+func nextOpening(windows []domain.HoursWindow, now time.Time) (time.Time, bool) {
+	y, m, d := now.Date()
+	for offset := 0; offset <= 7; offset++ {
+		day := time.Date(y, m, d+offset, 0, 0, 0, 0, now.Location())
+		var next time.Time
+		for _, w := range windows {
+			if w.Weekday != int(day.Weekday()) {
+				continue
+			}
+			start, _, ok := windowSpan(day, w)
+			if ok && start.After(now) && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+		if !next.IsZero() {
+			return next, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// hoursStatus works out whether a game takes new players at now.
+func (q *Queues) hoursStatus(ctx context.Context, game_id int, now time.Time) (*domain.HoursStatus, error) {
+	hours, err := q.repo.GetHours(ctx, game_id)
+	if err != nil {
+		return nil, err
+	}
+	game, err := q.repo.GetGameInfoByID(ctx, game_id)
+	if err != nil {
+		return nil, err
+	}
+	busy, err := q.repo.CountBusySlots(ctx, game_id)
+	if err != nil {
+		return nil, err
+	}
+	waiting, err := q.repo.GetWaitingSlots(ctx, game_id)
+	if err != nil {
+		return nil, err
+	}
+	state := domain.OpenState{Hours: *hours, Game: *game, Busy: busy, At: now}
+	for _, slots := range waiting {
+		state.Waiting += slots
+	}
+	return openStatus(state)
+}
+
+// openStatus works out whether a game takes new players at state.At.
+// Inside a window the game stops taking them at last call: once a
+// newcomer, by the same estimate the queue shows, could not finish a
+// session before the window closes.
+func openStatus(state domain.OpenState) (*domain.HoursStatus, error) {
+	hours, game, now := state.Hours, &state.Game, state.At
+
+	status := &domain.HoursStatus{Hours: hours, Open: true}
+	if len(hours.Windows) > 0 {
+		loc, err := time.LoadLocation(hours.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		now = now.In(loc)
+
+		if _, end, ok := currentWindow(hours.Windows, now); ok {
+			status.ClosesAt = &end
+
+			start := estimateStart(game, state.Busy, state.Waiting, 1, now)
+			if start.Add(time.Duration(game.Duration_seconds) * time.Second).After(end) {
+				status.Open, status.Reason = false, domain.ClosedLastCall
+				if next, ok := nextOpening(hours.Windows, end); ok {
+					status.OpensAt = &next
+				}
+			}
+		} else {
+			status.Open, status.Reason = false, domain.ClosedHours
+			if next, ok := nextOpening(hours.Windows, now); ok {
+				status.OpensAt = &next
+			}
+		}
+	}
+
+	if hours.Paused {
+		status.Open, status.Reason = false, domain.ClosedPaused
+	}
+	return status, nil
+}
+
+// checkOpen is the domain.OpenFunc handed to the repository: it refuses a
+// self-service join while the game is closed, inside the join transaction.
+// Operators placing players by hand are not subject to it.
+func (q *Queues) checkOpen(state domain.OpenState) error {
+	status, err := openStatus(state)
+	if err != nil {
+		return err
+	}
+	if !status.Open {
+		return fmt.Errorf("%w: %s", e.ErrQueueClosed, status.Reason)
+	}
+	return nil
+}
+
+func (q *Queues) GetHours(ctx context.Context, game_id int) (*domain.HoursStatus, error) {
+	return q.hoursStatus(ctx, game_id, time.Now())
+}
+
+func (q *Queues) SetHours(ctx context.Context, actorID, game_id int, hours domain.Hours) error {
+	if hours.Timezone == "" {
+		hours.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(hours.Timezone); err != nil {
+		return fmt.Errorf("%w: timezone: %v", e.ErrInvalidParams, err)
+	}
+	for _, w := range hours.Windows {
+		if w.Weekday < 0 || w.Weekday > 6 || w.Opens == w.Closes {
+			return e.ErrInvalidParams
+		}
+		if _, _, ok := windowSpan(time.Now(), w); !ok {
+			return fmt.Errorf("%w: times must be HH:MM", e.ErrInvalidParams)
+		}
+	}
+
+	if err := q.repo.SetHours(ctx, actorID, game_id, hours); err != nil {
+		return err
+	}
+	q.notify(game_id, "hours", 0)
+	return nil
+}
+
+// SetPaused closes (or reopens) joining by hand, whatever the schedule says.
+func (q *Queues) SetPaused(ctx context.Context, actorID, game_id int, paused bool) error {
+	if err := q.repo.SetPaused(ctx, actorID, game_id, paused); err != nil {
+		return err
+	}
+	kind := "resumed"
+	if paused {
+		kind = "paused"
+	}
+	q.notify(game_id, kind, 0)
+	return nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+func TestOpenStatus(t *testing.T) {
+	// Friday 2026-05-01, open 10:00-18:00, sessions of 30 minutes, 2 slots
+	hours := domain.Hours{
+		Timezone: "UTC",
+		Windows:  []domain.HoursWindow{{Weekday: int(time.Friday), Opens: "10:00", Closes: "18:00"}},
+	}
+	game := domain.Game{Max_slots: 2, Duration_seconds: 1800}
+	at := func(clock string) time.Time {
+		t, _ := time.Parse("2006-01-02 15:04", "2026-05-01 "+clock)
+		return t
+	}
+
+	tests := []struct {
+		name    string
+		hours   domain.Hours
+		waiting int // slots
+		busy    int
+		at      time.Time
+		open    bool
+		reason  string
+	}{
+		{"no windows", domain.Hours{Timezone: "UTC"}, 0, 0, at("03:00"), true, ""},
+		{"inside", hours, 0, 0, at("12:00"), true, ""},
+		{"before opening", hours, 0, 0, at("09:59"), false, domain.ClosedHours},
+		{"after closing", hours, 0, 0, at("18:00"), false, domain.ClosedHours},
+		{"last session fits", hours, 0, 0, at("17:30"), true, ""},
+		{"last session does not fit", hours, 0, 0, at("17:31"), false, domain.ClosedLastCall},
+		// two rounds of players ahead push the start back an hour
+		{"line ahead fits", hours, 2, 2, at("16:30"), true, ""},
+		{"line ahead past last call", hours, 2, 2, at("16:31"), false, domain.ClosedLastCall},
+		{"paused", domain.Hours{Timezone: "UTC", Paused: true}, 0, 0, at("12:00"), false, domain.ClosedPaused},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := openStatus(domain.OpenState{Hours: tt.hours, Game: game, Waiting: tt.waiting, Busy: tt.busy, At: tt.at})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status.Open != tt.open || status.Reason != tt.reason {
+				t.Fatalf("open = %v (%q), want %v (%q)", status.Open, status.Reason, tt.open, tt.reason)
+			}
+		})
+	}
+}
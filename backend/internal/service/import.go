@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var userRoles = []string{domain.RoleUser, domain.RoleOperator, domain.RoleAdmin}
+
+// rejectedRows lists the rows that could not even be decoded; they are
+// not validated further.
+func rejectedRows(decodeErrs []domain.ImportError) map[int]bool {
+	rejected := map[int]bool{}
+	for _, err := range decodeErrs {
+		rejected[err.Row] = true
+	}
+	return rejected
+}
+
+// validateGames checks each row on its own and against the other rows;
+// decodeErrs, from reading the file, are kept in front.
+func validateGames(rows []domain.GameImport, decodeErrs []domain.ImportError) []domain.ImportError {
+	errs := append([]domain.ImportError{}, decodeErrs...)
+	rejected := rejectedRows(decodeErrs)
+	type key struct {
+		eventID int
+		name    string
+	}
+	seen := map[key]int{}
+	for i, row := range rows {
+		n := i + 1
+		if rejected[n] {
+			continue
+		}
+		k := key{row.EventID, row.Name}
+		if row.Name == "" {
+			errs = append(errs, domain.ImportError{Row: n, Field: "name", Error: "required"})
+		} else if first, ok := seen[k]; ok {
+			errs = append(errs, domain.ImportError{Row: n, Field: "name", Error: fmt.Sprintf("duplicate of row %d", first)})
+		} else {
+			seen[k] = n
+		}
+		if row.MaxSlots <= 0 {
+			errs = append(errs, domain.ImportError{Row: n, Field: "max_slots", Error: "must be positive"})
+		}
+		if row.DurationSeconds < 0 {
+			errs = append(errs, domain.ImportError{Row: n, Field: "duration_seconds", Error: "must not be negative"})
+		}
+	}
+	return errs
+}
+
+func validateUsers(rows []domain.UserImport, decodeErrs []domain.ImportError) []domain.ImportError {
+	errs := append([]domain.ImportError{}, decodeErrs...)
+	rejected := rejectedRows(decodeErrs)
+	seen := map[string]int{}
+	for i, row := range rows {
+		n := i + 1
+		if rejected[n] {
+			continue
+		}
+		if row.Login == "" {
+			errs = append(errs, domain.ImportError{Row: n, Field: "login", Error: "required"})
+		} else if first, ok := seen[row.Login]; ok {
+			errs = append(errs, domain.ImportError{Row: n, Field: "login", Error: fmt.Sprintf("duplicate of row %d", first)})
+		} else {
+			seen[row.Login] = n
+		}
+		if row.Role != "" && !slices.Contains(userRoles, row.Role) {
+			errs = append(errs, domain.ImportError{Row: n, Field: "role", Error: "must be user, operator or admin"})
+		}
+		if row.Priority < 0 {
+			errs = append(errs, domain.ImportError{Row: n, Field: "priority", Error: "must not be negative"})
+		}
+	}
+	return errs
+}
+
+// ImportGames validates every row and, unless that fails or it is a dry
+// run, upserts them all in one transaction. A dry run still goes through
+// the database, so its counts are those a real run would report.
+func (q *Queues) ImportGames(ctx context.Context, actorID int, rows []domain.GameImport, decodeErrs []domain.ImportError, dryRun bool) (*domain.ImportResult, error) {
+	result := &domain.ImportResult{DryRun: dryRun, Errors: validateGames(rows, decodeErrs)}
+
+	known := map[int]bool{}
+	for i, row := range rows {
+		if row.EventID == 0 {
+			continue
+		}
+		if _, ok := known[row.EventID]; !ok {
+			_, err := q.repo.GetEvent(ctx, row.EventID)
+			if err != nil && err != e.ErrEventNotFound {
+				return nil, err
+			}
+			known[row.EventID] = err == nil
+		}
+		if !known[row.EventID] {
+			result.Errors = append(result.Errors, domain.ImportError{Row: i + 1, Field: "event_id", Error: "no such event"})
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		return result, nil
+	}
+	if err := q.repo.ImportGames(ctx, actorID, rows, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ImportUsers is ImportGames for users. Passwords are hashed here, except
+// on a dry run where only their presence matters.
+func (q *Queues) ImportUsers(ctx context.Context, actorID int, rows []domain.UserImport, decodeErrs []domain.ImportError, dryRun bool) (*domain.ImportResult, error) {
+	result := &domain.ImportResult{DryRun: dryRun, Errors: validateUsers(rows, decodeErrs)}
+	if len(result.Errors) > 0 {
+		return result, nil
+	}
+
+	if !dryRun {
+		for i := range rows {
+			if rows[i].Password == "" {
+				continue
+			}
+			hash, err := bcrypt.GenerateFromPassword([]byte(rows[i].Password), bcrypt.DefaultCost)
+			if err != nil {
+				return nil, err
+			}
+			rows[i].Password = string(hash)
+		}
+	}
+
+	if err := q.repo.ImportUsers(ctx, actorID, rows, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+func TestValidateGames(t *testing.T) {
+	decodeErr := domain.ImportError{Row: 2, Error: "wrong number of columns"}
+
+	tests := []struct {
+		name       string
+		rows       []domain.GameImport
+		decodeErrs []domain.ImportError
+		want       []domain.ImportError
+	}{
+		{
+			name: "valid",
+			rows: []domain.GameImport{{Name: "VR", MaxSlots: 2}, {Name: "Quiz", MaxSlots: 4, DurationSeconds: 300}},
+			want: []domain.ImportError{},
+		},
+		{
+			name: "missing fields",
+			rows: []domain.GameImport{{DurationSeconds: -1}},
+			want: []domain.ImportError{
+				{Row: 1, Field: "name", Error: "required"},
+				{Row: 1, Field: "max_slots", Error: "must be positive"},
+				{Row: 1, Field: "duration_seconds", Error: "must not be negative"},
+			},
+		},
+		{
+			name: "duplicate in the same event",
+			rows: []domain.GameImport{{EventID: 1, Name: "VR", MaxSlots: 2}, {EventID: 1, Name: "VR", MaxSlots: 3}},
+			want: []domain.ImportError{{Row: 2, Field: "name", Error: "duplicate of row 1"}},
+		},
+		{
+			name: "duplicate outside events",
+			rows: []domain.GameImport{{Name: "VR", MaxSlots: 2}, {Name: "VR", MaxSlots: 3}},
+			want: []domain.ImportError{{Row: 2, Field: "name", Error: "duplicate of row 1"}},
+		},
+		{
+			name: "same name in other events",
+			rows: []domain.GameImport{{EventID: 1, Name: "VR", MaxSlots: 2}, {EventID: 2, Name: "VR", MaxSlots: 2}, {Name: "VR", MaxSlots: 2}},
+			want: []domain.ImportError{},
+		},
+		{
+			name:       "undecoded rows are not validated",
+			rows:       []domain.GameImport{{Name: "VR", MaxSlots: 2}, {}},
+			decodeErrs: []domain.ImportError{decodeErr},
+			want:       []domain.ImportError{decodeErr},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := validateGames(tt.rows, tt.decodeErrs)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("errors = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateUsers(t *testing.T) {
+	decodeErr := domain.ImportError{Row: 1, Field: "priority", Error: `"high" is not a number`}
+
+	tests := []struct {
+		name       string
+		rows       []domain.UserImport
+		decodeErrs []domain.ImportError
+		want       []domain.ImportError
+	}{
+		{
+			name: "valid",
+			rows: []domain.UserImport{{Login: "alice"}, {Login: "bob", Role: domain.RoleOperator, Priority: 1}},
+			want: []domain.ImportError{},
+		},
+		{
+			name: "invalid fields",
+			rows: []domain.UserImport{{Role: "root", Priority: -1}},
+			want: []domain.ImportError{
+				{Row: 1, Field: "login", Error: "required"},
+				{Row: 1, Field: "role", Error: "must be user, operator or admin"},
+				{Row: 1, Field: "priority", Error: "must not be negative"},
+			},
+		},
+		{
+			name: "duplicate login",
+			rows: []domain.UserImport{{Login: "alice"}, {Login: "bob"}, {Login: "alice"}},
+			want: []domain.ImportError{{Row: 3, Field: "login", Error: "duplicate of row 1"}},
+		},
+		{
+			name:       "undecoded rows are not validated",
+			rows:       []domain.UserImport{{Role: "root"}},
+			decodeErrs: []domain.ImportError{decodeErr},
+			want:       []domain.ImportError{decodeErr},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := validateUsers(tt.rows, tt.decodeErrs)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("errors = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
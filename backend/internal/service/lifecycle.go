@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+// CallNext calls the next player of a game, to the given station or,
+// with station_id 0, to any free one.
+func (q *Queues) CallNext(ctx context.Context, actorID, game_id, station_id int) (int, error) {
+	entry_id, err := q.repo.CallNext(ctx, actorID, game_id, station_id, q.pick, q.checkOpen)
+	if err != nil {
+		return 0, err
+	}
+	q.notify(game_id, "called", entry_id)
+	return entry_id, nil
+}
+
+func (q *Queues) MarkArrived(ctx context.Context, actorID, game_id, entry_id int) error {
+	if err := q.repo.MarkArrived(ctx, actorID, game_id, entry_id); err != nil {
+		return err
+	}
+	q.notify(game_id, "arrived", entry_id)
+	return nil
+}
+
+func (q *Queues) FinishEntry(ctx context.Context, actorID, game_id, entry_id int, res domain.ResultInfo) error {
+	if err := validateResult(res); err != nil {
+		return err
+	}
+	called_id, err := q.repo.FinishEntry(ctx, actorID, game_id, entry_id, res, q.pick, q.checkOpen)
+	if err != nil {
+		return err
+	}
+	q.notify(game_id, "finished", entry_id)
+	if called_id != 0 {
+		q.notify(game_id, "called", called_id)
+	}
+	return nil
+}
+
+func (q *Queues) GetNoShowPolicy(ctx context.Context, game_id int) (*domain.NoShowPolicy, error) {
+	return q.repo.GetNoShowPolicy(ctx, game_id)
+}
+
+func (q *Queues) SetNoShowPolicy(ctx context.Context, actorID, game_id int, policy domain.NoShowPolicy) error {
+	if policy.GraceSeconds <= 0 || policy.ReinsertPlaces < 0 || policy.MaxSkips < 0 {
+		return e.ErrInvalidParams
+	}
+	return q.repo.SetNoShowPolicy(ctx, actorID, game_id, policy)
+}
+
+// RunNoShowSweeper periodically skips called players whose check-in grace
+// period has expired and calls the next ones. It returns when ctx is done.
+func (q *Queues) RunNoShowSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		handled, err := q.repo.ExpireNoShows(ctx, q.pick, q.checkOpen)
+		if err != nil {
+			log.Println("no-show sweeper error:", err)
+		}
+		for _, ns := range handled {
+			q.notify(ns.GameID, "skipped", ns.EntryID)
+			if !ns.Dropped {
+				q.notify(ns.GameID, "requeued", ns.EntryID)
+			}
+			if ns.CalledEntryID != 0 {
+				q.notify(ns.GameID, "called", ns.CalledEntryID)
+			}
+		}
+	}
+}
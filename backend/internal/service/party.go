@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+func (q *Queues) CreateParty(ctx context.Context, leader_id int, name string) (*domain.Party, error) {
+	code, err := randomCode(6)
+	if err != nil {
+		return nil, err
+	}
+
+	party_id, err := q.repo.CreateParty(ctx, leader_id, strings.TrimSpace(name), code)
+	if err != nil {
+		return nil, err
+	}
+	return q.repo.GetParty(ctx, party_id)
+}
+
+// GetParty is visible to the party's members and invitees only.
+func (q *Queues) GetParty(ctx context.Context, user_id, party_id int) (*domain.Party, error) {
+	party, err := q.repo.GetParty(ctx, party_id)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range party.Members {
+		if m.UserID == user_id {
+			return party, nil
+		}
+	}
+	return nil, e.ErrPartyNotFound
+}
+
+func (q *Queues) leadParty(ctx context.Context, user_id, party_id int) (*domain.Party, error) {
+	party, err := q.repo.GetParty(ctx, party_id)
+	if err != nil {
+		return nil, err
+	}
+	if party.LeaderID != user_id {
+		return nil, e.ErrForbidden
+	}
+	return party, nil
+}
+
+func (q *Queues) InviteToParty(ctx context.Context, leader_id, party_id int, login string) error {
+	if _, err := q.leadParty(ctx, leader_id, party_id); err != nil {
+		return err
+	}
+
+	user_id, err := q.repo.GetIdByLogin(ctx, login)
+	if err != nil {
+		return err
+	}
+	return q.repo.InviteToParty(ctx, party_id, user_id)
+}
+
+func (q *Queues) JoinPartyByCode(ctx context.Context, user_id int, code string) (*domain.Party, error) {
+	party_id, games, err := q.repo.JoinPartyByCode(ctx, user_id, strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		return nil, err
+	}
+	for _, game_id := range games {
+		q.notify(game_id, "party_joined", 0)
+	}
+	return q.repo.GetParty(ctx, party_id)
+}
+
+func (q *Queues) AcceptPartyInvite(ctx context.Context, user_id, party_id int) error {
+	games, err := q.repo.AcceptPartyInvite(ctx, party_id, user_id)
+	if err != nil {
+		return err
+	}
+	for _, game_id := range games {
+		q.notify(game_id, "party_joined", 0)
+	}
+	return nil
+}
+
+func (q *Queues) LeaveParty(ctx context.Context, user_id, party_id int) error {
+	games, err := q.repo.LeaveParty(ctx, party_id, user_id)
+	if err != nil {
+		return err
+	}
+	for _, game_id := range games {
+		q.notify(game_id, "party_left", 0)
+	}
+	return nil
+}
+
+// AddPartyToQueue is done by the party leader on behalf of everyone who
+// accepted; pending invitees are not counted.
+func (q *Queues) AddPartyToQueue(ctx context.Context, leader_id, party_id, game_id int) (int, error) {
+	if _, err := q.leadParty(ctx, leader_id, party_id); err != nil {
+		return 0, err
+	}
+
+	position, err := q.repo.AddPartyToQueue(ctx, party_id, game_id, q.checkOpen)
+	if err != nil {
+		return 0, err
+	}
+	q.notify(game_id, "joined", 0)
+	return position, nil
+}
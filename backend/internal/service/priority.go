@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+func (q *Queues) SetEntryPriority(ctx context.Context, actorID, game_id, entry_id, priority int) (int, error) {
+	if priority < 0 {
+		return 0, e.ErrInvalidParams
+	}
+
+	position, err := q.repo.SetEntryPriority(ctx, actorID, game_id, entry_id, priority)
+	if err != nil {
+		return 0, err
+	}
+	q.notify(game_id, "moved", entry_id)
+	return position, nil
+}
+
+// SetUserPriority sets the tier a user's future entries start with.
+func (q *Queues) SetUserPriority(ctx context.Context, actorID int, login string, priority int) error {
+	if priority < 0 {
+		return e.ErrInvalidParams
+	}
+
+	user_id, err := q.repo.GetIdByLogin(ctx, login)
+	if err != nil {
+		return err
+	}
+	return q.repo.SetUserPriority(ctx, actorID, user_id, priority)
+}
+
+func (q *Queues) GetPriorityPolicy(ctx context.Context, game_id int) (*domain.PriorityPolicy, error) {
+	return q.repo.GetPriorityPolicy(ctx, game_id)
+}
+
+func (q *Queues) SetPriorityPolicy(ctx context.Context, actorID, game_id int, policy domain.PriorityPolicy) error {
+	if policy.Every < 0 {
+		return e.ErrInvalidParams
+	}
+	return q.repo.SetPriorityPolicy(ctx, actorID, game_id, policy)
+}
@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"errors"
+	"log"
+	"time"
 
 	"github.com/DexScen/Queue/backend/internal/domain"
 	e "github.com/DexScen/Queue/backend/internal/errors"
@@ -10,9 +12,9 @@ import (
 )
 
 type QueuesRepository interface {
-	GetAllGames(ctx context.Context, listGames *domain.ListGames) error
+	GetAllGames(ctx context.Context, filter domain.ListFilter, listGames *domain.ListGames) (*domain.Cursor, error)
 	GetGameInfoByID(ctx context.Context, id int) (*domain.Game, error)
-	GetGamesByLogin(ctx context.Context, login string, listGames *domain.ListGameInfos) error
+	GetGamesByLogin(ctx context.Context, login string, filter domain.ListFilter, listGames *domain.ListGameInfos) (*domain.Cursor, error)
 	GetIdByLogin(ctx context.Context, login string) (int, error)
 
 	GetPassword(ctx context.Context, login string) (string, error)
@@ -20,32 +22,144 @@ type QueuesRepository interface {
 	UserExists(ctx context.Context, login string) (bool, error)
 	Register(ctx context.Context, user *domain.User) error
 
-	AddPlayerToQueue(ctx context.Context, user_id, game_id int) (int, error)
-	RemovePlayerFromQueue(ctx context.Context, user_id, game_id int) error
+	AddPlayerToQueue(ctx context.Context, user_id, game_id int, open domain.OpenFunc) (int, error)
+	RemovePlayerFromQueue(ctx context.Context, user_id, game_id int, pick domain.PickFunc, open domain.OpenFunc) (int, error)
 
-	GetPlayersByGameID(ctx context.Context, game_id int, listUsers *domain.ListUsers) error
+	GetUserByLogin(ctx context.Context, login string) (*domain.User, error)
+	GetUserByID(ctx context.Context, id int) (*domain.User, error)
+
+	MoveEntry(ctx context.Context, actorID, game_id, entry_id, position int) error
+	SwapEntries(ctx context.Context, actorID, game_id, entry_id, other_entry_id int) error
+	InsertPlayerAt(ctx context.Context, actorID, game_id, user_id, position int) (int, int, error)
+
+	CallNext(ctx context.Context, actorID, game_id, station_id int, pick domain.PickFunc, open domain.OpenFunc) (int, error)
+	MarkArrived(ctx context.Context, actorID, game_id, entry_id int) error
+	FinishEntry(ctx context.Context, actorID, game_id, entry_id int, res domain.ResultInfo, pick domain.PickFunc, open domain.OpenFunc) (int, error)
+	GetNoShowPolicy(ctx context.Context, game_id int) (*domain.NoShowPolicy, error)
+	SetNoShowPolicy(ctx context.Context, actorID, game_id int, policy domain.NoShowPolicy) error
+	ExpireNoShows(ctx context.Context, pick domain.PickFunc, open domain.OpenFunc) ([]domain.NoShow, error)
+
+	GetEntryByID(ctx context.Context, entry_id int) (*domain.QueueEntry, error)
+	GetEntryForUser(ctx context.Context, game_id, user_id int) (*domain.QueueEntry, error)
+
+	IssueTicket(ctx context.Context, actorID, game_id int, info domain.TicketInfo, claimCode string) (*domain.Ticket, error)
+	ClaimTicket(ctx context.Context, user_id int, info domain.ClaimInfo) (*domain.QueueEntry, error)
+	RemoveEntry(ctx context.Context, actorID, game_id, entry_id int, pick domain.PickFunc, open domain.OpenFunc) (int, error)
+
+	GetBoard(ctx context.Context, next int) ([]domain.BoardGame, domain.ListQueueEntries, error)
+
+	CreateParty(ctx context.Context, leader_id int, name, code string) (int, error)
+	GetParty(ctx context.Context, party_id int) (*domain.Party, error)
+	InviteToParty(ctx context.Context, party_id, user_id int) error
+	JoinPartyByCode(ctx context.Context, user_id int, code string) (int, []int, error)
+	AcceptPartyInvite(ctx context.Context, party_id, user_id int) ([]int, error)
+	LeaveParty(ctx context.Context, party_id, user_id int) ([]int, error)
+	AddPartyToQueue(ctx context.Context, party_id, game_id int, open domain.OpenFunc) (int, error)
+
+	SetEntryPriority(ctx context.Context, actorID, game_id, entry_id, priority int) (int, error)
+	SetUserPriority(ctx context.Context, actorID, user_id, priority int) error
+	GetPriorityPolicy(ctx context.Context, game_id int) (*domain.PriorityPolicy, error)
+	SetPriorityPolicy(ctx context.Context, actorID, game_id int, policy domain.PriorityPolicy) error
+
+	GetSchedulingPolicy(ctx context.Context, game_id int) (*domain.SchedulingPolicy, error)
+	SetSchedulingPolicy(ctx context.Context, actorID, game_id int, policy domain.SchedulingPolicy) error
+	SetUserGroup(ctx context.Context, actorID, user_id int, group string) error
+
+	GetStations(ctx context.Context, game_id int) (domain.ListStations, error)
+	CreateStation(ctx context.Context, actorID, game_id int, name string, enabled bool) (int, error)
+	UpdateStation(ctx context.Context, actorID, game_id, station_id int, info domain.StationInfo) error
+
+	GetHours(ctx context.Context, game_id int) (*domain.Hours, error)
+	SetHours(ctx context.Context, actorID, game_id int, hours domain.Hours) error
+	SetPaused(ctx context.Context, actorID, game_id int, paused bool) error
+
+	CountReservations(ctx context.Context, game_id int, from, to time.Time) (map[int64]int, error)
+	GetReservation(ctx context.Context, reservation_id int) (*domain.Reservation, error)
+	GetReservationsByUser(ctx context.Context, user_id, event_id int) (domain.ListReservations, error)
+	BookReservation(ctx context.Context, user_id, game_id int, start time.Time, open domain.OpenFunc) (*domain.Reservation, error)
+	RescheduleReservation(ctx context.Context, user_id, reservation_id int, start time.Time, open domain.OpenFunc) (*domain.Reservation, error)
+	CancelReservation(ctx context.Context, user_id, reservation_id int) (int, error)
+	ExpireReservations(ctx context.Context) (domain.ListReservations, error)
+	GetReservationPolicy(ctx context.Context, game_id int) (*domain.ReservationPolicy, error)
+	SetReservationPolicy(ctx context.Context, actorID, game_id int, policy domain.ReservationPolicy) error
+
+	GetUserHistory(ctx context.Context, user_id int, filter domain.ListFilter, list *domain.ListHistoryEntries) (*domain.Cursor, error)
+	GetGameTimeline(ctx context.Context, game_id int, filter domain.ListFilter, list *domain.ListQueueEvents) (*domain.Cursor, error)
+
+	GetAuditLog(ctx context.Context, filter domain.AuditFilter, page domain.ListFilter, list *domain.ListAuditRecords) (*domain.Cursor, error)
+	ExportAuditLog(ctx context.Context, filter domain.AuditFilter, fn func(*domain.AuditRecord) error) error
+
+	GetGameStats(ctx context.Context, gameID int, r domain.AnalyticsRange) (domain.ListGameStats, error)
+
+	ExportGames(ctx context.Context, fn func(*domain.Game) error) error
+	ExportUsers(ctx context.Context, fn func(*domain.UserRecord) error) error
+	ExportEntries(ctx context.Context, all bool, fn func(*domain.QueueEntry) error) error
+	ExportEvents(ctx context.Context, fn func(*domain.QueueEvent) error) error
+
+	ImportGames(ctx context.Context, actorID int, rows []domain.GameImport, result *domain.ImportResult) error
+	ImportUsers(ctx context.Context, actorID int, rows []domain.UserImport, result *domain.ImportResult) error
+
+	GetEvents(ctx context.Context) (domain.ListEvents, error)
+	GetEvent(ctx context.Context, eventID int) (*domain.Event, error)
+	GetActiveEvent(ctx context.Context) (*domain.Event, error)
+	CreateEvent(ctx context.Context, actorID int, event domain.Event) (int, error)
+	UpdateEvent(ctx context.Context, actorID int, event domain.Event) error
+	JoinEvent(ctx context.Context, eventID, userID int) error
+	LeaveEvent(ctx context.Context, eventID, userID int) error
+	GetEventMembers(ctx context.Context, eventID int) (domain.ListEventMembers, error)
+	SetEventMember(ctx context.Context, actorID, eventID, userID int, role string) error
+	SetGameEvent(ctx context.Context, actorID, gameID, eventID int) error
+	GetEventRole(ctx context.Context, eventID, userID int) (string, error)
+	GetGameEventRole(ctx context.Context, gameID, userID int) (string, error)
+
+	SetEntryScore(ctx context.Context, actorID, gameID, entryID int, res domain.ResultInfo) error
+	GetScoringPolicy(ctx context.Context, gameID int) (*domain.ScoringPolicy, error)
+	SetScoringPolicy(ctx context.Context, actorID, gameID int, policy domain.ScoringPolicy) error
+	GetLeaderboard(ctx context.Context, gameID, eventID, limit int) ([]domain.LeaderboardEntry, error)
+
+	RateGame(ctx context.Context, userID, gameID int, info domain.RatingInfo) (int, error)
+	GetRatings(ctx context.Context, gameID int, withHidden bool, filter domain.ListFilter, list *domain.ListRatings) (*domain.Cursor, error)
+	SetRatingHidden(ctx context.Context, actorID, gameID, ratingID int, hidden bool) error
+
+	GetBans(ctx context.Context, event_id int) (domain.ListBans, error)
+	GetActiveBan(ctx context.Context, userID, gameID int) (*domain.Ban, error)
+	CreateBan(ctx context.Context, actorID int, ban domain.Ban) (int, error)
+	LiftBan(ctx context.Context, actorID, banID int) error
+	CountLeaves(ctx context.Context, userID, gameID int, window time.Duration) (int, error)
+
+	GetPlayersByGameID(ctx context.Context, game_id int, filter domain.ListFilter, listEntries *domain.ListQueueEntries) (*domain.Cursor, error)
+	CountBusySlots(ctx context.Context, game_id int) (int, error)
+	GetWaitingSlots(ctx context.Context, game_id int) ([]int, error)
 }
 
 type Queues struct {
-	repo QueuesRepository
+	repo         QueuesRepository
+	events       *broker
+	board        boardCache
+	leaderboards leaderboardCache
+	checkinKey   []byte
+	sessionKey   []byte
 }
 
-func NewQueues(repo QueuesRepository) *Queues {
+func NewQueues(repo QueuesRepository, checkinKey, sessionKey []byte) *Queues {
 	return &Queues{
-		repo: repo,
+		repo:       repo,
+		events:     newBroker(),
+		checkinKey: checkinKey,
+		sessionKey: sessionKey,
 	}
 }
 
-func (q *Queues) GetAllGames(ctx context.Context, listGames *domain.ListGames) error {
-	return q.repo.GetAllGames(ctx, listGames)
+func (q *Queues) GetAllGames(ctx context.Context, filter domain.ListFilter, listGames *domain.ListGames) (*domain.Cursor, error) {
+	return q.repo.GetAllGames(ctx, filter, listGames)
 }
 
 func (q *Queues) GetGameInfoByID(ctx context.Context, id int) (*domain.Game, error) {
 	return q.repo.GetGameInfoByID(ctx, id)
 }
 
-func (q *Queues) GetGamesByLogin(ctx context.Context, login string, listGames *domain.ListGameInfos) error{
-	return q.repo.GetGamesByLogin(ctx, login, listGames)
+func (q *Queues) GetGamesByLogin(ctx context.Context, login string, filter domain.ListFilter, listGames *domain.ListGameInfos) (*domain.Cursor, error) {
+	return q.repo.GetGamesByLogin(ctx, login, filter, listGames)
 }
 
 func (q *Queues) LogIn(ctx context.Context, login, password string) (string, error) {
@@ -62,6 +176,14 @@ func (q *Queues) LogIn(ctx context.Context, login, password string) (string, err
 	if err != nil {
 		return "", e.ErrWrongPassword
 	}
+
+	user_id, err := q.repo.GetIdByLogin(ctx, login)
+	if err != nil {
+		return "", err
+	}
+	if err := q.checkBan(ctx, user_id, 0); err != nil {
+		return "", err
+	}
 	return q.repo.GetRole(ctx, login)
 }
 
@@ -85,18 +207,81 @@ func (q *Queues) Register(ctx context.Context, user *domain.User) error {
 	})
 }
 
-func (q *Queues) RemovePlayerFromQueue(ctx context.Context, user_id, game_id int) error{
-	return q.repo.RemovePlayerFromQueue(ctx, user_id, game_id)
+func (q *Queues) RemovePlayerFromQueue(ctx context.Context, user_id, game_id int) error {
+	called_id, err := q.repo.RemovePlayerFromQueue(ctx, user_id, game_id, q.pick, q.checkOpen)
+	if err != nil {
+		return err
+	}
+	q.notify(game_id, "left", 0)
+	if called_id != 0 {
+		q.notify(game_id, "called", called_id)
+	}
+
+	if err := q.applyRejoinCooldown(ctx, user_id, game_id); err != nil {
+		log.Println("rejoin cooldown error:", err)
+	}
+	return nil
+}
+
+func (q *Queues) AddPlayerToQueue(ctx context.Context, user_id, game_id int) (int, error) {
+	position, err := q.repo.AddPlayerToQueue(ctx, user_id, game_id, q.checkOpen)
+	if err != nil {
+		return 0, err
+	}
+	q.notify(game_id, "joined", 0)
+	return position, nil
 }
 
-func (q *Queues) AddPlayerToQueue(ctx context.Context, user_id, game_id int) (int, error){
-	return q.repo.AddPlayerToQueue(ctx , user_id, game_id)
+func (q *Queues) GetUserByLogin(ctx context.Context, login string) (*domain.User, error) {
+	return q.repo.GetUserByLogin(ctx, login)
 }
 
-func (q *Queues) GetIdByLogin(ctx context.Context, login string) (int,error){
+func (q *Queues) GetIdByLogin(ctx context.Context, login string) (int, error) {
 	return q.repo.GetIdByLogin(ctx, login)
 }
 
-func (q *Queues) GetPlayersByGameID(ctx context.Context, game_id int, listUsers *domain.ListUsers) error{
-	return q.repo.GetPlayersByGameID(ctx, game_id, listUsers)
-}
\ No newline at end of file
+func (q *Queues) GetPlayersByGameID(ctx context.Context, game_id int, filter domain.ListFilter, listEntries *domain.ListQueueEntries) (*domain.Cursor, error) {
+	game, err := q.repo.GetGameInfoByID(ctx, game_id)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := q.repo.GetPlayersByGameID(ctx, game_id, filter, listEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	busy, err := q.repo.CountBusySlots(ctx, game_id)
+	if err != nil {
+		return nil, err
+	}
+	// the page may start anywhere in the line, so the slots ahead of an
+	// entry come from the whole line rather than the page
+	waiting, err := q.repo.GetWaitingSlots(ctx, game_id)
+	if err != nil {
+		return nil, err
+	}
+	ahead := make([]int, len(waiting)+1)
+	for i, slots := range waiting {
+		ahead[i+1] = ahead[i] + slots
+	}
+
+	// only fifo calls players in position order; other policies get the
+	// same figures flagged as estimates
+	policy, err := q.repo.GetSchedulingPolicy(ctx, game_id)
+	if err != nil {
+		return nil, err
+	}
+	estimated := policy.Name != domain.ScheduleFIFO && policy.Name != ""
+
+	now := time.Now()
+	for i := range *listEntries {
+		entry := &(*listEntries)[i]
+		if entry.Status == domain.StatusWaiting && entry.Position >= 1 && entry.Position <= len(waiting) {
+			start := estimateStart(game, busy, ahead[entry.Position-1], entry.Slots, now)
+			entry.EstimatedStart = &start
+			entry.Estimated = estimated
+		}
+	}
+	return cursor, nil
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+const maxCommentLength = 1000
+
+// RateGame records the user's rating of a finished session of the game.
+func (q *Queues) RateGame(ctx context.Context, user_id, game_id int, info domain.RatingInfo) (int, error) {
+	if info.Stars < 1 || info.Stars > 5 {
+		return 0, fmt.Errorf("%w: stars must be between 1 and 5", e.ErrInvalidParams)
+	}
+	if utf8.RuneCountInString(info.Comment) > maxCommentLength {
+		return 0, fmt.Errorf("%w: comment is longer than %d characters", e.ErrInvalidParams, maxCommentLength)
+	}
+
+	if _, err := q.repo.GetGameInfoByID(ctx, game_id); err != nil {
+		return 0, err
+	}
+	return q.repo.RateGame(ctx, user_id, game_id, info)
+}
+
+func (q *Queues) GetRatings(ctx context.Context, game_id int, withHidden bool, filter domain.ListFilter, list *domain.ListRatings) (*domain.Cursor, error) {
+	if _, err := q.repo.GetGameInfoByID(ctx, game_id); err != nil {
+		return nil, err
+	}
+	return q.repo.GetRatings(ctx, game_id, withHidden, filter, list)
+}
+
+func (q *Queues) SetRatingHidden(ctx context.Context, actorID, game_id, rating_id int, hidden bool) error {
+	return q.repo.SetRatingHidden(ctx, actorID, game_id, rating_id, hidden)
+}
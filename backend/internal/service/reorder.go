@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+func (q *Queues) MoveEntry(ctx context.Context, actorID, game_id, entry_id, position int) error {
+	if err := q.repo.MoveEntry(ctx, actorID, game_id, entry_id, position); err != nil {
+		return err
+	}
+	q.notify(game_id, "moved", entry_id)
+	return nil
+}
+
+func (q *Queues) SwapEntries(ctx context.Context, actorID, game_id, entry_id, other_entry_id int) error {
+	if err := q.repo.SwapEntries(ctx, actorID, game_id, entry_id, other_entry_id); err != nil {
+		return err
+	}
+	q.notify(game_id, "swapped", entry_id)
+	return nil
+}
+
+func (q *Queues) InsertPlayerAt(ctx context.Context, actorID, game_id int, login string, position int) (int, error) {
+	user_id, err := q.repo.GetIdByLogin(ctx, login)
+	if err != nil {
+		return 0, err
+	}
+
+	entry_id, pos, err := q.repo.InsertPlayerAt(ctx, actorID, game_id, user_id, position)
+	if err != nil {
+		return 0, err
+	}
+	q.notify(game_id, "inserted", entry_id)
+	return pos, nil
+}
+
+// Subscribe returns a channel of updates for a game's queue and a function
+// that must be called once the subscriber goes away.
+func (q *Queues) Subscribe(game_id int) (<-chan domain.QueueUpdate, func()) {
+	return q.events.subscribe(game_id)
+}
+
+func (q *Queues) notify(game_id int, kind string, entry_id int) {
+	now := time.Now()
+	q.board.touch(now)
+	if scoreChanged(kind) {
+		q.leaderboards.touch(game_id, now)
+	}
+	q.events.publish(domain.QueueUpdate{
+		GameID:  game_id,
+		Type:    kind,
+		EntryID: entry_id,
+		At:      now,
+	})
+}
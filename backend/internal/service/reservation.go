@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+const dateLayout = "2006-01-02"
+
+// daySlots cuts the windows that open on the given local day into slots of
+// duration each; a slot must end before its window closes.
+func daySlots(windows []domain.HoursWindow, day time.Time, duration time.Duration) []domain.ReservationSlot {
+	var slots []domain.ReservationSlot
+	for _, w := range windows {
+		if w.Weekday != int(day.Weekday()) {
+			continue
+		}
+		start, end, ok := windowSpan(day, w)
+		if !ok {
+			continue
+		}
+		for s := start; !s.Add(duration).After(end); s = s.Add(duration) {
+			slots = append(slots, domain.ReservationSlot{Start: s, End: s.Add(duration)})
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Start.Before(slots[j].Start) })
+	return slots
+}
+
+// slotPlan loads what slot generation needs: the opening hours in their
+// time zone, the session length and the capacity per slot.
+func (q *Queues) slotPlan(ctx context.Context, game_id int) (*domain.Hours, *time.Location, time.Duration, int, error) {
+	hours, err := q.repo.GetHours(ctx, game_id)
+	if err != nil {
+		return nil, nil, 0, 0, err
+	}
+	loc, err := time.LoadLocation(hours.Timezone)
+	if err != nil {
+		return nil, nil, 0, 0, err
+	}
+	game, err := q.repo.GetGameInfoByID(ctx, game_id)
+	if err != nil {
+		return nil, nil, 0, 0, err
+	}
+	policy, err := q.repo.GetReservationPolicy(ctx, game_id)
+	if err != nil {
+		return nil, nil, 0, 0, err
+	}
+	return hours, loc, time.Duration(game.Duration_seconds) * time.Second, policy.Capacity, nil
+}
+
+// GetSlots lists the bookable slots of a game on a date (YYYY-MM-DD in the
+// game's time zone, today if empty) that have not started yet.
+func (q *Queues) GetSlots(ctx context.Context, game_id int, date string) ([]domain.ReservationSlot, error) {
+	hours, loc, duration, capacity, err := q.slotPlan(ctx, game_id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(loc)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if date != "" {
+		day, err = time.ParseInLocation(dateLayout, date, loc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: date: %v", e.ErrInvalidParams, err)
+		}
+	}
+
+	slots := []domain.ReservationSlot{}
+	if capacity == 0 {
+		return slots, nil
+	}
+	for _, slot := range daySlots(hours.Windows, day, duration) {
+		if slot.Start.After(now) {
+			slots = append(slots, slot)
+		}
+	}
+	if len(slots) == 0 {
+		return slots, nil
+	}
+
+	counts, err := q.repo.CountReservations(ctx, game_id, slots[0].Start, slots[len(slots)-1].End)
+	if err != nil {
+		return nil, err
+	}
+	for i := range slots {
+		slots[i].Capacity = capacity
+		slots[i].Booked = counts[slots[i].Start.Unix()]
+	}
+	return slots, nil
+}
+
+// checkSlotStart makes sure start is the beginning of a future slot of the
+// game. Windows past midnight belong to the day they open on, so the day
+// before is checked as well.
+func (q *Queues) checkSlotStart(ctx context.Context, game_id int, start time.Time) error {
+	hours, loc, duration, capacity, err := q.slotPlan(ctx, game_id)
+	if err != nil {
+		return err
+	}
+	if capacity == 0 {
+		return fmt.Errorf("%w: game takes no reservations", e.ErrInvalidParams)
+	}
+	if !start.After(time.Now()) {
+		return fmt.Errorf("%w: slot has already started", e.ErrInvalidParams)
+	}
+
+	local := start.In(loc)
+	for offset := -1; offset <= 0; offset++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, loc)
+		for _, slot := range daySlots(hours.Windows, day, duration) {
+			if slot.Start.Equal(start) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: no slot starts at %s", e.ErrInvalidParams, start.Format(time.RFC3339))
+}
+
+func (q *Queues) BookReservation(ctx context.Context, user_id, game_id int, info domain.ReservationInfo) (*domain.Reservation, error) {
+	if err := q.checkSlotStart(ctx, game_id, info.Start); err != nil {
+		return nil, err
+	}
+
+	reservation, err := q.repo.BookReservation(ctx, user_id, game_id, info.Start, q.checkOpen)
+	if err != nil {
+		return nil, err
+	}
+	q.notify(game_id, "reserved", 0)
+	return reservation, nil
+}
+
+func (q *Queues) RescheduleReservation(ctx context.Context, user_id, reservation_id int, info domain.ReservationInfo) (*domain.Reservation, error) {
+	reservation, err := q.repo.GetReservation(ctx, reservation_id)
+	if err != nil {
+		return nil, err
+	}
+	if reservation.UserID != user_id {
+		return nil, e.ErrReservationNotFound
+	}
+
+	if err := q.checkSlotStart(ctx, reservation.GameID, info.Start); err != nil {
+		return nil, err
+	}
+
+	reservation, err = q.repo.RescheduleReservation(ctx, user_id, reservation_id, info.Start, q.checkOpen)
+	if err != nil {
+		return nil, err
+	}
+	q.notify(reservation.GameID, "rescheduled", 0)
+	return reservation, nil
+}
+
+func (q *Queues) CancelReservation(ctx context.Context, user_id, reservation_id int) error {
+	game_id, err := q.repo.CancelReservation(ctx, user_id, reservation_id)
+	if err != nil {
+		return err
+	}
+	q.notify(game_id, "unreserved", 0)
+	return nil
+}
+
+// RunReservationSweeper periodically expires booked reservations whose
+// slot ended unused. It returns when ctx is done.
+func (q *Queues) RunReservationSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		expired, err := q.repo.ExpireReservations(ctx)
+		if err != nil {
+			log.Println("reservation sweeper error:", err)
+		}
+		for _, r := range expired {
+			q.notify(r.GameID, "expired", 0)
+		}
+	}
+}
+
+func (q *Queues) GetReservations(ctx context.Context, user_id, event_id int) (domain.ListReservations, error) {
+	return q.repo.GetReservationsByUser(ctx, user_id, event_id)
+}
+
+func (q *Queues) GetReservationPolicy(ctx context.Context, game_id int) (*domain.ReservationPolicy, error) {
+	return q.repo.GetReservationPolicy(ctx, game_id)
+}
+
+func (q *Queues) SetReservationPolicy(ctx context.Context, actorID, game_id int, policy domain.ReservationPolicy) error {
+	if policy.Capacity < 0 {
+		return e.ErrInvalidParams
+	}
+	return q.repo.SetReservationPolicy(ctx, actorID, game_id, policy)
+}
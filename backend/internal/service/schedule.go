@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"hash/fnv"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+// Scheduler picks the next entry to call out of a game's waiting line,
+// which is passed ordered by position. It returns the index of the chosen
+// entry, or -1 to call nobody. Schedulers only choose; whether the choice
+// fits the free slots is checked by the caller.
+type Scheduler interface {
+	Pick(waiting domain.ListQueueEntries, lastGroup string) int
+}
+
+// newScheduler builds the scheduler of a policy. rng is only used by
+// policies that draw; pass nil to derive it from the policy seed.
+func newScheduler(policy domain.SchedulingPolicy, rng *rand.Rand) (Scheduler, error) {
+	switch policy.Name {
+	case domain.ScheduleFIFO, "":
+		return fifo{}, nil
+	case domain.SchedulePriority:
+		return priorityWeighted{boost: policy.PriorityBoost}, nil
+	case domain.ScheduleLottery:
+		return lottery{policy: policy, rng: rng}, nil
+	case domain.ScheduleRoundRobin:
+		return roundRobin{}, nil
+	}
+	return nil, e.ErrInvalidParams
+}
+
+// fifo calls the head of the line.
+type fifo struct{}
+
+func (fifo) Pick(waiting domain.ListQueueEntries, _ string) int {
+	if len(waiting) == 0 {
+		return -1
+	}
+	return 0
+}
+
+// priorityWeighted lets each priority level count as boost places ahead
+// of the entry's actual position; ties go to the earlier position.
+type priorityWeighted struct {
+	boost int
+}
+
+func (p priorityWeighted) Pick(waiting domain.ListQueueEntries, _ string) int {
+	best, bestScore := -1, 0
+	for i, entry := range waiting {
+		score := i - entry.Priority*p.boost
+		if best < 0 || score < bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// lottery draws uniformly among everyone who joined before the cutoff.
+// Once they are all called, later arrivals are served first come, first
+// served. Without an explicit rng the draw is seeded from the policy seed
+// and the ids taking part, so the same line always yields the same winner.
+type lottery struct {
+	policy domain.SchedulingPolicy
+	rng    *rand.Rand
+}
+
+func (l lottery) Pick(waiting domain.ListQueueEntries, _ string) int {
+	var pool []int
+	for i, entry := range waiting {
+		if l.policy.LotteryCutoff == nil || entry.JoinedAt.Before(*l.policy.LotteryCutoff) {
+			pool = append(pool, i)
+		}
+	}
+	if len(pool) == 0 {
+		return fifo{}.Pick(waiting, "")
+	}
+
+	rng := l.rng
+	if rng == nil {
+		h := fnv.New64a()
+		for _, i := range pool {
+			h.Write([]byte(strconv.Itoa(waiting[i].ID) + ","))
+		}
+		rng = rand.New(rand.NewPCG(uint64(l.policy.Seed), h.Sum64()))
+	}
+	return pool[rng.IntN(len(pool))]
+}
+
+// roundRobin serves user groups in turn, in alphabetical order of their
+// names, starting after the group that was called last. Within a group
+// the line order holds. Entries without a group form a group of their own.
+type roundRobin struct{}
+
+func (roundRobin) Pick(waiting domain.ListQueueEntries, lastGroup string) int {
+	first := map[string]int{}
+	var groups []string
+	for i, entry := range waiting {
+		if _, ok := first[entry.Group]; !ok {
+			first[entry.Group] = i
+			groups = append(groups, entry.Group)
+		}
+	}
+	if len(groups) == 0 {
+		return -1
+	}
+
+	sort.Strings(groups)
+	next := sort.SearchStrings(groups, lastGroup)
+	if next < len(groups) && groups[next] == lastGroup {
+		next++
+	}
+	return first[groups[next%len(groups)]]
+}
+
+// pick is the domain.PickFunc handed to the repository: it runs the
+// scheduler of the game's policy inside the call transaction.
+func (q *Queues) pick(state domain.ScheduleState) int {
+	s, err := newScheduler(state.Policy, nil)
+	if err != nil {
+		s = fifo{}
+	}
+	i := s.Pick(state.Waiting, state.LastGroup)
+	if i < 0 || i >= len(state.Waiting) {
+		return 0
+	}
+	return state.Waiting[i].ID
+}
+
+// GetSchedulingPolicy returns a game's policy without the lottery seed.
+func (q *Queues) GetSchedulingPolicy(ctx context.Context, game_id int) (*domain.SchedulingPolicy, error) {
+	policy, err := q.repo.GetSchedulingPolicy(ctx, game_id)
+	if err != nil {
+		return nil, err
+	}
+	policy.Seed = 0
+	return policy, nil
+}
+
+// SetSchedulingPolicy validates and stores a game's policy. A lottery
+// without a seed gets a random one, which is kept so draws can be replayed.
+func (q *Queues) SetSchedulingPolicy(ctx context.Context, actorID, game_id int, policy domain.SchedulingPolicy) error {
+	if _, err := newScheduler(policy, nil); err != nil {
+		return err
+	}
+	if policy.PriorityBoost < 0 {
+		return e.ErrInvalidParams
+	}
+	if policy.Name == domain.ScheduleLottery && policy.Seed == 0 {
+		policy.Seed = rand.Int64()
+	}
+	return q.repo.SetSchedulingPolicy(ctx, actorID, game_id, policy)
+}
+
+// SetUserGroup puts a user into a group for round-robin scheduling.
+// An empty group removes them from any.
+func (q *Queues) SetUserGroup(ctx context.Context, actorID int, login, group string) error {
+	if len(group) > 50 {
+		return e.ErrInvalidParams
+	}
+
+	user_id, err := q.repo.GetIdByLogin(ctx, login)
+	if err != nil {
+		return err
+	}
+	return q.repo.SetUserGroup(ctx, actorID, user_id, group)
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+func TestNewScheduler(t *testing.T) {
+	base := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	entry := func(id int, group string, joined int) domain.QueueEntry {
+		return domain.QueueEntry{ID: id, Group: group, JoinedAt: base.Add(time.Duration(joined) * time.Minute)}
+	}
+	line := domain.ListQueueEntries{
+		entry(1, "b", 0),
+		entry(2, "a", 1),
+		entry(3, "b", 2),
+		entry(4, "", 3),
+		entry(5, "c", 4),
+	}
+	cutoff := func(minutes int) *time.Time {
+		t := base.Add(time.Duration(minutes) * time.Minute)
+		return &t
+	}
+	priority := domain.ListQueueEntries{{ID: 1}, {ID: 2}, {ID: 3, Priority: 1}}
+
+	tests := []struct {
+		name      string
+		policy    domain.SchedulingPolicy
+		waiting   domain.ListQueueEntries
+		lastGroup string
+		want      int
+		err       error
+	}{
+		{"fifo", domain.SchedulingPolicy{Name: domain.ScheduleFIFO}, line, "", 0, nil},
+		{"default is fifo", domain.SchedulingPolicy{}, line, "", 0, nil},
+		{"fifo empty", domain.SchedulingPolicy{Name: domain.ScheduleFIFO}, nil, "", -1, nil},
+		{"priority boost", domain.SchedulingPolicy{Name: domain.SchedulePriority, PriorityBoost: 3}, priority, "", 2, nil},
+		{"priority tie goes first", domain.SchedulingPolicy{Name: domain.SchedulePriority, PriorityBoost: 2}, priority, "", 0, nil},
+		// groups in order: "", a, b, c
+		{"round robin start", domain.SchedulingPolicy{Name: domain.ScheduleRoundRobin}, line, "", 1, nil},
+		{"round robin next group", domain.SchedulingPolicy{Name: domain.ScheduleRoundRobin}, line, "a", 0, nil},
+		{"round robin wraps", domain.SchedulingPolicy{Name: domain.ScheduleRoundRobin}, line, "c", 3, nil},
+		{"round robin unknown group", domain.SchedulingPolicy{Name: domain.ScheduleRoundRobin}, line, "bb", 4, nil},
+		{"round robin empty", domain.SchedulingPolicy{Name: domain.ScheduleRoundRobin}, nil, "", -1, nil},
+		{"lottery single ticket", domain.SchedulingPolicy{Name: domain.ScheduleLottery, LotteryCutoff: cutoff(1)}, line, "", 0, nil},
+		{"lottery after the draw", domain.SchedulingPolicy{Name: domain.ScheduleLottery, LotteryCutoff: cutoff(-1)}, line, "", 0, nil},
+		{"unknown", domain.SchedulingPolicy{Name: "random"}, line, "", 0, e.ErrInvalidParams},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := newScheduler(tt.policy, nil)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if got := s.Pick(tt.waiting, tt.lastGroup); got != tt.want {
+				t.Fatalf("Pick = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLotteryDraw(t *testing.T) {
+	base := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	var line domain.ListQueueEntries
+	for i := 1; i <= 6; i++ {
+		line = append(line, domain.QueueEntry{ID: i, JoinedAt: base.Add(time.Duration(i) * time.Minute)})
+	}
+	cutoff := base.Add(4*time.Minute + time.Second) // entries 1 to 4 take part
+	policy := domain.SchedulingPolicy{Name: domain.ScheduleLottery, LotteryCutoff: &cutoff, Seed: 42}
+
+	// the same seed and line always draw the same winner
+	s, err := newScheduler(policy, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := s.Pick(line, "")
+	if want < 0 || want > 3 {
+		t.Fatalf("Pick = %d, want one of the first four", want)
+	}
+	for range 10 {
+		if got := s.Pick(line, ""); got != want {
+			t.Fatalf("Pick = %d, then %d", want, got)
+		}
+	}
+
+	// an explicit rng drives the draw; every ticket before the cutoff wins
+	// sometimes and no one after it does
+	s, err = newScheduler(policy, rand.New(rand.NewPCG(1, 2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wins := map[int]int{}
+	for range 400 {
+		wins[s.Pick(line, "")]++
+	}
+	for i := range line {
+		if i <= 3 && wins[i] == 0 {
+			t.Errorf("entry %d never drawn", i)
+		}
+		if i > 3 && wins[i] != 0 {
+			t.Errorf("entry %d drawn %d times after the cutoff", i, wins[i])
+		}
+	}
+}
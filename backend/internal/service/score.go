@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	maxResultLength = 200
+
+	leaderboardTTL = 10 * time.Second
+	// like on the board, a leaderboard outdated by a score is still served
+	// this long so that a burst of scores costs one rebuild
+	leaderboardMinAge = 250 * time.Millisecond
+)
+
+// leaderboardKey names a cached leaderboard: of one game, or of an event
+// (or every game) when game is 0, cut to limit entries.
+type leaderboardKey struct {
+	game, event, limit int
+}
+
+func (k leaderboardKey) String() string {
+	return fmt.Sprintf("%d/%d/%d", k.game, k.event, k.limit)
+}
+
+// leaderboardCache keeps the last built leaderboards the way boardCache
+// keeps boards. A finished or scored session outdates the leaderboard of
+// its game and every overall one; concurrent rebuilds are coalesced.
+type leaderboardCache struct {
+	mu      sync.Mutex
+	boards  map[leaderboardKey]*domain.Leaderboard
+	changed map[int]time.Time // last score change per game, 0 for any game
+	group   singleflight.Group
+}
+
+func (c *leaderboardCache) get(key leaderboardKey, now time.Time) *domain.Leaderboard {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	board := c.boards[key]
+	if board == nil {
+		return nil
+	}
+	age := now.Sub(board.GeneratedAt)
+	if age > leaderboardTTL || (c.changed[key.game].After(board.GeneratedAt) && age > leaderboardMinAge) {
+		return nil
+	}
+	return board
+}
+
+// put stores a leaderboard and drops the expired ones, as there is one
+// per game and size.
+func (c *leaderboardCache) put(key leaderboardKey, board *domain.Leaderboard) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.boards == nil {
+		c.boards = map[leaderboardKey]*domain.Leaderboard{}
+	}
+	for k, b := range c.boards {
+		if board.GeneratedAt.Sub(b.GeneratedAt) > leaderboardTTL {
+			delete(c.boards, k)
+		}
+	}
+	c.boards[key] = board
+}
+
+func (c *leaderboardCache) touch(game_id int, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.changed == nil {
+		c.changed = map[int]time.Time{}
+	}
+	c.changed[game_id] = now
+	c.changed[0] = now
+}
+
+// scoreChanged tells whether a queue update can move a leaderboard.
+func scoreChanged(kind string) bool {
+	return kind == "finished" || kind == "scored"
+}
+
+// cachedLeaderboard serves a leaderboard from the cache or builds it once
+// for every caller waiting on it.
+func (q *Queues) cachedLeaderboard(ctx context.Context, key leaderboardKey, build func(ctx context.Context) (*domain.Leaderboard, error)) (*domain.Leaderboard, error) {
+	if board := q.leaderboards.get(key, time.Now()); board != nil {
+		return board, nil
+	}
+
+	// as on the board, the shared build must outlive the first caller
+	v, err, _ := q.leaderboards.group.Do(key.String(), func() (any, error) {
+		board, err := build(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+		q.leaderboards.put(key, board)
+		return board, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*domain.Leaderboard), nil
+}
+
+func validateResult(res domain.ResultInfo) error {
+	if utf8.RuneCountInString(res.Result) > maxResultLength {
+		return fmt.Errorf("%w: result is longer than %d characters", e.ErrInvalidParams, maxResultLength)
+	}
+	return nil
+}
+
+// SetEntryScore corrects the score of a finished session, e.g. after a
+// typo at the stand.
+func (q *Queues) SetEntryScore(ctx context.Context, actorID, game_id, entry_id int, res domain.ResultInfo) error {
+	if err := validateResult(res); err != nil {
+		return err
+	}
+	if err := q.repo.SetEntryScore(ctx, actorID, game_id, entry_id, res); err != nil {
+		return err
+	}
+	q.notify(game_id, "scored", entry_id)
+	return nil
+}
+
+func (q *Queues) GetScoringPolicy(ctx context.Context, game_id int) (*domain.ScoringPolicy, error) {
+	return q.repo.GetScoringPolicy(ctx, game_id)
+}
+
+func (q *Queues) SetScoringPolicy(ctx context.Context, actorID, game_id int, policy domain.ScoringPolicy) error {
+	if policy.Mode != domain.ScoringBest && policy.Mode != domain.ScoringSum {
+		return fmt.Errorf("%w: mode must be %s or %s", e.ErrInvalidParams, domain.ScoringBest, domain.ScoringSum)
+	}
+	if err := q.repo.SetScoringPolicy(ctx, actorID, game_id, policy); err != nil {
+		return err
+	}
+	q.notify(game_id, "scored", 0)
+	return nil
+}
+
+// GetGameLeaderboard ranks the players of one game under its scoring mode.
+func (q *Queues) GetGameLeaderboard(ctx context.Context, game_id, limit int) (*domain.Leaderboard, error) {
+	return q.cachedLeaderboard(ctx, leaderboardKey{game: game_id, limit: limit}, func(ctx context.Context) (*domain.Leaderboard, error) {
+		now := time.Now()
+		policy, err := q.repo.GetScoringPolicy(ctx, game_id)
+		if err != nil {
+			return nil, err
+		}
+
+		entries, err := q.repo.GetLeaderboard(ctx, game_id, 0, limit)
+		if err != nil {
+			return nil, err
+		}
+		return newLeaderboard(game_id, 0, policy.Mode, entries, now), nil
+	})
+}
+
+// GetLeaderboard ranks the players over every game of an event, or of
+// all games when event_id is 0.
+func (q *Queues) GetLeaderboard(ctx context.Context, event_id, limit int) (*domain.Leaderboard, error) {
+	return q.cachedLeaderboard(ctx, leaderboardKey{event: event_id, limit: limit}, func(ctx context.Context) (*domain.Leaderboard, error) {
+		now := time.Now()
+		entries, err := q.repo.GetLeaderboard(ctx, 0, event_id, limit)
+		if err != nil {
+			return nil, err
+		}
+		return newLeaderboard(0, event_id, "", entries, now), nil
+	})
+}
+
+// newLeaderboard stamps the leaderboard with the time its queries started,
+// so that a score committed meanwhile still outdates it.
+func newLeaderboard(game_id, event_id int, mode string, entries []domain.LeaderboardEntry, now time.Time) *domain.Leaderboard {
+	if entries == nil {
+		entries = []domain.LeaderboardEntry{}
+	}
+	return &domain.Leaderboard{
+		GameID:      game_id,
+		EventID:     event_id,
+		Mode:        mode,
+		Entries:     entries,
+		GeneratedAt: now,
+	}
+}
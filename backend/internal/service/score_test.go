@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+func TestLeaderboardCacheGet(t *testing.T) {
+	built := time.Unix(1_700_000_000, 0)
+	game := leaderboardKey{game: 1, limit: 10}
+	overall := leaderboardKey{limit: 10}
+
+	tests := []struct {
+		name    string
+		key     leaderboardKey
+		age     time.Duration
+		scored  int           // game scored in; 0 for none
+		changed time.Duration // since built
+		hit     bool
+	}{
+		{"fresh", game, time.Second, 0, 0, true},
+		{"expired", game, leaderboardTTL + time.Millisecond, 0, 0, false},
+		{"scored but young", game, leaderboardMinAge / 2, 1, time.Millisecond, true},
+		{"scored and old enough", game, leaderboardMinAge + time.Millisecond, 1, time.Millisecond, false},
+		{"scored before build", game, time.Second, 1, -time.Millisecond, true},
+		{"another game scored", game, time.Second, 2, time.Millisecond, true},
+		{"overall after any score", overall, time.Second, 2, time.Millisecond, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c leaderboardCache
+			c.put(tt.key, &domain.Leaderboard{GeneratedAt: built})
+			if tt.scored != 0 {
+				c.touch(tt.scored, built.Add(tt.changed))
+			}
+			if got := c.get(tt.key, built.Add(tt.age)) != nil; got != tt.hit {
+				t.Fatalf("hit = %v, want %v", got, tt.hit)
+			}
+			other := tt.key
+			other.limit = 5
+			if c.get(other, built) != nil {
+				t.Fatal("hit for a size never built")
+			}
+		})
+	}
+}
+
+// leaderboardRepo counts leaderboard queries and holds them until released.
+type leaderboardRepo struct {
+	QueuesRepository
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (r *leaderboardRepo) GetLeaderboard(ctx context.Context, gameID, eventID, limit int) ([]domain.LeaderboardEntry, error) {
+	r.calls.Add(1)
+	<-r.release
+	return nil, nil
+}
+
+func TestGetLeaderboardCoalesces(t *testing.T) {
+	repo := &leaderboardRepo{release: make(chan struct{})}
+	q := NewQueues(repo, nil, nil)
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := q.GetLeaderboard(context.Background(), 0, 10); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	// let the callers pile up behind the first build
+	time.Sleep(50 * time.Millisecond)
+	close(repo.release)
+	wg.Wait()
+
+	if n := repo.calls.Load(); n != 1 {
+		t.Fatalf("built %d times, want 1", n)
+	}
+
+	// a queue update that moves no score keeps the leaderboard
+	q.notify(1, "joined", 0)
+	if _, err := q.GetLeaderboard(context.Background(), 0, 10); err != nil {
+		t.Fatal(err)
+	}
+	if n := repo.calls.Load(); n != 1 {
+		t.Fatalf("a join rebuilt the leaderboard")
+	}
+
+	// a score outdates it once it is old enough
+	time.Sleep(leaderboardMinAge + 10*time.Millisecond)
+	q.notify(1, "scored", 0)
+	if _, err := q.GetLeaderboard(context.Background(), 0, 10); err != nil {
+		t.Fatal(err)
+	}
+	if n := repo.calls.Load(); n != 2 {
+		t.Fatalf("built %d times after a score, want 2", n)
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+func TestVerifySession(t *testing.T) {
+	q := &Queues{sessionKey: []byte("secret")}
+	other := &Queues{sessionKey: []byte("other")}
+	now := time.Unix(1_700_000_000, 0)
+	valid := q.signSession(7, now.Add(time.Hour))
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+		err   error
+	}{
+		{"valid", valid, 7, nil},
+		{"expired", q.signSession(7, now.Add(-time.Second)), 0, e.ErrUnauthorized},
+		{"other key", other.signSession(7, now.Add(time.Hour)), 0, e.ErrUnauthorized},
+		{"other user", "8" + valid[1:], 0, e.ErrUnauthorized},
+		{"malformed", "7.abc", 0, e.ErrUnauthorized},
+		{"empty", "", 0, e.ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := q.verifySession(tt.token, now)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Fatalf("user = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// sessionRepo knows one user and whether they are banned.
+type sessionRepo struct {
+	QueuesRepository
+	user   domain.User
+	banned bool
+}
+
+func (r *sessionRepo) GetUserByID(ctx context.Context, id int) (*domain.User, error) {
+	if id != r.user.ID {
+		return nil, e.ErrUserNotFound
+	}
+	user := r.user
+	return &user, nil
+}
+
+func (r *sessionRepo) GetActiveBan(ctx context.Context, userID, gameID int) (*domain.Ban, error) {
+	if !r.banned || userID != r.user.ID || gameID != 0 {
+		return nil, e.ErrBanNotFound
+	}
+	return &domain.Ban{UserID: userID, Reason: "test"}, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name   string
+		role   string
+		banned bool
+		err    error
+	}{
+		{"user", domain.RoleUser, false, nil},
+		{"banned user", domain.RoleUser, true, e.ErrBanned},
+		{"banned operator", domain.RoleOperator, true, e.ErrBanned},
+		{"banned admin", domain.RoleAdmin, true, e.ErrBanned},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &sessionRepo{user: domain.User{ID: 7, Login: "alice", Role: tt.role}, banned: tt.banned}
+			q := NewQueues(repo, nil, []byte("secret"))
+			token := q.signSession(7, time.Now().Add(time.Hour))
+
+			user, err := q.Authenticate(context.Background(), token)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && user.ID != 7 {
+				t.Fatalf("user = %+v", user)
+			}
+		})
+	}
+}
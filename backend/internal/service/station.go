@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+const maxStationName = 50
+
+func (q *Queues) GetStations(ctx context.Context, game_id int) (domain.ListStations, error) {
+	return q.repo.GetStations(ctx, game_id)
+}
+
+func (q *Queues) CreateStation(ctx context.Context, actorID, game_id int, info domain.StationInfo) (int, error) {
+	name := strings.TrimSpace(info.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxStationName {
+		return 0, e.ErrInvalidParams
+	}
+	enabled := info.Enabled == nil || *info.Enabled
+
+	station_id, err := q.repo.CreateStation(ctx, actorID, game_id, name, enabled)
+	if err != nil {
+		return 0, err
+	}
+	q.notify(game_id, "station", 0)
+	return station_id, nil
+}
+
+func (q *Queues) UpdateStation(ctx context.Context, actorID, game_id, station_id int, info domain.StationInfo) error {
+	info.Name = strings.TrimSpace(info.Name)
+	if utf8.RuneCountInString(info.Name) > maxStationName {
+		return e.ErrInvalidParams
+	}
+
+	if err := q.repo.UpdateStation(ctx, actorID, game_id, station_id, info); err != nil {
+		return err
+	}
+	q.notify(game_id, "station", 0)
+	return nil
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"strings"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+// claim and party codes avoid look-alike characters since they are typed
+// in by hand
+const claimAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+func newClaimCode() (string, error) {
+	return randomCode(6)
+}
+
+func randomCode(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = claimAlphabet[int(b)%len(claimAlphabet)]
+	}
+	return string(buf), nil
+}
+
+func (q *Queues) IssueTicket(ctx context.Context, actorID, game_id int, info domain.TicketInfo) (*domain.Ticket, error) {
+	code, err := newClaimCode()
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := q.repo.IssueTicket(ctx, actorID, game_id, info, code)
+	if err != nil {
+		return nil, err
+	}
+	q.notify(game_id, "joined", ticket.EntryID)
+	return ticket, nil
+}
+
+func (q *Queues) ClaimTicket(ctx context.Context, user_id int, info domain.ClaimInfo) (*domain.QueueEntry, error) {
+	info.Ticket = strings.ToUpper(strings.TrimSpace(info.Ticket))
+	info.ClaimCode = strings.ToUpper(strings.TrimSpace(info.ClaimCode))
+	if info.Ticket == "" || info.ClaimCode == "" {
+		return nil, e.ErrInvalidParams
+	}
+
+	entry, err := q.repo.ClaimTicket(ctx, user_id, info)
+	if err != nil {
+		return nil, err
+	}
+	q.notify(entry.GameID, "claimed", entry.ID)
+	return entry, nil
+}
+
+func (q *Queues) RemoveEntry(ctx context.Context, actorID, game_id, entry_id int) error {
+	called_id, err := q.repo.RemoveEntry(ctx, actorID, game_id, entry_id, q.pick, q.checkOpen)
+	if err != nil {
+		return err
+	}
+	q.notify(game_id, "removed", entry_id)
+	if called_id != 0 {
+		q.notify(game_id, "called", called_id)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package rest
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+	"github.com/gorilla/mux"
+)
+
+var analyticsParams = []string{"from", "to", "bucket"}
+
+// parseAnalyticsRange reads from and to, each either RFC 3339 or a plain
+// date (midnight UTC; to is exclusive), and the bucket unit.
+func parseAnalyticsRange(r *http.Request) (domain.AnalyticsRange, error) {
+	query := r.URL.Query()
+	rng := domain.AnalyticsRange{Bucket: query.Get("bucket")}
+
+	for name, dst := range map[string]*time.Time{"from": &rng.From, "to": &rng.To} {
+		v := query.Get(name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			t, err = time.Parse(time.DateOnly, v)
+		}
+		if err != nil {
+			return rng, fmt.Errorf("%w: %s must be RFC 3339 or a date", e.ErrInvalidParams, name)
+		}
+		*dst = t
+	}
+
+	return rng, nil
+}
+
+func (h *Handler) GetAnalytics(w http.ResponseWriter, r *http.Request) {
+	rng, err := parseAnalyticsRange(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("GetAnalytics error:", err)
+		return
+	}
+
+	if rng.EventID, err = h.activeEvent(r); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("GetAnalytics error:", err)
+		return
+	}
+
+	stats, err := h.queuesService.GetAnalytics(r.Context(), rng)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("GetAnalytics error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (h *Handler) GetGameAnalytics(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("GetGameAnalytics error:", err)
+		return
+	}
+
+	rng, err := parseAnalyticsRange(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("GetGameAnalytics error:", err)
+		return
+	}
+
+	stats, err := h.queuesService.GetGameAnalytics(r.Context(), gameID, rng)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("GetGameAnalytics error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+var auditParams = []string{"actor", "action", "game_id", "target_type", "target_id", "request_id", "client_request_id", "from", "to"}
+
+// parseAuditFilter reads the audit filters: actor (login), action
+// (prefix), game_id, target_type, target_id, request_id,
+// client_request_id and the from/to bounds (RFC 3339, to is exclusive).
+func parseAuditFilter(r *http.Request) (domain.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := domain.AuditFilter{
+		Actor:           query.Get("actor"),
+		Action:          query.Get("action"),
+		TargetType:      query.Get("target_type"),
+		RequestID:       query.Get("request_id"),
+		ClientRequestID: query.Get("client_request_id"),
+	}
+
+	for name, dst := range map[string]*int{"game_id": &filter.GameID, "target_id": &filter.TargetID} {
+		if v := query.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return filter, fmt.Errorf("%w: %s: %v", e.ErrInvalidParams, name, err)
+			}
+			*dst = n
+		}
+	}
+
+	for name, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := query.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return filter, fmt.Errorf("%w: %s: %v", e.ErrInvalidParams, name, err)
+			}
+			*dst = &t
+		}
+	}
+
+	return filter, nil
+}
+
+func (h *Handler) ListAudit(w http.ResponseWriter, r *http.Request) {
+	page, err := parseListFilter(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListAudit error:", err)
+		return
+	}
+
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListAudit error:", err)
+		return
+	}
+
+	if filter.EventID, err = h.activeEvent(r); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListAudit error:", err)
+		return
+	}
+
+	var list domain.ListAuditRecords
+	cursor, err := h.queuesService.GetAuditLog(r.Context(), filter, page, &list)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListAudit error:", err)
+		return
+	}
+
+	writePage(w, list, cursor)
+}
+
+// ExportAudit streams the filtered audit log of the active event as CSV
+// or NDJSON, oldest first, like the other exports.
+func (h *Handler) ExportAudit(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ExportAudit error:", err)
+		return
+	}
+
+	if filter.EventID, err = h.activeEvent(r); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ExportAudit error:", err)
+		return
+	}
+
+	streamExport(w, r, "audit", func(ctx context.Context, fn func(*domain.AuditRecord) error) error {
+		return h.queuesService.ExportAuditLog(ctx, filter, fn)
+	})
+}
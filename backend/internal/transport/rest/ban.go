@@ -0,0 +1,64 @@
+package rest
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) ListBans(w http.ResponseWriter, r *http.Request) {
+	eventID, err := h.activeEvent(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListBans error:", err)
+		return
+	}
+
+	bans, err := h.queuesService.GetBans(r.Context(), eventID)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListBans error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, bans)
+}
+
+func (h *Handler) CreateBan(w http.ResponseWriter, r *http.Request) {
+	var info domain.BanInfo
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("CreateBan error:", err)
+		return
+	}
+
+	banID, err := h.queuesService.CreateBan(r.Context(), actorFrom(r.Context()).ID, info)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("CreateBan error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, domain.IdInfo{Id: banID})
+}
+
+func (h *Handler) LiftBan(w http.ResponseWriter, r *http.Request) {
+	banID, err := strconv.Atoi(mux.Vars(r)["ban_id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("LiftBan error:", err)
+		return
+	}
+
+	if err := h.queuesService.LiftBan(r.Context(), actorFrom(r.Context()).ID, banID); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("LiftBan error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,86 @@
+package rest
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultBoardNext = 5
+	maxBoardNext     = 20
+	boardRefresh     = 5 * time.Second
+)
+
+func boardNext(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("next")
+	if v == "" {
+		return defaultBoardNext, nil
+	}
+	next, err := strconv.Atoi(v)
+	if err != nil || next < 0 || next > maxBoardNext {
+		return 0, fmt.Errorf("next must be between 0 and %d", maxBoardNext)
+	}
+	return next, nil
+}
+
+// GetBoard serves the stands wall display: for every game who is playing
+// with time remaining, the next waiting players and the queue length.
+func (h *Handler) GetBoard(w http.ResponseWriter, r *http.Request) {
+	next, err := boardNext(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("GetBoard error:", err)
+		return
+	}
+
+	eventID, err := h.activeEvent(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("GetBoard error:", err)
+		return
+	}
+
+	board, err := h.queuesService.GetBoard(r.Context(), eventID, next)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("GetBoard error:", err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=2")
+	writeJSON(w, http.StatusOK, board)
+}
+
+// StreamBoard pushes the board as server-sent events whenever any queue
+// changes, and every boardRefresh so countdowns stay current. Bursts of
+// updates are coalesced into one push.
+func (h *Handler) StreamBoard(w http.ResponseWriter, r *http.Request) {
+	next, err := boardNext(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("StreamBoard error:", err)
+		return
+	}
+
+	eventID, err := h.activeEvent(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("StreamBoard error:", err)
+		return
+	}
+
+	updates, unsubscribe := h.queuesService.SubscribeAll()
+	defer unsubscribe()
+
+	streamSnapshots(w, r, "StreamBoard", snapshotStream{
+		event:   "board",
+		updates: updates,
+		refresh: boardRefresh,
+		load: func() (any, error) {
+			return h.queuesService.GetBoard(r.Context(), eventID, next)
+		},
+	})
+}
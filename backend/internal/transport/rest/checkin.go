@@ -0,0 +1,107 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/gorilla/mux"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const qrSize = 256
+
+// CheckinCode renders the caller's check-in token for a game as a QR code,
+// PNG by default or SVG with ?format=svg.
+func (h *Handler) CheckinCode(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("CheckinCode error:", err)
+		return
+	}
+
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("CheckinCode error:", err)
+		return
+	}
+
+	token, err := h.queuesService.CheckinToken(r.Context(), userID, gameID)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("CheckinCode error:", err)
+		return
+	}
+
+	code, err := qrcode.New(token, qrcode.Medium)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println("CheckinCode error:", err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	switch r.URL.Query().Get("format") {
+	case "", "png":
+		png, err := code.PNG(qrSize)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Println("CheckinCode error:", err)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	case "svg":
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Write([]byte(qrSVG(code.Bitmap())))
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func qrSVG(bitmap [][]bool) string {
+	var b strings.Builder
+	n := len(bitmap)
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, n, n)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, n, n)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.String()
+}
+
+func (h *Handler) ScanCheckin(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("ScanCheckin error:", err)
+		return
+	}
+
+	var info domain.CheckinInfo
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("ScanCheckin error:", err)
+		return
+	}
+
+	entry, err := h.queuesService.ScanCheckin(r.Context(), actorFrom(r.Context()).ID, gameID, info.Token)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ScanCheckin error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entry)
+}
@@ -0,0 +1,238 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+	"github.com/gorilla/mux"
+)
+
+const eventHeader = "X-Event-ID"
+
+// activeEvent picks the event a list is scoped to: the one named by the
+// X-Event-ID header or the event_id query parameter, otherwise the active
+// event. "all", or no active event, returns 0 and lists every game.
+func (h *Handler) activeEvent(r *http.Request) (int, error) {
+	v := r.Header.Get(eventHeader)
+	if v == "" {
+		v = r.URL.Query().Get("event_id")
+	}
+
+	switch v {
+	case "":
+		return h.queuesService.ActiveEventID(r.Context())
+	case "all":
+		return 0, nil
+	}
+
+	eventID, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%w: event_id must be a number or all", e.ErrInvalidParams)
+	}
+	if _, err := h.queuesService.GetEvent(r.Context(), eventID); err != nil {
+		return 0, err
+	}
+	return eventID, nil
+}
+
+func (h *Handler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	events, err := h.queuesService.GetEvents(r.Context())
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListEvents error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, events)
+}
+
+func (h *Handler) GetActiveEvent(w http.ResponseWriter, r *http.Request) {
+	event, err := h.queuesService.GetActiveEvent(r.Context())
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("GetActiveEvent error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, event)
+}
+
+func (h *Handler) GetEvent(w http.ResponseWriter, r *http.Request) {
+	eventID, err := strconv.Atoi(mux.Vars(r)["event_id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("GetEvent error:", err)
+		return
+	}
+
+	event, err := h.queuesService.GetEvent(r.Context(), eventID)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("GetEvent error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, event)
+}
+
+func (h *Handler) CreateEvent(w http.ResponseWriter, r *http.Request) {
+	var event domain.Event
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("CreateEvent error:", err)
+		return
+	}
+
+	created, err := h.queuesService.CreateEvent(r.Context(), actorFrom(r.Context()).ID, event)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("CreateEvent error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (h *Handler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
+	eventID, err := strconv.Atoi(mux.Vars(r)["event_id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("UpdateEvent error:", err)
+		return
+	}
+
+	var event domain.Event
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("UpdateEvent error:", err)
+		return
+	}
+
+	if err := h.queuesService.UpdateEvent(r.Context(), actorFrom(r.Context()).ID, eventID, event); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("UpdateEvent error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) JoinEvent(w http.ResponseWriter, r *http.Request) {
+	eventID, err := strconv.Atoi(mux.Vars(r)["event_id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("JoinEvent error:", err)
+		return
+	}
+
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("JoinEvent error:", err)
+		return
+	}
+
+	if err := h.queuesService.JoinEvent(r.Context(), userID, eventID); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("JoinEvent error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) LeaveEvent(w http.ResponseWriter, r *http.Request) {
+	eventID, err := strconv.Atoi(mux.Vars(r)["event_id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("LeaveEvent error:", err)
+		return
+	}
+
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("LeaveEvent error:", err)
+		return
+	}
+
+	if err := h.queuesService.LeaveEvent(r.Context(), userID, eventID); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("LeaveEvent error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ListEventMembers(w http.ResponseWriter, r *http.Request) {
+	eventID, err := strconv.Atoi(mux.Vars(r)["event_id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("ListEventMembers error:", err)
+		return
+	}
+
+	members, err := h.queuesService.GetEventMembers(r.Context(), eventID)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListEventMembers error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, members)
+}
+
+func (h *Handler) SetEventMember(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	eventID, err := strconv.Atoi(vars["event_id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetEventMember error:", err)
+		return
+	}
+
+	var info domain.RoleInfo
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetEventMember error:", err)
+		return
+	}
+
+	if err := h.queuesService.SetEventMember(r.Context(), actorFrom(r.Context()).ID, eventID, vars["login"], info.Role); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("SetEventMember error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) SetGameEvent(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetGameEvent error:", err)
+		return
+	}
+
+	var info domain.EventAssign
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetGameEvent error:", err)
+		return
+	}
+
+	if err := h.queuesService.SetGameEvent(r.Context(), actorFrom(r.Context()).ID, gameID, info.EventID); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("SetGameEvent error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,208 @@
+package rest
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+const (
+	exportCSV    = "csv"
+	exportNDJSON = "ndjson"
+
+	exportFlushEvery = 100
+)
+
+// rowWriter writes one exported record at a time.
+type rowWriter interface {
+	Write(v any) error
+	Flush() error
+}
+
+type ndjsonWriter struct{ enc *json.Encoder }
+
+func (w ndjsonWriter) Write(v any) error { return w.enc.Encode(v) }
+func (w ndjsonWriter) Flush() error      { return nil }
+
+// csvRowWriter flattens a struct into columns named after its JSON fields.
+type csvRowWriter struct{ cw *csv.Writer }
+
+func (w csvRowWriter) Write(v any) error {
+	return w.cw.Write(csvRecord(reflect.Indirect(reflect.ValueOf(v))))
+}
+
+func (w csvRowWriter) Flush() error {
+	w.cw.Flush()
+	return w.cw.Error()
+}
+
+// csvFields visits the exported fields of t in order, with embedded
+// structs flattened the way encoding/json does.
+func csvFields(t reflect.Type, visit func(name string, index []int)) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		// like encoding/json, the fields of an embedded struct count even
+		// when its type is unexported
+		if name == "" && f.Anonymous && f.Type.Kind() == reflect.Struct {
+			csvFields(f.Type, func(name string, index []int) {
+				visit(name, append([]int{i}, index...))
+			})
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		visit(name, []int{i})
+	}
+}
+
+func csvHeader(t reflect.Type) []string {
+	var header []string
+	csvFields(t, func(name string, _ []int) { header = append(header, name) })
+	return header
+}
+
+func csvRecord(v reflect.Value) []string {
+	var record []string
+	csvFields(v.Type(), func(_ string, index []int) {
+		record = append(record, csvValue(v.FieldByIndex(index)))
+	})
+	return record
+}
+
+func csvValue(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	switch x := v.Interface().(type) {
+	case time.Time:
+		return x.Format(time.RFC3339)
+	case json.RawMessage:
+		return string(x)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return csvText(v.String())
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	}
+	raw, _ := json.Marshal(v.Interface())
+	return string(raw)
+}
+
+// csvText keeps a spreadsheet from running user text as a formula by
+// prefixing the cells it would treat as one with a quote.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// newRowWriter starts an export of records of type t; a CSV export
+// begins with its header so that even an empty one names its columns.
+func newRowWriter(format string, w io.Writer, t reflect.Type) (rowWriter, error) {
+	if format == exportNDJSON {
+		return ndjsonWriter{json.NewEncoder(w)}, nil
+	}
+	cw := csv.NewWriter(w)
+	return csvRowWriter{cw}, cw.Write(csvHeader(t))
+}
+
+// streamExport writes every record produced by export as CSV (the
+// default) or NDJSON, flushing as it goes so that nothing but the current
+// batch is held in memory. Once the first byte is out the status can no
+// longer change, so a failure mid-stream is only logged.
+func streamExport[T any](w http.ResponseWriter, r *http.Request, name string, export func(context.Context, func(*T) error) error) {
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "":
+		format = exportCSV
+	case exportCSV, exportNDJSON:
+	default:
+		err := fmt.Errorf("%w: format must be csv or ndjson", e.ErrInvalidParams)
+		w.WriteHeader(errorStatus(err))
+		log.Printf("Export %s error: %v", name, err)
+		return
+	}
+
+	if format == exportNDJSON {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	} else {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+
+	rw, err := newRowWriter(format, w, reflect.TypeFor[T]())
+	if err != nil {
+		log.Printf("Export %s error: %v", name, err)
+		return
+	}
+	flusher, _ := w.(http.Flusher)
+
+	n := 0
+	err = export(r.Context(), func(v *T) error {
+		if err := rw.Write(v); err != nil {
+			return err
+		}
+		if n++; n%exportFlushEvery == 0 {
+			if err := rw.Flush(); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		err = rw.Flush()
+	}
+	if err != nil {
+		log.Printf("Export %s error: %v", name, err)
+	}
+}
+
+func (h *Handler) ExportGames(w http.ResponseWriter, r *http.Request) {
+	streamExport(w, r, "games", h.queuesService.ExportGames)
+}
+
+func (h *Handler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	streamExport(w, r, "users", h.queuesService.ExportUsers)
+}
+
+func (h *Handler) ExportQueues(w http.ResponseWriter, r *http.Request) {
+	streamExport(w, r, "queues", h.queuesService.ExportQueues)
+}
+
+func (h *Handler) ExportHistory(w http.ResponseWriter, r *http.Request) {
+	streamExport(w, r, "history", h.queuesService.ExportHistory)
+}
+
+func (h *Handler) ExportEvents(w http.ResponseWriter, r *http.Request) {
+	streamExport(w, r, "events", h.queuesService.ExportEvents)
+}
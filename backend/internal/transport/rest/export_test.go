@@ -0,0 +1,140 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+type exportBase struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type exportRow struct {
+	exportBase
+	Secret  string `json:"-"`
+	hidden  string
+	Plain   int        // no tag
+	Score   *int       `json:"score,omitempty"`
+	At      *time.Time `json:"at"`
+	Tags    []string   `json:"tags"`
+	Enabled bool       `json:"enabled"`
+}
+
+func TestCSVFields(t *testing.T) {
+	var names []string
+	var indexes [][]int
+	csvFields(reflect.TypeFor[exportRow](), func(name string, index []int) {
+		names = append(names, name)
+		indexes = append(indexes, index)
+	})
+
+	wantNames := []string{"id", "name", "Plain", "score", "at", "tags", "enabled"}
+	if !slices.Equal(names, wantNames) {
+		t.Fatalf("names = %v, want %v", names, wantNames)
+	}
+	if !slices.Equal(indexes[0], []int{0, 0}) || !slices.Equal(indexes[1], []int{0, 1}) {
+		t.Fatalf("embedded indexes = %v, want [0 0] and [0 1]", indexes[:2])
+	}
+}
+
+func TestCSVRecord(t *testing.T) {
+	score := -3
+	at := time.Date(2025, 3, 1, 12, 30, 0, 0, time.UTC)
+	row := exportRow{
+		exportBase: exportBase{ID: 7, Name: "=HYPERLINK(\"x\")"},
+		Score:      &score,
+		At:         &at,
+		Tags:       []string{"a"},
+	}
+
+	got := csvRecord(reflect.ValueOf(row))
+	want := []string{"7", "'=HYPERLINK(\"x\")", "0", "-3", "2025-03-01T12:30:00Z", `["a"]`, "false"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("record = %q, want %q", got, want)
+	}
+
+	empty := csvRecord(reflect.ValueOf(exportRow{}))
+	if empty[3] != "" || empty[4] != "" {
+		t.Fatalf("nil pointers = %q, %q, want empty cells", empty[3], empty[4])
+	}
+}
+
+func TestCSVText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"plain", "plain"},
+		{"a=b", "a=b"},
+		{"=1+1", "'=1+1"},
+		{"+1", "'+1"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tx", "'\tx"},
+		{"\rx", "'\rx"},
+	}
+	for _, tt := range tests {
+		if got := csvText(tt.in); got != tt.want {
+			t.Errorf("csvText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestStreamExport(t *testing.T) {
+	rows := []exportBase{{1, "one"}, {2, "-two"}}
+
+	tests := []struct {
+		name        string
+		format      string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"default is csv", "", http.StatusOK, "text/csv; charset=utf-8", "id,name\n1,one\n2,'-two\n"},
+		{"csv", "csv", http.StatusOK, "text/csv; charset=utf-8", "id,name\n1,one\n2,'-two\n"},
+		{"ndjson", "ndjson", http.StatusOK, "application/x-ndjson", "{\"id\":1,\"name\":\"one\"}\n{\"id\":2,\"name\":\"-two\"}\n"},
+		{"unknown", "xml", http.StatusBadRequest, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			export := func(_ context.Context, fn func(*exportBase) error) error {
+				for i := range rows {
+					if err := fn(&rows[i]); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/export?format="+tt.format, nil)
+			streamExport(w, r, "rows", export)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Fatalf("content type = %q, want %q", got, tt.contentType)
+			}
+			if got := w.Body.String(); got != tt.body {
+				t.Fatalf("body = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestStreamExportEmptyCSV(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/export", nil)
+	streamExport(w, r, "rows", func(context.Context, func(*exportBase) error) error { return nil })
+
+	if got := w.Body.String(); got != "id,name\n" {
+		t.Fatalf("body = %q, want only the header", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
 
 	"github.com/DexScen/Queue/backend/internal/domain"
@@ -14,9 +15,9 @@ import (
 )
 
 type Queues interface {
-	GetAllGames(ctx context.Context, listGames *domain.ListGames) error
+	GetAllGames(ctx context.Context, filter domain.ListFilter, listGames *domain.ListGames) (*domain.Cursor, error)
 	GetGameInfoByID(ctx context.Context, id int) (*domain.Game, error)
-	GetGamesByLogin(ctx context.Context, login string, listGames *domain.ListGameInfos) error
+	GetGamesByLogin(ctx context.Context, login string, filter domain.ListFilter, listGames *domain.ListGameInfos) (*domain.Cursor, error)
 	GetIdByLogin(ctx context.Context, login string) (int, error)
 
 	Register(ctx context.Context, user *domain.User) error
@@ -25,11 +26,117 @@ type Queues interface {
 	RemovePlayerFromQueue(ctx context.Context, user_id, game_id int) error
 	AddPlayerToQueue(ctx context.Context, user_id, game_id int) (int, error)
 
-	GetPlayersByGameID(ctx context.Context, game_id int, listUsers *domain.ListUsers) error
+	GetUserByLogin(ctx context.Context, login string) (*domain.User, error)
+	SessionToken(ctx context.Context, login string) (string, error)
+	Authenticate(ctx context.Context, token string) (*domain.User, error)
+	SessionUserID(token string) (int, error)
+
+	MoveEntry(ctx context.Context, actorID, game_id, entry_id, position int) error
+	SwapEntries(ctx context.Context, actorID, game_id, entry_id, other_entry_id int) error
+	InsertPlayerAt(ctx context.Context, actorID, game_id int, login string, position int) (int, error)
+	Subscribe(game_id int) (<-chan domain.QueueUpdate, func())
+
+	CallNext(ctx context.Context, actorID, game_id, station_id int) (int, error)
+	MarkArrived(ctx context.Context, actorID, game_id, entry_id int) error
+	FinishEntry(ctx context.Context, actorID, game_id, entry_id int, res domain.ResultInfo) error
+	GetNoShowPolicy(ctx context.Context, game_id int) (*domain.NoShowPolicy, error)
+	SetNoShowPolicy(ctx context.Context, actorID, game_id int, policy domain.NoShowPolicy) error
+	CheckinToken(ctx context.Context, user_id, game_id int) (string, error)
+	ScanCheckin(ctx context.Context, actorID, game_id int, token string) (*domain.QueueEntry, error)
+
+	IssueTicket(ctx context.Context, actorID, game_id int, info domain.TicketInfo) (*domain.Ticket, error)
+	ClaimTicket(ctx context.Context, user_id int, info domain.ClaimInfo) (*domain.QueueEntry, error)
+	RemoveEntry(ctx context.Context, actorID, game_id, entry_id int) error
+
+	GetBoard(ctx context.Context, event_id, next int) (*domain.Board, error)
+	SubscribeAll() (<-chan domain.QueueUpdate, func())
+
+	CreateParty(ctx context.Context, leader_id int, name string) (*domain.Party, error)
+	GetParty(ctx context.Context, user_id, party_id int) (*domain.Party, error)
+	InviteToParty(ctx context.Context, leader_id, party_id int, login string) error
+	JoinPartyByCode(ctx context.Context, user_id int, code string) (*domain.Party, error)
+	AcceptPartyInvite(ctx context.Context, user_id, party_id int) error
+	LeaveParty(ctx context.Context, user_id, party_id int) error
+	AddPartyToQueue(ctx context.Context, leader_id, party_id, game_id int) (int, error)
+
+	SetEntryPriority(ctx context.Context, actorID, game_id, entry_id, priority int) (int, error)
+	SetUserPriority(ctx context.Context, actorID int, login string, priority int) error
+	GetPriorityPolicy(ctx context.Context, game_id int) (*domain.PriorityPolicy, error)
+	SetPriorityPolicy(ctx context.Context, actorID, game_id int, policy domain.PriorityPolicy) error
+
+	GetSchedulingPolicy(ctx context.Context, game_id int) (*domain.SchedulingPolicy, error)
+	SetSchedulingPolicy(ctx context.Context, actorID, game_id int, policy domain.SchedulingPolicy) error
+	SetUserGroup(ctx context.Context, actorID int, login, group string) error
+
+	GetStations(ctx context.Context, game_id int) (domain.ListStations, error)
+	CreateStation(ctx context.Context, actorID, game_id int, info domain.StationInfo) (int, error)
+	UpdateStation(ctx context.Context, actorID, game_id, station_id int, info domain.StationInfo) error
+
+	GetHours(ctx context.Context, game_id int) (*domain.HoursStatus, error)
+	SetHours(ctx context.Context, actorID, game_id int, hours domain.Hours) error
+	SetPaused(ctx context.Context, actorID, game_id int, paused bool) error
+
+	GetSlots(ctx context.Context, game_id int, date string) ([]domain.ReservationSlot, error)
+	GetReservations(ctx context.Context, user_id, event_id int) (domain.ListReservations, error)
+	BookReservation(ctx context.Context, user_id, game_id int, info domain.ReservationInfo) (*domain.Reservation, error)
+	RescheduleReservation(ctx context.Context, user_id, reservation_id int, info domain.ReservationInfo) (*domain.Reservation, error)
+	CancelReservation(ctx context.Context, user_id, reservation_id int) error
+	GetReservationPolicy(ctx context.Context, game_id int) (*domain.ReservationPolicy, error)
+	SetReservationPolicy(ctx context.Context, actorID, game_id int, policy domain.ReservationPolicy) error
+
+	GetUserHistory(ctx context.Context, user_id int, filter domain.ListFilter, list *domain.ListHistoryEntries) (*domain.Cursor, error)
+	GetGameTimeline(ctx context.Context, game_id int, filter domain.ListFilter, list *domain.ListQueueEvents) (*domain.Cursor, error)
+
+	GetAuditLog(ctx context.Context, filter domain.AuditFilter, page domain.ListFilter, list *domain.ListAuditRecords) (*domain.Cursor, error)
+	ExportAuditLog(ctx context.Context, filter domain.AuditFilter, fn func(*domain.AuditRecord) error) error
+
+	GetAnalytics(ctx context.Context, r domain.AnalyticsRange) (domain.ListGameStats, error)
+	GetGameAnalytics(ctx context.Context, game_id int, r domain.AnalyticsRange) (domain.ListGameStats, error)
+
+	ExportGames(ctx context.Context, fn func(*domain.Game) error) error
+	ExportUsers(ctx context.Context, fn func(*domain.UserRecord) error) error
+	ExportQueues(ctx context.Context, fn func(*domain.QueueEntry) error) error
+	ExportHistory(ctx context.Context, fn func(*domain.QueueEntry) error) error
+	ExportEvents(ctx context.Context, fn func(*domain.QueueEvent) error) error
+
+	ImportGames(ctx context.Context, actorID int, rows []domain.GameImport, decodeErrs []domain.ImportError, dryRun bool) (*domain.ImportResult, error)
+	ImportUsers(ctx context.Context, actorID int, rows []domain.UserImport, decodeErrs []domain.ImportError, dryRun bool) (*domain.ImportResult, error)
+
+	GetEvents(ctx context.Context) (domain.ListEvents, error)
+	GetEvent(ctx context.Context, event_id int) (*domain.Event, error)
+	GetActiveEvent(ctx context.Context) (*domain.Event, error)
+	ActiveEventID(ctx context.Context) (int, error)
+	CreateEvent(ctx context.Context, actorID int, event domain.Event) (*domain.Event, error)
+	UpdateEvent(ctx context.Context, actorID, event_id int, event domain.Event) error
+	JoinEvent(ctx context.Context, user_id, event_id int) error
+	LeaveEvent(ctx context.Context, user_id, event_id int) error
+	GetEventMembers(ctx context.Context, event_id int) (domain.ListEventMembers, error)
+	SetEventMember(ctx context.Context, actorID, event_id int, login, role string) error
+	SetGameEvent(ctx context.Context, actorID, game_id, event_id int) error
+	EventRole(ctx context.Context, event_id, user_id int) (string, error)
+	GameEventRole(ctx context.Context, game_id, user_id int) (string, error)
+
+	SetEntryScore(ctx context.Context, actorID, game_id, entry_id int, res domain.ResultInfo) error
+	GetScoringPolicy(ctx context.Context, game_id int) (*domain.ScoringPolicy, error)
+	SetScoringPolicy(ctx context.Context, actorID, game_id int, policy domain.ScoringPolicy) error
+	GetGameLeaderboard(ctx context.Context, game_id, limit int) (*domain.Leaderboard, error)
+	GetLeaderboard(ctx context.Context, event_id, limit int) (*domain.Leaderboard, error)
+
+	RateGame(ctx context.Context, user_id, game_id int, info domain.RatingInfo) (int, error)
+	GetRatings(ctx context.Context, game_id int, withHidden bool, filter domain.ListFilter, list *domain.ListRatings) (*domain.Cursor, error)
+	SetRatingHidden(ctx context.Context, actorID, game_id, rating_id int, hidden bool) error
+
+	GetBans(ctx context.Context, event_id int) (domain.ListBans, error)
+	CreateBan(ctx context.Context, actorID int, info domain.BanInfo) (int, error)
+	LiftBan(ctx context.Context, actorID, ban_id int) error
+
+	GetPlayersByGameID(ctx context.Context, game_id int, filter domain.ListFilter, listEntries *domain.ListQueueEntries) (*domain.Cursor, error)
 }
 
 type Handler struct {
 	queuesService Queues
+	spec          map[string]any
+	limiter       *RateLimiter
 }
 
 func NewQueues(queues Queues) *Handler {
@@ -38,35 +145,168 @@ func NewQueues(queues Queues) *Handler {
 	}
 }
 
+// UseRateLimiter throttles the routes registered by InitRouter.
+func (h *Handler) UseRateLimiter(l *RateLimiter) {
+	h.limiter = l
+}
+
 func (h *Handler) OptionsHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-func (h *Handler) InitRouter() *mux.Router {
+func (h *Handler) InitRouter() (*mux.Router, error) {
 	r := mux.NewRouter().StrictSlash(true)
+	r.Use(requestIDMiddleware)
 	r.Use(loggingMiddleware)
 	r.Use(corsMiddleware)
 
-	links := r.PathPrefix("").Subrouter()
-	{
-		links.HandleFunc("/games", h.GetAllGames).Methods(http.MethodGet)
-		links.HandleFunc("/games/{id}", h.GetGameInfoByID).Methods(http.MethodGet)
-		links.HandleFunc("/queue/{login}", h.GetGamesByLogin).Methods(http.MethodGet)
-		links.HandleFunc("/auth/{login}", h.GetIdByLogin).Methods(http.MethodGet)
+	routes := h.routes()
+	spec, err := buildOpenAPI(routes)
+	if err != nil {
+		return nil, err
+	}
+	h.spec = spec
 
-		links.HandleFunc("/auth/register", h.Register).Methods(http.MethodPost)
-		links.HandleFunc("/auth/login", h.LogIn).Methods(http.MethodPost)
-		
-		links.HandleFunc("/remove", h.RemovePlayerFromQueue).Methods(http.MethodDelete)
-		links.HandleFunc("/add", h.AddPlayerToQueue).Methods(http.MethodPost)
+	api := r.PathPrefix(apiPrefix).Subrouter()
+	{
+		for _, rt := range routes {
+			api.HandleFunc(rt.path, h.limit(rt.method, rt.path, rt.handler)).Methods(rt.method)
+		}
+		api.HandleFunc("/openapi.json", h.OpenAPI).Methods(http.MethodGet)
+	}
 
-		links.HandleFunc("/players/{id}", h.GetPlayersByGameID).Methods(http.MethodGet)
+	// legacy routes are kept as deprecated aliases for one release
+	links := r.PathPrefix("").Subrouter()
+	{
+		for _, rt := range h.legacyRoutes() {
+			links.HandleFunc(rt.path, deprecated(rt.successor, h.limit(rt.method, rt.successor, rt.handler))).Methods(rt.method)
+		}
 
 		links.HandleFunc("", h.OptionsHandler).Methods(http.MethodOptions)
 		links.PathPrefix("/").HandlerFunc(h.OptionsHandler).Methods(http.MethodOptions)
 
 	}
-	return r
+	return r, nil
+}
+
+func (h *Handler) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.spec)
+}
+
+func (h *Handler) JoinQueue(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("JoinQueue error:", err)
+		return
+	}
+
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("JoinQueue error:", err)
+		return
+	}
+
+	position, err := h.queuesService.AddPlayerToQueue(r.Context(), userID, gameID)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("JoinQueue error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, domain.PosInfo{Pos: position})
+}
+
+func (h *Handler) LeaveQueue(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("LeaveQueue error:", err)
+		return
+	}
+
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("LeaveQueue error:", err)
+		return
+	}
+
+	if err := h.queuesService.RemovePlayerFromQueue(r.Context(), userID, gameID); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("LeaveQueue error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// currentUserID resolves the caller from their session token.
+func (h *Handler) currentUserID(r *http.Request) (int, error) {
+	user, err := h.authenticate(r)
+	if err != nil {
+		return 0, err
+	}
+	return user.ID, nil
+}
+
+// checkActingFor lets the caller change the queue entry of user_id in
+// game_id: their own, or anyone's if they run the game.
+func (h *Handler) checkActingFor(r *http.Request, user_id, game_id int) error {
+	user, err := h.authenticate(r)
+	if err != nil {
+		return err
+	}
+	if user.ID == user_id || slices.Contains(staff, user.Role) {
+		return nil
+	}
+
+	role, err := h.queuesService.GameEventRole(r.Context(), game_id, user.ID)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(staff, role) {
+		return e.ErrForbidden
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	jsonResp, err := json.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println("writeJSON error:", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(jsonResp)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, e.ErrGameNotFound), errors.Is(err, e.ErrUserNotFound), errors.Is(err, e.ErrEntryNotFound),
+		errors.Is(err, e.ErrPartyNotFound), errors.Is(err, e.ErrStationNotFound), errors.Is(err, e.ErrReservationNotFound),
+		errors.Is(err, e.ErrEventNotFound), errors.Is(err, e.ErrRatingNotFound),
+		errors.Is(err, e.ErrBanNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, e.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, e.ErrForbidden), errors.Is(err, e.ErrBanned):
+		return http.StatusForbidden
+	case errors.Is(err, e.ErrUserExists), errors.Is(err, e.ErrNothingToCall), errors.Is(err, e.ErrNotCalled),
+		errors.Is(err, e.ErrAlreadyInQueue), errors.Is(err, e.ErrPartyTooLarge), errors.Is(err, e.ErrPartyInPlay), errors.Is(err, e.ErrStationBusy),
+		errors.Is(err, e.ErrStationExists), errors.Is(err, e.ErrGameExists), errors.Is(err, e.ErrQueueClosed), errors.Is(err, e.ErrSlotFull),
+		errors.Is(err, e.ErrAlreadyBooked), errors.Is(err, e.ErrEventActive), errors.Is(err, e.ErrNotFinished),
+		errors.Is(err, e.ErrAlreadyRated):
+		return http.StatusConflict
+	case errors.Is(err, e.ErrInvalidParams), errors.Is(err, e.ErrInvalidToken), errors.Is(err, e.ErrWrongGame):
+		return http.StatusBadRequest
+	case errors.Is(err, e.ErrTokenExpired):
+		return http.StatusGone
+	}
+	return http.StatusInternalServerError
 }
 
 func (h *Handler) AddPlayerToQueue(w http.ResponseWriter, r *http.Request) {
@@ -78,6 +318,12 @@ func (h *Handler) AddPlayerToQueue(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := h.checkActingFor(r, addInfo.UserID, addInfo.GameID); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("addPlayerToQueue error:", err)
+		return
+	}
+
 	position, err := h.queuesService.AddPlayerToQueue(r.Context(), addInfo.UserID, addInfo.GameID)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -104,6 +350,12 @@ func (h *Handler) RemovePlayerFromQueue(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if err := h.checkActingFor(r, removeInfo.UserID, removeInfo.GameID); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("RemovePlayerFromQueue error:", err)
+		return
+	}
+
 	err := h.queuesService.RemovePlayerFromQueue(r.Context(), removeInfo.UserID, removeInfo.GameID)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -115,8 +367,15 @@ func (h *Handler) RemovePlayerFromQueue(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *Handler) GetAllGames(w http.ResponseWriter, r *http.Request) {
+	eventID, err := h.activeEvent(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("getAllGames error:", err)
+		return
+	}
+
 	var list domain.ListGames
-	if err := h.queuesService.GetAllGames(context.TODO(), &list); err != nil {
+	if _, err := h.queuesService.GetAllGames(context.TODO(), domain.ListFilter{EventID: eventID}, &list); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		log.Println("getAllGames error:", err)
 		return
@@ -164,8 +423,15 @@ func (h *Handler) GetGamesByLogin(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	loginStr := vars["login"]
 
+	eventID, err := h.activeEvent(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("GetGamesByLogin error:", err)
+		return
+	}
+
 	var list domain.ListGameInfos
-	if err := h.queuesService.GetGamesByLogin(context.TODO(), loginStr, &list); err != nil {
+	if _, err := h.queuesService.GetGamesByLogin(context.TODO(), loginStr, domain.ListFilter{EventID: eventID}, &list); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		log.Println("GetGamesByLogin error:", err)
 		return
@@ -199,11 +465,18 @@ func (h *Handler) LogIn(w http.ResponseWriter, r *http.Request) {
 		} else if errors.Is(err, e.ErrWrongPassword) {
 			role = "wrong password"
 			log.Println("Login error:", err)
+		} else if errors.Is(err, e.ErrBanned) {
+			role = "banned"
+			log.Println("Login error:", err)
 		} else {
 			w.WriteHeader(http.StatusInternalServerError)
 			log.Println("Login error:", err, e.ErrUserNotFound)
 			return
 		}
+	} else if roleInfo.Token, err = h.queuesService.SessionToken(r.Context(), info.Login); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println("Login error:", err)
+		return
 	}
 	roleInfo.Role = role
 	if jsonResp, err := json.Marshal(roleInfo); err != nil {
@@ -250,6 +523,11 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 			log.Println("Register error:", err)
 			return
 		}
+		if roleInfo.Token, err = h.queuesService.SessionToken(r.Context(), user.Login); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Println("Register error:", err)
+			return
+		}
 		if jsonResp, err := json.Marshal(roleInfo); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			log.Println("Register error:", err)
@@ -261,7 +539,7 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (h *Handler) GetIdByLogin(w http.ResponseWriter, r *http.Request){
+func (h *Handler) GetIdByLogin(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	loginStr := vars["login"]
 
@@ -284,24 +562,30 @@ func (h *Handler) GetIdByLogin(w http.ResponseWriter, r *http.Request){
 	}
 }
 
-func (h *Handler) GetPlayersByGameID(w http.ResponseWriter, r *http.Request){
-	var list domain.ListUsers
+func (h *Handler) GetPlayersByGameID(w http.ResponseWriter, r *http.Request) {
+	var entries domain.ListQueueEntries
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 	id, err := strconv.Atoi(idStr)
 
-	if err != nil{
+	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		log.Println("getPlayersByGameID error:", err)
 		return
 	}
 
-	if err := h.queuesService.GetPlayersByGameID(context.TODO(), id, &list); err != nil {
+	if _, err := h.queuesService.GetPlayersByGameID(context.TODO(), id, domain.ListFilter{}, &entries); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		log.Println("getPlayersByGameID error:", err)
 		return
 	}
 
+	// the legacy route answers with bare users
+	list := make(domain.ListUsers, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, domain.User{ID: entry.UserID, Login: entry.Login})
+	}
+
 	if jsonResp, err := json.Marshal(list); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		log.Println("getPlayersByGameID error:", err)
@@ -310,4 +594,4 @@ func (h *Handler) GetPlayersByGameID(w http.ResponseWriter, r *http.Request){
 		w.Header().Set("Content-Type", "application/json")
 		w.Write(jsonResp)
 	}
-}
\ No newline at end of file
+}
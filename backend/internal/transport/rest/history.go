@@ -0,0 +1,70 @@
+package rest
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) ListHistory(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseListFilter(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListHistory error:", err)
+		return
+	}
+
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListHistory error:", err)
+		return
+	}
+
+	if filter.EventID, err = h.activeEvent(r); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListHistory error:", err)
+		return
+	}
+
+	var list domain.ListHistoryEntries
+	cursor, err := h.queuesService.GetUserHistory(r.Context(), userID, filter, &list)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListHistory error:", err)
+		return
+	}
+
+	writePage(w, list, cursor)
+}
+
+// ListTimeline pages through the events of a game. The status parameter
+// filters event types here, e.g. status=called,finished.
+func (h *Handler) ListTimeline(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("ListTimeline error:", err)
+		return
+	}
+
+	filter, err := parseListFilter(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListTimeline error:", err)
+		return
+	}
+
+	var list domain.ListQueueEvents
+	cursor, err := h.queuesService.GetGameTimeline(r.Context(), gameID, filter, &list)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListTimeline error:", err)
+		return
+	}
+
+	writePage(w, list, cursor)
+}
@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) GetHours(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("GetHours error:", err)
+		return
+	}
+
+	status, err := h.queuesService.GetHours(r.Context(), gameID)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("GetHours error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (h *Handler) SetHours(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetHours error:", err)
+		return
+	}
+
+	var hours domain.Hours
+	if err := json.NewDecoder(r.Body).Decode(&hours); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetHours error:", err)
+		return
+	}
+
+	if err := h.queuesService.SetHours(r.Context(), actorFrom(r.Context()).ID, gameID, hours); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("SetHours error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setPaused returns the handler of the pause or resume endpoint.
+func (h *Handler) setPaused(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Println("SetPaused error:", err)
+			return
+		}
+
+		if err := h.queuesService.SetPaused(r.Context(), actorFrom(r.Context()).ID, gameID, paused); err != nil {
+			w.WriteHeader(errorStatus(err))
+			log.Println("SetPaused error:", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,89 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+	"github.com/DexScen/Queue/backend/internal/importer"
+)
+
+const maxImportSize = 10 << 20
+
+var importParams = []string{"format", "dry_run"}
+
+// importFormat takes the format from the query, falling back to the
+// content type of the body; CSV is the default.
+func importFormat(r *http.Request) (string, error) {
+	switch format := r.URL.Query().Get("format"); format {
+	case importer.FormatCSV, importer.FormatJSON:
+		return format, nil
+	case "":
+	default:
+		return "", fmt.Errorf("%w: format must be csv or json", e.ErrInvalidParams)
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		return importer.FormatJSON, nil
+	}
+	return importer.FormatCSV, nil
+}
+
+type importFunc[T any] func(ctx context.Context, actorID int, rows []T, decodeErrs []domain.ImportError, dryRun bool) (*domain.ImportResult, error)
+
+// importFile decodes the request body and runs the import. The result is
+// returned with 200, or with 422 when rows were rejected by a real run and
+// nothing was written.
+func importFile[T any](w http.ResponseWriter, r *http.Request, name string, run importFunc[T]) {
+	format, err := importFormat(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Printf("Import %s error: %v", name, err)
+		return
+	}
+
+	dryRun := false
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Printf("Import %s error: %v", name, err)
+			return
+		}
+	}
+
+	rows, decodeErrs, err := importer.Decode[T](http.MaxBytesReader(w, r.Body, maxImportSize), format)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Printf("Import %s error: %v", name, err)
+		return
+	}
+
+	result, err := run(r.Context(), actorFrom(r.Context()).ID, rows, decodeErrs, dryRun)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Printf("Import %s error: %v", name, err)
+		return
+	}
+
+	status := http.StatusOK
+	if !dryRun && !result.Applied {
+		status = http.StatusUnprocessableEntity
+	}
+	if result.Errors == nil {
+		result.Errors = []domain.ImportError{}
+	}
+	writeJSON(w, status, result)
+}
+
+func (h *Handler) ImportGames(w http.ResponseWriter, r *http.Request) {
+	importFile(w, r, "games", h.queuesService.ImportGames)
+}
+
+func (h *Handler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	importFile(w, r, "users", h.queuesService.ImportUsers)
+}
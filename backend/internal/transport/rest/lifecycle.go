@@ -0,0 +1,128 @@
+package rest
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/gorilla/mux"
+)
+
+// entryVars parses the {id} and {entry_id} path variables.
+func entryVars(r *http.Request) (int, int, error) {
+	vars := mux.Vars(r)
+	gameID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return 0, 0, err
+	}
+	entryID, err := strconv.Atoi(vars["entry_id"])
+	if err != nil {
+		return 0, 0, err
+	}
+	return gameID, entryID, nil
+}
+
+func (h *Handler) CallNext(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("CallNext error:", err)
+		return
+	}
+
+	entryID, err := h.queuesService.CallNext(r.Context(), actorFrom(r.Context()).ID, gameID, 0)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("CallNext error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, domain.IdInfo{Id: entryID})
+}
+
+func (h *Handler) MarkArrived(w http.ResponseWriter, r *http.Request) {
+	gameID, entryID, err := entryVars(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("MarkArrived error:", err)
+		return
+	}
+
+	if err := h.queuesService.MarkArrived(r.Context(), actorFrom(r.Context()).ID, gameID, entryID); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("MarkArrived error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) FinishEntry(w http.ResponseWriter, r *http.Request) {
+	gameID, entryID, err := entryVars(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("FinishEntry error:", err)
+		return
+	}
+
+	// the result is optional: an empty body finishes an unscored session
+	var res domain.ResultInfo
+	if err := json.NewDecoder(r.Body).Decode(&res); err != nil && err != io.EOF {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("FinishEntry error:", err)
+		return
+	}
+
+	if err := h.queuesService.FinishEntry(r.Context(), actorFrom(r.Context()).ID, gameID, entryID, res); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("FinishEntry error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) GetNoShowPolicy(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("GetNoShowPolicy error:", err)
+		return
+	}
+
+	policy, err := h.queuesService.GetNoShowPolicy(r.Context(), gameID)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("GetNoShowPolicy error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, policy)
+}
+
+func (h *Handler) SetNoShowPolicy(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetNoShowPolicy error:", err)
+		return
+	}
+
+	var policy domain.NoShowPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetNoShowPolicy error:", err)
+		return
+	}
+
+	if err := h.queuesService.SetNoShowPolicy(r.Context(), actorFrom(r.Context()).ID, gameID, policy); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("SetNoShowPolicy error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
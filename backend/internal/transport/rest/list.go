@@ -0,0 +1,169 @@
+package rest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// parseListFilter reads the common list query parameters:
+// limit, cursor, sort (a leading "-" sorts descending), status,
+// joined_after (RFC 3339) and q (substring search).
+func parseListFilter(r *http.Request) (domain.ListFilter, error) {
+	query := r.URL.Query()
+	filter := domain.ListFilter{
+		Limit:  defaultPageSize,
+		Status: query.Get("status"),
+		Search: query.Get("q"),
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return filter, fmt.Errorf("%w: limit must be between 1 and %d", e.ErrInvalidParams, maxPageSize)
+		}
+		filter.Limit = limit
+	}
+
+	if v := query.Get("sort"); v != "" {
+		filter.Sort, filter.Desc = strings.CutPrefix(v, "-")
+	}
+
+	if v := query.Get("joined_after"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, fmt.Errorf("%w: joined_after: %v", e.ErrInvalidParams, err)
+		}
+		filter.JoinedAfter = &t
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := decodeCursor(v)
+		if err != nil {
+			return filter, err
+		}
+		if filter.Sort != "" && cursor.Sort != filter.Sort {
+			return filter, fmt.Errorf("%w: cursor does not match sort", e.ErrInvalidParams)
+		}
+		filter.Cursor = cursor
+	}
+
+	return filter, nil
+}
+
+func encodeCursor(c *domain.Cursor) string {
+	if c == nil {
+		return ""
+	}
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (*domain.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", e.ErrInvalidParams)
+	}
+	var c domain.Cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.Sort == "" || c.ID < 0 {
+		return nil, fmt.Errorf("%w: malformed cursor", e.ErrInvalidParams)
+	}
+	return &c, nil
+}
+
+func writePage[T any](w http.ResponseWriter, items []T, cursor *domain.Cursor) {
+	if items == nil {
+		items = []T{}
+	}
+	writeJSON(w, http.StatusOK, domain.Page[T]{Items: items, NextCursor: encodeCursor(cursor)})
+}
+
+func (h *Handler) ListGames(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseListFilter(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListGames error:", err)
+		return
+	}
+
+	if filter.EventID, err = h.activeEvent(r); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListGames error:", err)
+		return
+	}
+
+	var list domain.ListGames
+	cursor, err := h.queuesService.GetAllGames(r.Context(), filter, &list)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListGames error:", err)
+		return
+	}
+
+	writePage(w, list, cursor)
+}
+
+func (h *Handler) ListPlayers(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("ListPlayers error:", err)
+		return
+	}
+
+	filter, err := parseListFilter(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListPlayers error:", err)
+		return
+	}
+
+	var list domain.ListQueueEntries
+	cursor, err := h.queuesService.GetPlayersByGameID(r.Context(), id, filter, &list)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListPlayers error:", err)
+		return
+	}
+
+	writePage(w, list, cursor)
+}
+
+func (h *Handler) ListUserGames(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseListFilter(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListUserGames error:", err)
+		return
+	}
+
+	if filter.EventID, err = h.activeEvent(r); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListUserGames error:", err)
+		return
+	}
+
+	var list domain.ListGameInfos
+	cursor, err := h.queuesService.GetGamesByLogin(r.Context(), mux.Vars(r)["login"], filter, &list)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListUserGames error:", err)
+		return
+	}
+
+	writePage(w, list, cursor)
+}
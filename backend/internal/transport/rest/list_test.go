@@ -0,0 +1,47 @@
+package rest
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+)
+
+func TestParseListFilterCursor(t *testing.T) {
+	valid := encodeCursor(&domain.Cursor{Sort: "position", Key: "3", ID: 7})
+
+	tests := []struct {
+		name   string
+		query  url.Values
+		failed bool
+	}{
+		{"no cursor", url.Values{}, false},
+		{"valid", url.Values{"cursor": {valid}}, false},
+		{"valid with sort", url.Values{"cursor": {valid}, "sort": {"position"}}, false},
+		{"other sort", url.Values{"cursor": {valid}, "sort": {"-login"}}, true},
+		{"not base64", url.Values{"cursor": {"%%%"}}, true},
+		{"not json", url.Values{"cursor": {base64.RawURLEncoding.EncodeToString([]byte("nope"))}}, true},
+		{"no sort", url.Values{"cursor": {base64.RawURLEncoding.EncodeToString([]byte(`{"k":"3","i":7}`))}}, true},
+		{"negative id", url.Values{"cursor": {base64.RawURLEncoding.EncodeToString([]byte(`{"s":"position","k":"3","i":-1}`))}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/games?"+tt.query.Encode(), nil)
+			_, err := parseListFilter(r)
+			if tt.failed && !errors.Is(err, e.ErrInvalidParams) {
+				t.Fatalf("err = %v, want ErrInvalidParams", err)
+			}
+			if !tt.failed && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err != nil && errorStatus(err) != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400", errorStatus(err))
+			}
+		})
+	}
+}
@@ -1,29 +1,166 @@
 package rest
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	e "github.com/DexScen/Queue/backend/internal/errors"
+	"github.com/DexScen/Queue/backend/pkg/requestid"
+	"github.com/gorilla/mux"
 )
 
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("%s: [%s] - %s ", time.Now().Format(time.RFC3339), r.Method, r.RequestURI)
+		log.Printf("%s: [%s] - %s %s", time.Now().Format(time.RFC3339), r.Method, r.RequestURI, requestid.From(r.Context()))
 		next.ServeHTTP(w, r)
 	})
 }
 
+const requestIDHeader = "X-Request-ID"
+
+// requestIDMiddleware tags every request with an id of its own, echoed in
+// the response and recorded in the audit log. A well-formed id sent by the
+// client or a proxy cannot be trusted to be unique, so it is only recorded
+// next to ours, letting a request still be followed across services.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := requestid.New()
+		ctx := requestid.With(r.Context(), id)
+		if client := r.Header.Get(requestIDHeader); validRequestID(client) {
+			ctx = requestid.WithClient(ctx, client)
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
 func corsMiddleware(next http.Handler) http.Handler {
-    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-        w.Header().Set("Access-Control-Allow-Origin", "*")
-        w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-        w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
-
-        if r.Method == http.MethodOptions {
-            w.WriteHeader(http.StatusOK)
-            return
-        }
-
-        next.ServeHTTP(w, r)
-    })
-}
\ No newline at end of file
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, "+requestIDHeader+", "+eventHeader)
+		w.Header().Set("Access-Control-Expose-Headers", requestIDHeader+", Retry-After")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// deprecated marks a legacy route and points clients at its /api/v1 successor.
+func deprecated(successor string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+apiPrefix+successor+`>; rel="successor-version"`)
+		next.ServeHTTP(w, r)
+	}
+}
+
+type ctxKey int
+
+const actorKey ctxKey = iota
+
+// requireRole lets the request through only if the caller identified by
+// their session token has one of the given roles. The caller is then
+// available to the handler through actorFrom.
+func (h *Handler) requireRole(next http.HandlerFunc, roles ...string) http.HandlerFunc {
+	return h.authorize(next, func(r *http.Request, user *domain.User) (bool, error) {
+		return slices.Contains(roles, user.Role), nil
+	})
+}
+
+// requireEventRole is requireRole that also admits a caller holding one
+// of the roles in the event of the game ({id}) or event ({event_id}) the
+// route addresses, so that an event's own staff can run its stands
+// without rights on any other event.
+func (h *Handler) requireEventRole(next http.HandlerFunc, roles ...string) http.HandlerFunc {
+	return h.authorize(next, func(r *http.Request, user *domain.User) (bool, error) {
+		if slices.Contains(roles, user.Role) {
+			return true, nil
+		}
+
+		var role string
+		vars := mux.Vars(r)
+		if eventID, err := strconv.Atoi(vars["event_id"]); err == nil {
+			if role, err = h.queuesService.EventRole(r.Context(), eventID, user.ID); err != nil {
+				return false, err
+			}
+		} else if gameID, err := strconv.Atoi(vars["id"]); err == nil {
+			if role, err = h.queuesService.GameEventRole(r.Context(), gameID, user.ID); err != nil {
+				return false, err
+			}
+		}
+		return slices.Contains(roles, role), nil
+	})
+}
+
+// authorize resolves the caller from their session token and lets the
+// request through if allowed says so.
+func (h *Handler) authorize(next http.HandlerFunc, allowed func(r *http.Request, user *domain.User) (bool, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := h.authenticate(r)
+		if err != nil {
+			w.WriteHeader(errorStatus(err))
+			if !errors.Is(err, e.ErrUnauthorized) {
+				log.Println("requireRole error:", err)
+			}
+			return
+		}
+
+		ok, err := allowed(r, user)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			log.Println("requireRole error:", err)
+			return
+		}
+		if !ok {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), actorKey, user)))
+	}
+}
+
+// authenticate resolves the caller from the bearer token in the
+// Authorization header. Requests without one are anonymous and fail
+// with ErrUnauthorized.
+func (h *Handler) authenticate(r *http.Request) (*domain.User, error) {
+	token, ok := bearerToken(r)
+	if !ok {
+		return nil, e.ErrUnauthorized
+	}
+	return h.queuesService.Authenticate(r.Context(), token)
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return token, ok && token != ""
+}
+
+func actorFrom(ctx context.Context) *domain.User {
+	user, _ := ctx.Value(actorKey).(*domain.User)
+	return user
+}
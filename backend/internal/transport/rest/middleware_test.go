@@ -0,0 +1,46 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DexScen/Queue/backend/pkg/requestid"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		client string
+	}{
+		{"none", "", ""},
+		{"client id kept aside", "trace-42", "trace-42"},
+		{"malformed client id", "bad id", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var id, client string
+			h := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id, client = requestid.From(r.Context()), requestid.ClientFrom(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				r.Header.Set(requestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if len(id) != 32 || id == tt.header {
+				t.Fatalf("request id = %q, want a generated one", id)
+			}
+			if got := w.Header().Get(requestIDHeader); got != id {
+				t.Fatalf("response id = %q, want %q", got, id)
+			}
+			if client != tt.client {
+				t.Fatalf("client id = %q, want %q", client, tt.client)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/DexScen/Queue/backend/internal/importer"
+)
+
+// schemaTypes lists the domain types exposed in the OpenAPI components.
+// Route request/response names must refer to an entry here.
+var schemaTypes = map[string]any{
+	"Game":       domain.Game{},
+	"GameInfo":   domain.GameInfo{},
+	"User":       domain.User{},
+	"LoginInfo":  domain.LoginInfo{},
+	"RoleInfo":   domain.RoleInfo{},
+	"ChangeInfo": domain.ChangeInfo{},
+	"PosInfo":    domain.PosInfo{},
+	"IdInfo":     domain.IdInfo{},
+
+	"GamePage":       domain.Page[domain.Game]{},
+	"GameInfoPage":   domain.Page[domain.GameInfo]{},
+	"QueueEntry":     domain.QueueEntry{},
+	"QueueEntryPage": domain.Page[domain.QueueEntry]{},
+	"QueueUpdate":    domain.QueueUpdate{},
+	"MoveInfo":       domain.MoveInfo{},
+	"SwapInfo":       domain.SwapInfo{},
+	"InsertInfo":     domain.InsertInfo{},
+	"NoShowPolicy":   domain.NoShowPolicy{},
+	"CheckinInfo":    domain.CheckinInfo{},
+	"TicketInfo":     domain.TicketInfo{},
+	"Ticket":         domain.Ticket{},
+	"ClaimInfo":      domain.ClaimInfo{},
+	"Board":          domain.Board{},
+	"Party":          domain.Party{},
+	"PartyInfo":      domain.PartyInfo{},
+	"PartyInvite":    domain.PartyInvite{},
+	"PartyCode":      domain.PartyCode{},
+	"PartyQueueInfo": domain.PartyQueueInfo{},
+	"PriorityInfo":   domain.PriorityInfo{},
+	"PriorityPolicy": domain.PriorityPolicy{},
+
+	"SchedulingPolicy": domain.SchedulingPolicy{},
+	"GroupInfo":        domain.GroupInfo{},
+	"Station":          domain.Station{},
+	"StationInfo":      domain.StationInfo{},
+	"Hours":            domain.Hours{},
+	"HoursStatus":      domain.HoursStatus{},
+
+	"Reservation":       domain.Reservation{},
+	"ReservationSlot":   domain.ReservationSlot{},
+	"ReservationInfo":   domain.ReservationInfo{},
+	"ReservationPolicy": domain.ReservationPolicy{},
+
+	"HistoryEntryPage": domain.Page[domain.HistoryEntry]{},
+	"QueueEventPage":   domain.Page[domain.QueueEvent]{},
+	"AuditRecordPage":  domain.Page[domain.AuditRecord]{},
+	"AuditRecord":      domain.AuditRecord{},
+	"GameStats":        domain.GameStats{},
+	"UserRecord":       domain.UserRecord{},
+	"QueueEvent":       domain.QueueEvent{},
+	"GameImport":       domain.GameImport{},
+	"UserImport":       domain.UserImport{},
+	"ImportResult":     domain.ImportResult{},
+	"Event":            domain.Event{},
+	"EventMember":      domain.EventMember{},
+	"EventAssign":      domain.EventAssign{},
+	"ResultInfo":       domain.ResultInfo{},
+	"ScoringPolicy":    domain.ScoringPolicy{},
+	"Leaderboard":      domain.Leaderboard{},
+	"RatingInfo":       domain.RatingInfo{},
+	"RatingPage":       domain.Page[domain.Rating]{},
+	"Ban":              domain.Ban{},
+	"BanInfo":          domain.BanInfo{},
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// buildOpenAPI renders an OpenAPI 3 document from the route table.
+// It fails on a route that references an unknown schema so that a
+// mismatch is caught when the router is built.
+func buildOpenAPI(routes []route) (map[string]any, error) {
+	paths := map[string]any{}
+	for _, rt := range routes {
+		item, ok := paths[apiPrefix+rt.path].(map[string]any)
+		if !ok {
+			item = map[string]any{}
+			paths[apiPrefix+rt.path] = item
+		}
+
+		op := map[string]any{
+			"summary":     rt.summary,
+			"operationId": strings.ToLower(rt.method) + operationName(rt.path),
+			"tags":        []string{rt.tag},
+		}
+
+		var params []any
+		for _, m := range pathParam.FindAllStringSubmatch(rt.path, -1) {
+			typ := "string"
+			if m[1] == "id" || strings.HasSuffix(m[1], "_id") {
+				typ = "integer"
+			}
+			params = append(params, map[string]any{
+				"name":     m[1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]any{"type": typ},
+			})
+		}
+		for _, name := range rt.query {
+			params = append(params, map[string]any{
+				"name":   name,
+				"in":     "query",
+				"schema": queryParamSchema(rt, name),
+			})
+		}
+		if params != nil {
+			op["parameters"] = params
+		}
+
+		if name, ok := strings.CutPrefix(rt.request, "import:"); ok {
+			ref, err := schemaRef("[]" + name)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", rt.method, rt.path, err)
+			}
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{
+					"text/csv":         map[string]any{"schema": map[string]any{"type": "string"}},
+					"application/json": map[string]any{"schema": ref},
+				},
+			}
+		} else if rt.request != "" {
+			ref, err := schemaRef(rt.request)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", rt.method, rt.path, err)
+			}
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": ref},
+				},
+			}
+		}
+
+		resp := map[string]any{"description": http.StatusText(rt.status)}
+		if rt.response == "image" {
+			binary := map[string]any{"schema": map[string]any{"type": "string", "format": "binary"}}
+			resp["content"] = map[string]any{"image/png": binary, "image/svg+xml": binary}
+		} else if name, ok := strings.CutPrefix(rt.response, "export:"); ok {
+			ref, err := schemaRef(name)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", rt.method, rt.path, err)
+			}
+			resp["content"] = map[string]any{
+				"text/csv":             map[string]any{"schema": map[string]any{"type": "string"}},
+				"application/x-ndjson": map[string]any{"schema": ref},
+			}
+		} else if name, ok := strings.CutPrefix(rt.response, "sse:"); ok {
+			ref, err := schemaRef(name)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", rt.method, rt.path, err)
+			}
+			resp["content"] = map[string]any{
+				"text/event-stream": map[string]any{"schema": ref},
+			}
+		} else if rt.response != "" {
+			ref, err := schemaRef(rt.response)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", rt.method, rt.path, err)
+			}
+			resp["content"] = map[string]any{
+				"application/json": map[string]any{"schema": ref},
+			}
+		}
+		op["responses"] = map[string]any{
+			strconv.Itoa(rt.status): resp,
+			"default":               map[string]any{"description": "Error"},
+		}
+
+		item[strings.ToLower(rt.method)] = op
+	}
+
+	schemas := map[string]any{}
+	for name, v := range schemaTypes {
+		schemas[name] = schemaOf(reflect.TypeOf(v))
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Queue API",
+			"version": "1.0.0",
+		},
+		"servers": []any{map[string]any{"url": apiPrefix}},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"session": map[string]any{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "token returned by /auth/login",
+				},
+			},
+		},
+		"security": []any{map[string]any{"session": []string{}}},
+	}, nil
+}
+
+func schemaRef(name string) (map[string]any, error) {
+	if elem, ok := strings.CutPrefix(name, "[]"); ok {
+		items, err := schemaRef(elem)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	}
+	if _, ok := schemaTypes[name]; !ok {
+		return nil, fmt.Errorf("openapi: unknown schema %q", name)
+	}
+	return map[string]any{"$ref": "#/components/schemas/" + name}, nil
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+func schemaOf(t reflect.Type) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		s := schemaOf(t.Elem())
+		s["nullable"] = true
+		return s
+	}
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	if t == rawType {
+		return map[string]any{"type": "object"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(t.Elem())}
+	case reflect.Struct:
+		props := map[string]any{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" || !f.IsExported() {
+				continue
+			}
+			if name == "" && f.Anonymous && f.Type.Kind() == reflect.Struct {
+				// embedded structs are flattened by encoding/json
+				for k, v := range schemaOf(f.Type)["properties"].(map[string]any) {
+					props[k] = v
+				}
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			props[name] = schemaOf(f.Type)
+			if doc := f.Tag.Get("doc"); doc != "" {
+				props[name].(map[string]any)["description"] = doc
+			}
+		}
+		return map[string]any{"type": "object", "properties": props}
+	}
+	return map[string]any{}
+}
+
+func queryParamSchema(rt route, name string) map[string]any {
+	switch name {
+	case "limit":
+		return map[string]any{"type": "integer", "minimum": 1, "maximum": maxPageSize, "default": defaultPageSize}
+	case "top":
+		return map[string]any{"type": "integer", "minimum": 1, "maximum": maxLeaderboardTop, "default": defaultLeaderboardTop}
+	case "next":
+		return map[string]any{"type": "integer", "minimum": 0, "maximum": maxBoardNext, "default": defaultBoardNext}
+	case "format":
+		if strings.HasPrefix(rt.response, "export:") {
+			return map[string]any{"type": "string", "enum": []string{exportCSV, exportNDJSON}, "default": exportCSV}
+		}
+		if strings.HasPrefix(rt.request, "import:") {
+			return map[string]any{"type": "string", "enum": []string{importer.FormatCSV, importer.FormatJSON}}
+		}
+		return map[string]any{"type": "string", "enum": []string{"png", "svg"}, "default": "png"}
+	case "joined_after", "from", "to":
+		return map[string]any{"type": "string", "format": "date-time"}
+	case "game_id", "target_id":
+		return map[string]any{"type": "integer"}
+	case "event_id":
+		return map[string]any{"type": "string", "description": "event id, or all; defaults to the active event"}
+	case "dry_run":
+		return map[string]any{"type": "boolean", "default": false}
+	case "bucket":
+		return map[string]any{"type": "string", "enum": []string{domain.BucketHour, domain.BucketDay}}
+	case "date":
+		return map[string]any{"type": "string", "format": "date"}
+	}
+	return map[string]any{"type": "string"}
+}
+
+func operationName(path string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(path, "/") {
+		part = strings.Trim(part, "{}")
+		for _, word := range strings.Split(part, "_") {
+			if word == "" {
+				continue
+			}
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
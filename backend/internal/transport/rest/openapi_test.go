@@ -0,0 +1,94 @@
+package rest
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/gorilla/mux"
+)
+
+// TestOpenAPIMatchesRouter checks that every versioned route mounted on
+// the router is documented and that the document lists nothing else.
+func TestOpenAPIMatchesRouter(t *testing.T) {
+	h := NewQueues(nil)
+	r, err := h.InitRouter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mounted := map[string]bool{}
+	err = r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil || !strings.HasPrefix(path, apiPrefix+"/") || path == apiPrefix+"/openapi.json" {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, m := range methods {
+			mounted[strings.ToLower(m)+" "+path] = true
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	documented := map[string]bool{}
+	for path, item := range h.spec["paths"].(map[string]any) {
+		for method := range item.(map[string]any) {
+			documented[method+" "+path] = true
+		}
+	}
+
+	for op := range mounted {
+		if !documented[op] {
+			t.Errorf("%s is mounted but not documented", op)
+		}
+	}
+	for op := range documented {
+		if !mounted[op] {
+			t.Errorf("%s is documented but not mounted", op)
+		}
+	}
+	if len(mounted) == 0 {
+		t.Fatal("no routes mounted")
+	}
+}
+
+func TestOpenAPIUnknownSchema(t *testing.T) {
+	tests := []struct {
+		name string
+		rt   route
+	}{
+		{"request", route{method: http.MethodPost, path: "/x", request: "Nope", status: http.StatusOK}},
+		{"import", route{method: http.MethodPost, path: "/x", request: "import:Nope", status: http.StatusOK}},
+		{"response", route{method: http.MethodGet, path: "/x", response: "Nope", status: http.StatusOK}},
+		{"array", route{method: http.MethodGet, path: "/x", response: "[]Nope", status: http.StatusOK}},
+		{"stream", route{method: http.MethodGet, path: "/x", response: "sse:Nope", status: http.StatusOK}},
+		{"export", route{method: http.MethodGet, path: "/x", response: "export:Nope", status: http.StatusOK}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := buildOpenAPI([]route{tt.rt}); err == nil {
+				t.Fatal("expected an error for an unknown schema")
+			}
+		})
+	}
+}
+
+func TestOpenAPIFieldDocs(t *testing.T) {
+	props := schemaOf(reflect.TypeOf(domain.QueueEntry{}))["properties"].(map[string]any)
+	for _, name := range []string{"position", "estimated_start", "estimated"} {
+		if props[name].(map[string]any)["description"] == nil {
+			t.Errorf("%s has no description", name)
+		}
+	}
+	if props["estimated_start"].(map[string]any)["nullable"] != true {
+		t.Error("estimated_start lost nullable")
+	}
+}
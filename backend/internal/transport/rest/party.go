@@ -0,0 +1,197 @@
+package rest
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) CreateParty(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("CreateParty error:", err)
+		return
+	}
+
+	var info domain.PartyInfo
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("CreateParty error:", err)
+		return
+	}
+
+	party, err := h.queuesService.CreateParty(r.Context(), userID, info.Name)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("CreateParty error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, party)
+}
+
+func (h *Handler) GetParty(w http.ResponseWriter, r *http.Request) {
+	partyID, err := strconv.Atoi(mux.Vars(r)["party_id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("GetParty error:", err)
+		return
+	}
+
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("GetParty error:", err)
+		return
+	}
+
+	party, err := h.queuesService.GetParty(r.Context(), userID, partyID)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("GetParty error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, party)
+}
+
+func (h *Handler) InviteToParty(w http.ResponseWriter, r *http.Request) {
+	partyID, err := strconv.Atoi(mux.Vars(r)["party_id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("InviteToParty error:", err)
+		return
+	}
+
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("InviteToParty error:", err)
+		return
+	}
+
+	var invite domain.PartyInvite
+	if err := json.NewDecoder(r.Body).Decode(&invite); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("InviteToParty error:", err)
+		return
+	}
+
+	if err := h.queuesService.InviteToParty(r.Context(), userID, partyID, invite.Login); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("InviteToParty error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) JoinParty(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("JoinParty error:", err)
+		return
+	}
+
+	var code domain.PartyCode
+	if err := json.NewDecoder(r.Body).Decode(&code); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("JoinParty error:", err)
+		return
+	}
+
+	party, err := h.queuesService.JoinPartyByCode(r.Context(), userID, code.Code)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("JoinParty error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, party)
+}
+
+func (h *Handler) AcceptPartyInvite(w http.ResponseWriter, r *http.Request) {
+	partyID, err := strconv.Atoi(mux.Vars(r)["party_id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("AcceptPartyInvite error:", err)
+		return
+	}
+
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("AcceptPartyInvite error:", err)
+		return
+	}
+
+	if err := h.queuesService.AcceptPartyInvite(r.Context(), userID, partyID); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("AcceptPartyInvite error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) LeaveParty(w http.ResponseWriter, r *http.Request) {
+	partyID, err := strconv.Atoi(mux.Vars(r)["party_id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("LeaveParty error:", err)
+		return
+	}
+
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("LeaveParty error:", err)
+		return
+	}
+
+	if err := h.queuesService.LeaveParty(r.Context(), userID, partyID); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("LeaveParty error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) JoinQueueAsParty(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("JoinQueueAsParty error:", err)
+		return
+	}
+
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("JoinQueueAsParty error:", err)
+		return
+	}
+
+	var info domain.PartyQueueInfo
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("JoinQueueAsParty error:", err)
+		return
+	}
+
+	position, err := h.queuesService.AddPartyToQueue(r.Context(), userID, info.PartyID, gameID)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("JoinQueueAsParty error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, domain.PosInfo{Pos: position})
+}
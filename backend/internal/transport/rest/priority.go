@@ -0,0 +1,95 @@
+package rest
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) SetEntryPriority(w http.ResponseWriter, r *http.Request) {
+	gameID, entryID, err := entryVars(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetEntryPriority error:", err)
+		return
+	}
+
+	var info domain.PriorityInfo
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetEntryPriority error:", err)
+		return
+	}
+
+	position, err := h.queuesService.SetEntryPriority(r.Context(), actorFrom(r.Context()).ID, gameID, entryID, info.Priority)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("SetEntryPriority error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, domain.PosInfo{Pos: position})
+}
+
+func (h *Handler) SetUserPriority(w http.ResponseWriter, r *http.Request) {
+	var info domain.PriorityInfo
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetUserPriority error:", err)
+		return
+	}
+
+	if err := h.queuesService.SetUserPriority(r.Context(), actorFrom(r.Context()).ID, mux.Vars(r)["login"], info.Priority); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("SetUserPriority error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) GetPriorityPolicy(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("GetPriorityPolicy error:", err)
+		return
+	}
+
+	policy, err := h.queuesService.GetPriorityPolicy(r.Context(), gameID)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("GetPriorityPolicy error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, policy)
+}
+
+func (h *Handler) SetPriorityPolicy(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetPriorityPolicy error:", err)
+		return
+	}
+
+	var policy domain.PriorityPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetPriorityPolicy error:", err)
+		return
+	}
+
+	if err := h.queuesService.SetPriorityPolicy(r.Context(), actorFrom(r.Context()).ID, gameID, policy); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("SetPriorityPolicy error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,196 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/DexScen/Queue/backend/internal/ratelimit"
+)
+
+// Route groups sharing a rate limit.
+const (
+	limitAuth  = "auth"
+	limitJoin  = "join"
+	limitWrite = "write"
+	limitRead  = "read"
+)
+
+// DefaultRateLimits are the limits of every route group, per client IP,
+// per user and, for sign-ins, per login alike.
+var DefaultRateLimits = map[string]ratelimit.Limit{
+	limitAuth:  {Requests: 10, Per: time.Minute},
+	limitJoin:  {Requests: 20, Per: time.Minute},
+	limitWrite: {Requests: 120, Per: time.Minute},
+	limitRead:  {Requests: 600, Per: time.Minute},
+}
+
+// maxLoginPeek bounds how much of a sign-in body is read to find the login.
+const maxLoginPeek = 4 << 10
+
+// RateLimiter throttles requests with a token bucket per route group and
+// client IP, another per route group and user when the caller has a
+// session, and one per login for sign-ins.
+type RateLimiter struct {
+	store   ratelimit.Store
+	limits  map[string]ratelimit.Limit
+	trusted []netip.Prefix
+}
+
+// NewRateLimiter limits the route groups in limits; a group left out or
+// limited to zero requests is not throttled. X-Forwarded-For is only
+// believed when the connection comes from one of the trusted proxies.
+func NewRateLimiter(store ratelimit.Store, limits map[string]ratelimit.Limit, trusted []netip.Prefix) *RateLimiter {
+	return &RateLimiter{store: store, limits: limits, trusted: trusted}
+}
+
+// ParseTrustedProxies reads a comma separated list of addresses and
+// CIDR ranges.
+func ParseTrustedProxies(s string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !strings.Contains(part, "/") {
+			addr, err := netip.ParseAddr(part)
+			if err != nil {
+				return nil, fmt.Errorf("trusted proxy %q: %w", part, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(part)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", part, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// limitGroup tells the rate limit group of a route: logins and sign-ups,
+// joining a queue, other changes and reads.
+func limitGroup(method, path string) string {
+	switch {
+	case path == "/auth/login" || path == "/auth/register":
+		return limitAuth
+	case method == http.MethodPost && (path == "/games/{id}/queue" || path == "/games/{id}/queue/party"):
+		return limitJoin
+	case method == http.MethodGet:
+		return limitRead
+	}
+	return limitWrite
+}
+
+// limit wraps the handler of a route with the limit of its group. A
+// failing store lets requests through rather than take the API down.
+func (h *Handler) limit(method, path string, next http.HandlerFunc) http.HandlerFunc {
+	if h.limiter == nil {
+		return next
+	}
+	group := limitGroup(method, path)
+	limit, ok := h.limiter.limits[group]
+	if !ok || limit.Requests == 0 {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys := []string{group + ":ip:" + h.limiter.clientIP(r)}
+		if token, ok := bearerToken(r); ok {
+			if userID, err := h.queuesService.SessionUserID(token); err == nil {
+				keys = append(keys, group+":user:"+strconv.Itoa(userID))
+			}
+		}
+		// guessing one account's password from many addresses still
+		// drains that account's bucket
+		if path == "/auth/login" {
+			if login := submittedLogin(r); login != "" {
+				keys = append(keys, group+":login:"+login)
+			}
+		}
+
+		for _, key := range keys {
+			retryAfter, ok, err := h.limiter.store.Allow(r.Context(), key, limit)
+			if err != nil {
+				log.Println("rate limit error:", err)
+				break
+			}
+			if !ok {
+				seconds := max(int(math.Ceil(retryAfter.Seconds())), 1)
+				w.Header().Set("Retry-After", strconv.Itoa(seconds))
+				w.WriteHeader(http.StatusTooManyRequests)
+				log.Println("rate limited:", key)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
+
+// submittedLogin reads the login a sign-in is for and puts the body back
+// for the handler. It returns "" when the body is not a login.
+func submittedLogin(r *http.Request) string {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxLoginPeek))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	if err != nil {
+		return ""
+	}
+
+	var info domain.LoginInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return ""
+	}
+	return info.Login
+}
+
+// clientIP is the address the request came from. Behind trusted proxies
+// it is the last X-Forwarded-For hop not added by one of them; the hops
+// before that are whatever the client chose to send.
+func (l *RateLimiter) clientIP(r *http.Request) string {
+	addrPort, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	ip := addrPort.Addr().Unmap()
+	if !l.trustedProxy(ip) {
+		return ip.String()
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		ip = hop.Unmap()
+		if !l.trustedProxy(ip) {
+			break
+		}
+	}
+	return ip.String()
+}
+
+func (l *RateLimiter) trustedProxy(ip netip.Addr) bool {
+	for _, prefix := range l.trusted {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package rest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	e "github.com/DexScen/Queue/backend/internal/errors"
+	"github.com/DexScen/Queue/backend/internal/ratelimit"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want []string
+		ok   bool
+	}{
+		{"empty", "", nil, true},
+		{"address", "10.0.0.1", []string{"10.0.0.1/32"}, true},
+		{"mapped address", "::ffff:10.0.0.1", []string{"10.0.0.1/32"}, true},
+		{"ipv6 address", "fd00::1", []string{"fd00::1/128"}, true},
+		{"ranges are masked", "172.16.5.4/12, 10.0.0.0/8", []string{"172.16.0.0/12", "10.0.0.0/8"}, true},
+		{"bad address", "10.0.0.300", nil, false},
+		{"bad range", "10.0.0.0/40", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefixes, err := ParseTrustedProxies(tt.s)
+			if tt.ok != (err == nil) {
+				t.Fatalf("err = %v, want ok %v", err, tt.ok)
+			}
+			var got []string
+			for _, p := range prefixes {
+				got = append(got, p.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("prefixes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	l := NewRateLimiter(nil, nil, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")})
+
+	tests := []struct {
+		name   string
+		remote string
+		xff    string
+		want   string
+	}{
+		{"direct", "203.0.113.5:1234", "", "203.0.113.5"},
+		{"untrusted proxy is not believed", "203.0.113.5:1234", "198.51.100.7", "203.0.113.5"},
+		{"trusted proxy", "10.0.0.1:1234", "198.51.100.7", "198.51.100.7"},
+		{"trusted proxy without header", "10.0.0.1:1234", "", "10.0.0.1"},
+		{"chain of trusted proxies", "10.0.0.1:1234", "198.51.100.7, 10.0.0.2, 10.0.0.3", "198.51.100.7"},
+		{"hops sent by the client are ignored", "10.0.0.1:1234", "6.6.6.6, 198.51.100.7", "198.51.100.7"},
+		{"untrusted hop in the chain", "10.0.0.1:1234", "198.51.100.7, 203.0.113.9, 10.0.0.2", "203.0.113.9"},
+		{"only trusted hops", "10.0.0.1:1234", "10.0.0.2", "10.0.0.2"},
+		{"garbage from the client", "10.0.0.1:1234", "bogus, 198.51.100.7", "198.51.100.7"},
+		{"garbage from a proxy", "10.0.0.1:1234", "198.51.100.7, bogus", "10.0.0.1"},
+		{"mapped address", "[::ffff:10.0.0.1]:1234", "198.51.100.7", "198.51.100.7"},
+		{"no port", "pipe", "198.51.100.7", "pipe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+			if tt.xff != "" {
+				r.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if got := l.clientIP(r); got != tt.want {
+				t.Fatalf("client ip = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// sessionService knows the session token "token-7" of user 7.
+type sessionService struct {
+	Queues
+}
+
+func (sessionService) SessionUserID(token string) (int, error) {
+	if token != "token-7" {
+		return 0, e.ErrUnauthorized
+	}
+	return 7, nil
+}
+
+func TestLimitKeys(t *testing.T) {
+	type request struct {
+		remote string
+		token  string
+		header string // X-User-Login, which must not matter
+		login  string // of a sign-in
+		status int
+	}
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		requests []request
+	}{
+		{"session user from two addresses", http.MethodPut, "/games/{id}", []request{
+			{remote: "203.0.113.1:1", token: "token-7", status: http.StatusOK},
+			{remote: "203.0.113.2:1", token: "token-7", status: http.StatusTooManyRequests},
+		}},
+		{"invalid token is anonymous", http.MethodPut, "/games/{id}", []request{
+			{remote: "203.0.113.1:1", token: "forged", status: http.StatusOK},
+			{remote: "203.0.113.2:1", token: "forged", status: http.StatusOK},
+		}},
+		{"login header is ignored", http.MethodPut, "/games/{id}", []request{
+			{remote: "203.0.113.1:1", header: "alice", status: http.StatusOK},
+			{remote: "203.0.113.2:1", header: "alice", status: http.StatusOK},
+		}},
+		{"same address", http.MethodPut, "/games/{id}", []request{
+			{remote: "203.0.113.1:1", status: http.StatusOK},
+			{remote: "203.0.113.1:2", status: http.StatusTooManyRequests},
+		}},
+		{"one login from two addresses", http.MethodPost, "/auth/login", []request{
+			{remote: "203.0.113.1:1", login: "alice", status: http.StatusOK},
+			{remote: "203.0.113.2:1", login: "alice", status: http.StatusTooManyRequests},
+		}},
+		{"two logins", http.MethodPost, "/auth/login", []request{
+			{remote: "203.0.113.1:1", login: "alice", status: http.StatusOK},
+			{remote: "203.0.113.2:1", login: "bob", status: http.StatusOK},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			one := ratelimit.Limit{Requests: 1, Per: time.Hour}
+			h := &Handler{
+				queuesService: sessionService{},
+				limiter: NewRateLimiter(ratelimit.NewMemoryStore(), map[string]ratelimit.Limit{
+					limitAuth:  one,
+					limitWrite: one,
+				}, nil),
+			}
+
+			var bodies []string
+			handler := h.limit(tt.method, tt.path, func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Error(err)
+				}
+				bodies = append(bodies, string(body))
+			})
+
+			var sent []string
+			for i, req := range tt.requests {
+				var body string
+				if req.login != "" {
+					body = `{"login":"` + req.login + `","password":"secret"}`
+				}
+				r := httptest.NewRequest(tt.method, "/", strings.NewReader(body))
+				r.RemoteAddr = req.remote
+				if req.token != "" {
+					r.Header.Set("Authorization", "Bearer "+req.token)
+				}
+				if req.header != "" {
+					r.Header.Set("X-User-Login", req.header)
+				}
+				w := httptest.NewRecorder()
+				handler(w, r)
+
+				if w.Code != req.status {
+					t.Fatalf("request %d: status %d, want %d", i, w.Code, req.status)
+				}
+				if w.Code == http.StatusOK {
+					sent = append(sent, body)
+				}
+			}
+			// the handler reads the sign-in body in full
+			if !slices.Equal(bodies, sent) {
+				t.Fatalf("handler read %q, want %q", bodies, sent)
+			}
+		})
+	}
+}
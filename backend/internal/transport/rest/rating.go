@@ -0,0 +1,102 @@
+package rest
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/gorilla/mux"
+)
+
+var ratingParams = []string{"limit", "cursor", "sort"}
+
+func (h *Handler) RateGame(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("RateGame error:", err)
+		return
+	}
+
+	var info domain.RatingInfo
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("RateGame error:", err)
+		return
+	}
+
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("RateGame error:", err)
+		return
+	}
+
+	ratingID, err := h.queuesService.RateGame(r.Context(), userID, gameID, info)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("RateGame error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, domain.IdInfo{Id: ratingID})
+}
+
+// listRatings returns the handler of the public rating list, where hidden
+// comments are blanked, or of the moderation list showing them.
+func (h *Handler) listRatings(withHidden bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Println("ListRatings error:", err)
+			return
+		}
+
+		filter, err := parseListFilter(r)
+		if err != nil {
+			w.WriteHeader(errorStatus(err))
+			log.Println("ListRatings error:", err)
+			return
+		}
+
+		var list domain.ListRatings
+		cursor, err := h.queuesService.GetRatings(r.Context(), gameID, withHidden, filter, &list)
+		if err != nil {
+			w.WriteHeader(errorStatus(err))
+			log.Println("ListRatings error:", err)
+			return
+		}
+
+		writePage(w, list, cursor)
+	}
+}
+
+// setRatingHidden returns the handler of the hide or unhide endpoint.
+func (h *Handler) setRatingHidden(hidden bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		gameID, err := strconv.Atoi(vars["id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Println("SetRatingHidden error:", err)
+			return
+		}
+		ratingID, err := strconv.Atoi(vars["rating_id"])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			log.Println("SetRatingHidden error:", err)
+			return
+		}
+
+		if err := h.queuesService.SetRatingHidden(r.Context(), actorFrom(r.Context()).ID, gameID, ratingID, hidden); err != nil {
+			w.WriteHeader(errorStatus(err))
+			log.Println("SetRatingHidden error:", err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,91 @@
+package rest
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) MoveEntry(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	gameID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("MoveEntry error:", err)
+		return
+	}
+	entryID, err := strconv.Atoi(vars["entry_id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("MoveEntry error:", err)
+		return
+	}
+
+	var info domain.MoveInfo
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("MoveEntry error:", err)
+		return
+	}
+
+	if err := h.queuesService.MoveEntry(r.Context(), actorFrom(r.Context()).ID, gameID, entryID, info.Position); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("MoveEntry error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) SwapEntries(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SwapEntries error:", err)
+		return
+	}
+
+	var info domain.SwapInfo
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SwapEntries error:", err)
+		return
+	}
+
+	if err := h.queuesService.SwapEntries(r.Context(), actorFrom(r.Context()).ID, gameID, info.EntryID, info.OtherEntryID); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("SwapEntries error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) InsertPlayer(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("InsertPlayer error:", err)
+		return
+	}
+
+	var info domain.InsertInfo
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("InsertPlayer error:", err)
+		return
+	}
+
+	position, err := h.queuesService.InsertPlayerAt(r.Context(), actorFrom(r.Context()).ID, gameID, info.Login, info.Position)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("InsertPlayer error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, domain.PosInfo{Pos: position})
+}
@@ -0,0 +1,184 @@
+package rest
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) ListSlots(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("ListSlots error:", err)
+		return
+	}
+
+	slots, err := h.queuesService.GetSlots(r.Context(), gameID, r.URL.Query().Get("date"))
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListSlots error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, slots)
+}
+
+func (h *Handler) ListReservations(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListReservations error:", err)
+		return
+	}
+
+	eventID, err := h.activeEvent(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListReservations error:", err)
+		return
+	}
+
+	reservations, err := h.queuesService.GetReservations(r.Context(), userID, eventID)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListReservations error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reservations)
+}
+
+func (h *Handler) BookReservation(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("BookReservation error:", err)
+		return
+	}
+
+	var info domain.ReservationInfo
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("BookReservation error:", err)
+		return
+	}
+
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("BookReservation error:", err)
+		return
+	}
+
+	reservation, err := h.queuesService.BookReservation(r.Context(), userID, gameID, info)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("BookReservation error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, reservation)
+}
+
+func (h *Handler) RescheduleReservation(w http.ResponseWriter, r *http.Request) {
+	reservationID, err := strconv.Atoi(mux.Vars(r)["reservation_id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("RescheduleReservation error:", err)
+		return
+	}
+
+	var info domain.ReservationInfo
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("RescheduleReservation error:", err)
+		return
+	}
+
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("RescheduleReservation error:", err)
+		return
+	}
+
+	reservation, err := h.queuesService.RescheduleReservation(r.Context(), userID, reservationID, info)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("RescheduleReservation error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reservation)
+}
+
+func (h *Handler) CancelReservation(w http.ResponseWriter, r *http.Request) {
+	reservationID, err := strconv.Atoi(mux.Vars(r)["reservation_id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("CancelReservation error:", err)
+		return
+	}
+
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("CancelReservation error:", err)
+		return
+	}
+
+	if err := h.queuesService.CancelReservation(r.Context(), userID, reservationID); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("CancelReservation error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) GetReservationPolicy(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("GetReservationPolicy error:", err)
+		return
+	}
+
+	policy, err := h.queuesService.GetReservationPolicy(r.Context(), gameID)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("GetReservationPolicy error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, policy)
+}
+
+func (h *Handler) SetReservationPolicy(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetReservationPolicy error:", err)
+		return
+	}
+
+	var policy domain.ReservationPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetReservationPolicy error:", err)
+		return
+	}
+
+	if err := h.queuesService.SetReservationPolicy(r.Context(), actorFrom(r.Context()).ID, gameID, policy); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("SetReservationPolicy error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
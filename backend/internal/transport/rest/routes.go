@@ -0,0 +1,169 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+const apiPrefix = "/api/v1"
+
+// route is a single versioned endpoint. The same table mounts handlers on
+// the router and builds the OpenAPI document, so the two cannot drift.
+type route struct {
+	method   string
+	path     string
+	summary  string
+	tag      string
+	request  string // schema name of the JSON body, "import:Name" for a CSV file or JSON array of Name, "" if none
+	response string // schema name of the success body, "[]Name" for arrays, "sse:Name" for event streams, "image" for PNG/SVG, "export:Name" for CSV or NDJSON rows of Name
+	status   int
+	handler  http.HandlerFunc
+	query    []string // documented query parameters
+}
+
+var listParams = []string{"limit", "cursor", "sort", "status", "joined_after", "q"}
+
+// scopedListParams are listParams of the lists scoped to an event.
+var scopedListParams = []string{"limit", "cursor", "sort", "status", "joined_after", "q", "event_id"}
+
+// gameListParams are the list parameters games support; they have no
+// status or join time of their own.
+var gameListParams = []string{"limit", "cursor", "sort", "q", "event_id"}
+
+var exportParams = []string{"format"}
+
+var staff = []string{domain.RoleOperator, domain.RoleAdmin}
+
+func (h *Handler) routes() []route {
+	return []route{
+		{http.MethodGet, "/events", "List events", "events", "", "[]Event", http.StatusOK, h.ListEvents, nil},
+		{http.MethodPost, "/events", "Create an event (admin)", "admin", "Event", "Event", http.StatusCreated, h.requireRole(h.CreateEvent, domain.RoleAdmin), nil},
+		{http.MethodGet, "/events/active", "The event lists are scoped to by default", "events", "", "Event", http.StatusOK, h.GetActiveEvent, nil},
+		{http.MethodGet, "/events/{event_id}", "Get an event", "events", "", "Event", http.StatusOK, h.GetEvent, nil},
+		{http.MethodPut, "/events/{event_id}", "Update an event (event admin)", "admin", "Event", "", http.StatusNoContent, h.requireEventRole(h.UpdateEvent, domain.RoleAdmin), nil},
+		{http.MethodPost, "/events/{event_id}/join", "Take part in an event", "events", "", "", http.StatusNoContent, h.JoinEvent, nil},
+		{http.MethodDelete, "/events/{event_id}/members/me", "Leave an event", "events", "", "", http.StatusNoContent, h.LeaveEvent, nil},
+		{http.MethodGet, "/events/{event_id}/members", "List the members of an event (event staff)", "operator", "", "[]EventMember", http.StatusOK, h.requireEventRole(h.ListEventMembers, staff...), nil},
+		{http.MethodPut, "/events/{event_id}/members/{login}", "Set the role of a user in an event (event admin)", "admin", "RoleInfo", "", http.StatusNoContent, h.requireEventRole(h.SetEventMember, domain.RoleAdmin), nil},
+
+		{http.MethodGet, "/games", "List games", "games", "", "GamePage", http.StatusOK, h.ListGames, gameListParams},
+		{http.MethodGet, "/games/{id}", "Get a game", "games", "", "Game", http.StatusOK, h.GetGameInfoByID, nil},
+		{http.MethodGet, "/games/{id}/hours", "Opening hours of a game and whether it takes players now", "games", "", "HoursStatus", http.StatusOK, h.GetHours, nil},
+		{http.MethodGet, "/games/{id}/players", "List queue entries of a game", "queue", "", "QueueEntryPage", http.StatusOK, h.ListPlayers, listParams},
+		{http.MethodPost, "/games/{id}/queue", "Join the queue of a game", "queue", "", "PosInfo", http.StatusCreated, h.JoinQueue, nil},
+		{http.MethodPost, "/games/{id}/queue/party", "Join the queue of a game as a party (leader)", "parties", "PartyQueueInfo", "PosInfo", http.StatusCreated, h.JoinQueueAsParty, nil},
+		{http.MethodDelete, "/games/{id}/queue/me", "Leave the queue of a game", "queue", "", "", http.StatusNoContent, h.LeaveQueue, nil},
+		{http.MethodGet, "/games/{id}/queue/me/checkin", "QR code with the caller's check-in token", "queue", "", "image", http.StatusOK, h.CheckinCode, []string{"format"}},
+		{http.MethodGet, "/games/{id}/slots", "Bookable time slots of a game on a date", "reservations", "", "[]ReservationSlot", http.StatusOK, h.ListSlots, []string{"date"}},
+		{http.MethodPost, "/games/{id}/reservations", "Book a time slot", "reservations", "ReservationInfo", "Reservation", http.StatusCreated, h.BookReservation, nil},
+		{http.MethodGet, "/games/{id}/stream", "Stream queue updates of a game", "queue", "", "sse:QueueUpdate", http.StatusOK, h.StreamQueue, nil},
+
+		{http.MethodPost, "/games/{id}/queue/{entry_id}/move", "Move an entry to a position (operator)", "operator", "MoveInfo", "", http.StatusNoContent, h.requireEventRole(h.MoveEntry, staff...), nil},
+		{http.MethodPost, "/games/{id}/queue/swap", "Swap two entries (operator)", "operator", "SwapInfo", "", http.StatusNoContent, h.requireEventRole(h.SwapEntries, staff...), nil},
+		{http.MethodPost, "/games/{id}/queue/call-next", "Call the next waiting player (operator)", "operator", "", "IdInfo", http.StatusOK, h.requireEventRole(h.CallNext, staff...), nil},
+		{http.MethodPost, "/games/{id}/stations/{station_id}/call-next", "Call the next waiting player to a station (operator)", "operator", "", "IdInfo", http.StatusOK, h.requireEventRole(h.CallNextToStation, staff...), nil},
+		{http.MethodPost, "/games/{id}/queue/{entry_id}/arrive", "Confirm a called player arrived (operator)", "operator", "", "", http.StatusNoContent, h.requireEventRole(h.MarkArrived, staff...), nil},
+		{http.MethodPost, "/games/{id}/queue/{entry_id}/finish", "Finish an active session, optionally with its score (operator)", "operator", "ResultInfo", "", http.StatusNoContent, h.requireEventRole(h.FinishEntry, staff...), nil},
+		{http.MethodPut, "/games/{id}/queue/{entry_id}/score", "Correct the score of a finished session (operator)", "operator", "ResultInfo", "", http.StatusNoContent, h.requireEventRole(h.SetEntryScore, staff...), nil},
+		{http.MethodDelete, "/games/{id}/queue/{entry_id}", "Remove an entry from the queue (operator)", "operator", "", "", http.StatusNoContent, h.requireEventRole(h.RemoveEntry, staff...), nil},
+		{http.MethodPost, "/games/{id}/tickets", "Issue a walk-in ticket (operator)", "operator", "TicketInfo", "Ticket", http.StatusCreated, h.requireEventRole(h.IssueTicket, staff...), nil},
+		{http.MethodPost, "/games/{id}/checkin", "Scan a check-in token at the stand (operator)", "operator", "CheckinInfo", "QueueEntry", http.StatusOK, h.requireEventRole(h.ScanCheckin, staff...), nil},
+		{http.MethodGet, "/games/{id}/stations", "List the stations of a game", "operator", "", "[]Station", http.StatusOK, h.requireEventRole(h.ListStations, staff...), nil},
+		{http.MethodPost, "/games/{id}/stations", "Add a station to a game (admin)", "admin", "StationInfo", "IdInfo", http.StatusCreated, h.requireEventRole(h.CreateStation, domain.RoleAdmin), nil},
+		{http.MethodPatch, "/games/{id}/stations/{station_id}", "Rename, enable or disable a station (operator)", "operator", "StationInfo", "", http.StatusNoContent, h.requireEventRole(h.UpdateStation, staff...), nil},
+		{http.MethodPut, "/games/{id}/hours", "Set the opening hours of a game (admin)", "admin", "Hours", "", http.StatusNoContent, h.requireEventRole(h.SetHours, domain.RoleAdmin), nil},
+		{http.MethodPost, "/games/{id}/pause", "Stop new players from joining (operator)", "operator", "", "", http.StatusNoContent, h.requireEventRole(h.setPaused(true), staff...), nil},
+		{http.MethodPost, "/games/{id}/resume", "Let new players join again (operator)", "operator", "", "", http.StatusNoContent, h.requireEventRole(h.setPaused(false), staff...), nil},
+		{http.MethodGet, "/games/{id}/reservation-policy", "Get the reservation policy of a game", "operator", "", "ReservationPolicy", http.StatusOK, h.requireEventRole(h.GetReservationPolicy, staff...), nil},
+		{http.MethodPut, "/games/{id}/reservation-policy", "Set the reservation policy of a game (admin)", "admin", "ReservationPolicy", "", http.StatusNoContent, h.requireEventRole(h.SetReservationPolicy, domain.RoleAdmin), nil},
+		{http.MethodGet, "/games/{id}/analytics", "Wait, throughput and no-show statistics of a game (admin)", "admin", "", "[]GameStats", http.StatusOK, h.requireEventRole(h.GetGameAnalytics, domain.RoleAdmin), analyticsParams},
+		{http.MethodGet, "/games/{id}/timeline", "Every queue event of a game (admin)", "admin", "", "QueueEventPage", http.StatusOK, h.requireEventRole(h.ListTimeline, domain.RoleAdmin), listParams},
+		{http.MethodGet, "/games/{id}/no-show-policy", "Get the no-show policy of a game", "operator", "", "NoShowPolicy", http.StatusOK, h.requireEventRole(h.GetNoShowPolicy, staff...), nil},
+		{http.MethodPut, "/games/{id}/no-show-policy", "Set the no-show policy of a game (admin)", "admin", "NoShowPolicy", "", http.StatusNoContent, h.requireEventRole(h.SetNoShowPolicy, domain.RoleAdmin), nil},
+		{http.MethodPut, "/games/{id}/queue/{entry_id}/priority", "Set the priority of an entry (operator)", "operator", "PriorityInfo", "PosInfo", http.StatusOK, h.requireEventRole(h.SetEntryPriority, staff...), nil},
+		{http.MethodGet, "/games/{id}/priority-policy", "Get the priority lane policy of a game", "operator", "", "PriorityPolicy", http.StatusOK, h.requireEventRole(h.GetPriorityPolicy, staff...), nil},
+		{http.MethodPut, "/games/{id}/priority-policy", "Set the priority lane policy of a game (admin)", "admin", "PriorityPolicy", "", http.StatusNoContent, h.requireEventRole(h.SetPriorityPolicy, domain.RoleAdmin), nil},
+		{http.MethodGet, "/games/{id}/scheduling-policy", "Get the scheduling policy of a game", "operator", "", "SchedulingPolicy", http.StatusOK, h.requireEventRole(h.GetSchedulingPolicy, staff...), nil},
+		{http.MethodPut, "/games/{id}/scheduling-policy", "Set the scheduling policy of a game (admin)", "admin", "SchedulingPolicy", "", http.StatusNoContent, h.requireEventRole(h.SetSchedulingPolicy, domain.RoleAdmin), nil},
+		{http.MethodPut, "/games/{id}/event", "Move a game to an event (admin)", "admin", "EventAssign", "", http.StatusNoContent, h.requireRole(h.SetGameEvent, domain.RoleAdmin), nil},
+		{http.MethodPost, "/games/{id}/queue/insert", "Insert a user at a position (operator)", "operator", "InsertInfo", "PosInfo", http.StatusCreated, h.requireEventRole(h.InsertPlayer, staff...), nil},
+
+		{http.MethodGet, "/games/{id}/scoring-policy", "Get the scoring policy of a game", "operator", "", "ScoringPolicy", http.StatusOK, h.requireEventRole(h.GetScoringPolicy, staff...), nil},
+		{http.MethodPut, "/games/{id}/scoring-policy", "Set the scoring policy of a game (admin)", "admin", "ScoringPolicy", "", http.StatusNoContent, h.requireEventRole(h.SetScoringPolicy, domain.RoleAdmin), nil},
+		{http.MethodPost, "/games/{id}/ratings", "Rate a finished session of a game", "ratings", "RatingInfo", "IdInfo", http.StatusCreated, h.RateGame, nil},
+		{http.MethodGet, "/games/{id}/ratings", "Ratings of a game; hidden comments are blanked", "ratings", "", "RatingPage", http.StatusOK, h.listRatings(false), ratingParams},
+		{http.MethodGet, "/games/{id}/ratings/moderation", "Ratings of a game including hidden comments (admin)", "admin", "", "RatingPage", http.StatusOK, h.requireEventRole(h.listRatings(true), domain.RoleAdmin), ratingParams},
+		{http.MethodPost, "/games/{id}/ratings/{rating_id}/hide", "Hide the comment of a rating (admin)", "admin", "", "", http.StatusNoContent, h.requireEventRole(h.setRatingHidden(true), domain.RoleAdmin), nil},
+		{http.MethodPost, "/games/{id}/ratings/{rating_id}/unhide", "Show a hidden comment again (admin)", "admin", "", "", http.StatusNoContent, h.requireEventRole(h.setRatingHidden(false), domain.RoleAdmin), nil},
+		{http.MethodGet, "/games/{id}/leaderboard", "Leaderboard of a game", "board", "", "Leaderboard", http.StatusOK, h.GetGameLeaderboard, []string{"top"}},
+		{http.MethodGet, "/games/{id}/leaderboard/stream", "Stream the leaderboard of a game", "board", "", "sse:Leaderboard", http.StatusOK, h.StreamGameLeaderboard, []string{"top"}},
+		{http.MethodGet, "/leaderboard", "Overall leaderboard of an event", "board", "", "Leaderboard", http.StatusOK, h.GetLeaderboard, []string{"top", "event_id"}},
+		{http.MethodGet, "/leaderboard/stream", "Stream the overall leaderboard", "board", "", "sse:Leaderboard", http.StatusOK, h.StreamLeaderboard, []string{"top", "event_id"}},
+		{http.MethodGet, "/board", "Display board of all stands", "board", "", "Board", http.StatusOK, h.GetBoard, []string{"next", "event_id"}},
+		{http.MethodGet, "/board/stream", "Stream the display board", "board", "", "sse:Board", http.StatusOK, h.StreamBoard, []string{"next", "event_id"}},
+
+		{http.MethodPost, "/parties", "Create a party", "parties", "PartyInfo", "Party", http.StatusCreated, h.CreateParty, nil},
+		{http.MethodPost, "/parties/join", "Join a party by its code", "parties", "PartyCode", "Party", http.StatusOK, h.JoinParty, nil},
+		{http.MethodGet, "/parties/{party_id}", "Get a party", "parties", "", "Party", http.StatusOK, h.GetParty, nil},
+		{http.MethodPost, "/parties/{party_id}/invites", "Invite a user by login (leader)", "parties", "PartyInvite", "", http.StatusNoContent, h.InviteToParty, nil},
+		{http.MethodPost, "/parties/{party_id}/accept", "Accept a party invite", "parties", "", "", http.StatusNoContent, h.AcceptPartyInvite, nil},
+		{http.MethodDelete, "/parties/{party_id}/members/me", "Leave a party", "parties", "", "", http.StatusNoContent, h.LeaveParty, nil},
+
+		{http.MethodGet, "/analytics", "Wait, throughput and no-show statistics of every game (admin)", "admin", "", "[]GameStats", http.StatusOK, h.requireRole(h.GetAnalytics, domain.RoleAdmin), []string{"from", "to", "bucket", "event_id"}},
+		{http.MethodGet, "/bans", "Bans in force (admin)", "admin", "", "[]Ban", http.StatusOK, h.requireRole(h.ListBans, domain.RoleAdmin), []string{"event_id"}},
+		{http.MethodPost, "/bans", "Ban a user globally or from one game (admin)", "admin", "BanInfo", "IdInfo", http.StatusCreated, h.requireRole(h.CreateBan, domain.RoleAdmin), nil},
+		{http.MethodDelete, "/bans/{ban_id}", "Lift a ban (admin)", "admin", "", "", http.StatusNoContent, h.requireRole(h.LiftBan, domain.RoleAdmin), nil},
+		{http.MethodGet, "/audit", "Query the audit log (admin)", "admin", "", "AuditRecordPage", http.StatusOK, h.requireRole(h.ListAudit, domain.RoleAdmin), append([]string{"limit", "cursor", "sort", "event_id"}, auditParams...)},
+		{http.MethodGet, "/audit/export", "Export the audit log (admin)", "admin", "", "export:AuditRecord", http.StatusOK, h.requireRole(h.ExportAudit, domain.RoleAdmin), append(append([]string{"event_id"}, exportParams...), auditParams...)},
+
+		{http.MethodGet, "/export/games", "Export every game (admin)", "admin", "", "export:Game", http.StatusOK, h.requireRole(h.ExportGames, domain.RoleAdmin), exportParams},
+		{http.MethodGet, "/export/users", "Export every user without password hashes (admin)", "admin", "", "export:UserRecord", http.StatusOK, h.requireRole(h.ExportUsers, domain.RoleAdmin), exportParams},
+		{http.MethodGet, "/export/queues", "Export the entries currently in play (admin)", "admin", "", "export:QueueEntry", http.StatusOK, h.requireRole(h.ExportQueues, domain.RoleAdmin), exportParams},
+		{http.MethodGet, "/export/history", "Export every queue entry ever made (admin)", "admin", "", "export:QueueEntry", http.StatusOK, h.requireRole(h.ExportHistory, domain.RoleAdmin), exportParams},
+		{http.MethodGet, "/export/events", "Export every queue event (admin)", "admin", "", "export:QueueEvent", http.StatusOK, h.requireRole(h.ExportEvents, domain.RoleAdmin), exportParams},
+
+		{http.MethodPost, "/import/games", "Upsert games by event and name from CSV or JSON (admin)", "admin", "import:GameImport", "ImportResult", http.StatusOK, h.requireRole(h.ImportGames, domain.RoleAdmin), importParams},
+		{http.MethodPost, "/import/users", "Upsert users by login from CSV or JSON (admin)", "admin", "import:UserImport", "ImportResult", http.StatusOK, h.requireRole(h.ImportUsers, domain.RoleAdmin), importParams},
+
+		{http.MethodGet, "/history", "The caller's queue history", "users", "", "HistoryEntryPage", http.StatusOK, h.ListHistory, scopedListParams},
+
+		{http.MethodGet, "/reservations", "List the caller's reservations", "reservations", "", "[]Reservation", http.StatusOK, h.ListReservations, []string{"event_id"}},
+		{http.MethodPut, "/reservations/{reservation_id}", "Move a reservation to another slot", "reservations", "ReservationInfo", "Reservation", http.StatusOK, h.RescheduleReservation, nil},
+		{http.MethodDelete, "/reservations/{reservation_id}", "Cancel a reservation", "reservations", "", "", http.StatusNoContent, h.CancelReservation, nil},
+
+		{http.MethodPost, "/tickets/claim", "Attach a walk-in ticket to the caller's account", "queue", "ClaimInfo", "QueueEntry", http.StatusOK, h.ClaimTicket, nil},
+
+		{http.MethodGet, "/users/{login}", "Resolve a user id by login", "users", "", "IdInfo", http.StatusOK, h.GetIdByLogin, nil},
+		{http.MethodPut, "/users/{login}/priority", "Set the priority tier of a user (admin)", "admin", "PriorityInfo", "", http.StatusNoContent, h.requireRole(h.SetUserPriority, domain.RoleAdmin), nil},
+		{http.MethodPut, "/users/{login}/group", "Set the scheduling group of a user (admin)", "admin", "GroupInfo", "", http.StatusNoContent, h.requireRole(h.SetUserGroup, domain.RoleAdmin), nil},
+		{http.MethodGet, "/users/{login}/games", "List games the user is queued for", "users", "", "GameInfoPage", http.StatusOK, h.ListUserGames, scopedListParams},
+
+		{http.MethodPost, "/auth/register", "Register a new user", "auth", "LoginInfo", "RoleInfo", http.StatusOK, h.Register, nil},
+		{http.MethodPost, "/auth/login", "Log in", "auth", "LoginInfo", "RoleInfo", http.StatusOK, h.LogIn, nil},
+	}
+}
+
+type legacyRoute struct {
+	method    string
+	path      string
+	successor string
+	handler   http.HandlerFunc
+}
+
+func (h *Handler) legacyRoutes() []legacyRoute {
+	return []legacyRoute{
+		{http.MethodGet, "/games", "/games", h.GetAllGames},
+		{http.MethodGet, "/games/{id}", "/games/{id}", h.GetGameInfoByID},
+		{http.MethodGet, "/queue/{login}", "/users/{login}/games", h.GetGamesByLogin},
+		{http.MethodGet, "/auth/{login}", "/users/{login}", h.GetIdByLogin},
+
+		{http.MethodPost, "/auth/register", "/auth/register", h.Register},
+		{http.MethodPost, "/auth/login", "/auth/login", h.LogIn},
+
+		{http.MethodDelete, "/remove", "/games/{id}/queue/me", h.RemovePlayerFromQueue},
+		{http.MethodPost, "/add", "/games/{id}/queue", h.AddPlayerToQueue},
+
+		{http.MethodGet, "/players/{id}", "/games/{id}/players", h.GetPlayersByGameID},
+	}
+}
@@ -0,0 +1,70 @@
+package rest
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) GetSchedulingPolicy(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("GetSchedulingPolicy error:", err)
+		return
+	}
+
+	policy, err := h.queuesService.GetSchedulingPolicy(r.Context(), gameID)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("GetSchedulingPolicy error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, policy)
+}
+
+func (h *Handler) SetSchedulingPolicy(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetSchedulingPolicy error:", err)
+		return
+	}
+
+	var policy domain.SchedulingPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetSchedulingPolicy error:", err)
+		return
+	}
+
+	if err := h.queuesService.SetSchedulingPolicy(r.Context(), actorFrom(r.Context()).ID, gameID, policy); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("SetSchedulingPolicy error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) SetUserGroup(w http.ResponseWriter, r *http.Request) {
+	var info domain.GroupInfo
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetUserGroup error:", err)
+		return
+	}
+
+	if err := h.queuesService.SetUserGroup(r.Context(), actorFrom(r.Context()).ID, mux.Vars(r)["login"], info.Group); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("SetUserGroup error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,211 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultLeaderboardTop = 10
+	maxLeaderboardTop     = 100
+)
+
+func leaderboardTop(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("top")
+	if v == "" {
+		return defaultLeaderboardTop, nil
+	}
+	top, err := strconv.Atoi(v)
+	if err != nil || top < 1 || top > maxLeaderboardTop {
+		return 0, fmt.Errorf("top must be between 1 and %d", maxLeaderboardTop)
+	}
+	return top, nil
+}
+
+func (h *Handler) SetEntryScore(w http.ResponseWriter, r *http.Request) {
+	gameID, entryID, err := entryVars(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetEntryScore error:", err)
+		return
+	}
+
+	var res domain.ResultInfo
+	if err := json.NewDecoder(r.Body).Decode(&res); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetEntryScore error:", err)
+		return
+	}
+
+	if err := h.queuesService.SetEntryScore(r.Context(), actorFrom(r.Context()).ID, gameID, entryID, res); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("SetEntryScore error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) GetScoringPolicy(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("GetScoringPolicy error:", err)
+		return
+	}
+
+	policy, err := h.queuesService.GetScoringPolicy(r.Context(), gameID)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("GetScoringPolicy error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, policy)
+}
+
+func (h *Handler) SetScoringPolicy(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetScoringPolicy error:", err)
+		return
+	}
+
+	var policy domain.ScoringPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("SetScoringPolicy error:", err)
+		return
+	}
+
+	if err := h.queuesService.SetScoringPolicy(r.Context(), actorFrom(r.Context()).ID, gameID, policy); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("SetScoringPolicy error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) GetGameLeaderboard(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("GetGameLeaderboard error:", err)
+		return
+	}
+
+	top, err := leaderboardTop(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("GetGameLeaderboard error:", err)
+		return
+	}
+
+	board, err := h.queuesService.GetGameLeaderboard(r.Context(), gameID, top)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("GetGameLeaderboard error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, board)
+}
+
+func (h *Handler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	top, err := leaderboardTop(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("GetLeaderboard error:", err)
+		return
+	}
+
+	eventID, err := h.activeEvent(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("GetLeaderboard error:", err)
+		return
+	}
+
+	board, err := h.queuesService.GetLeaderboard(r.Context(), eventID, top)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("GetLeaderboard error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, board)
+}
+
+func (h *Handler) StreamGameLeaderboard(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("StreamGameLeaderboard error:", err)
+		return
+	}
+
+	top, err := leaderboardTop(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("StreamGameLeaderboard error:", err)
+		return
+	}
+
+	// fail before the stream starts when the game does not exist
+	if _, err := h.queuesService.GetScoringPolicy(r.Context(), gameID); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("StreamGameLeaderboard error:", err)
+		return
+	}
+
+	updates, unsubscribe := h.queuesService.Subscribe(gameID)
+	defer unsubscribe()
+
+	streamLeaderboard(w, r, "StreamGameLeaderboard", updates, func() (*domain.Leaderboard, error) {
+		return h.queuesService.GetGameLeaderboard(r.Context(), gameID, top)
+	})
+}
+
+func (h *Handler) StreamLeaderboard(w http.ResponseWriter, r *http.Request) {
+	top, err := leaderboardTop(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("StreamLeaderboard error:", err)
+		return
+	}
+
+	eventID, err := h.activeEvent(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("StreamLeaderboard error:", err)
+		return
+	}
+
+	updates, unsubscribe := h.queuesService.SubscribeAll()
+	defer unsubscribe()
+
+	streamLeaderboard(w, r, "StreamLeaderboard", updates, func() (*domain.Leaderboard, error) {
+		return h.queuesService.GetLeaderboard(r.Context(), eventID, top)
+	})
+}
+
+// streamLeaderboard pushes the leaderboard when the stream opens and
+// again whenever a session is scored.
+func streamLeaderboard(w http.ResponseWriter, r *http.Request, name string, updates <-chan domain.QueueUpdate, load func() (*domain.Leaderboard, error)) {
+	streamSnapshots(w, r, name, snapshotStream{
+		event:   "leaderboard",
+		updates: updates,
+		relevant: func(update domain.QueueUpdate) bool {
+			return update.Type == "finished" || update.Type == "scored"
+		},
+		load: func() (any, error) { return load() },
+	})
+}
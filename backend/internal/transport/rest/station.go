@@ -0,0 +1,110 @@
+package rest
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/gorilla/mux"
+)
+
+// stationVars parses the {id} and {station_id} path variables.
+func stationVars(r *http.Request) (int, int, error) {
+	vars := mux.Vars(r)
+	gameID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		return 0, 0, err
+	}
+	stationID, err := strconv.Atoi(vars["station_id"])
+	if err != nil {
+		return 0, 0, err
+	}
+	return gameID, stationID, nil
+}
+
+func (h *Handler) ListStations(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("ListStations error:", err)
+		return
+	}
+
+	stations, err := h.queuesService.GetStations(r.Context(), gameID)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ListStations error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stations)
+}
+
+func (h *Handler) CreateStation(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("CreateStation error:", err)
+		return
+	}
+
+	var info domain.StationInfo
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("CreateStation error:", err)
+		return
+	}
+
+	stationID, err := h.queuesService.CreateStation(r.Context(), actorFrom(r.Context()).ID, gameID, info)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("CreateStation error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, domain.IdInfo{Id: stationID})
+}
+
+func (h *Handler) UpdateStation(w http.ResponseWriter, r *http.Request) {
+	gameID, stationID, err := stationVars(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("UpdateStation error:", err)
+		return
+	}
+
+	var info domain.StationInfo
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("UpdateStation error:", err)
+		return
+	}
+
+	if err := h.queuesService.UpdateStation(r.Context(), actorFrom(r.Context()).ID, gameID, stationID, info); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("UpdateStation error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) CallNextToStation(w http.ResponseWriter, r *http.Request) {
+	gameID, stationID, err := stationVars(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("CallNextToStation error:", err)
+		return
+	}
+
+	entryID, err := h.queuesService.CallNext(r.Context(), actorFrom(r.Context()).ID, gameID, stationID)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("CallNextToStation error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, domain.IdInfo{Id: entryID})
+}
@@ -0,0 +1,150 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/gorilla/mux"
+)
+
+const (
+	keepAliveInterval = 25 * time.Second
+	// a burst of updates within this time is pushed as one snapshot
+	snapshotDebounce = 500 * time.Millisecond
+)
+
+// startStream opens a server-sent events response, or answers 500 when
+// the connection cannot stream.
+func startStream(w http.ResponseWriter, name string) (http.Flusher, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		log.Println(name, "error: streaming unsupported")
+		return nil, false
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	return flusher, true
+}
+
+// snapshotStream describes a stream that sends a whole snapshot, such as
+// the board, rather than the individual updates.
+type snapshotStream struct {
+	event   string // SSE event name of a snapshot
+	updates <-chan domain.QueueUpdate
+	// relevant picks the updates that call for a new snapshot; nil takes
+	// every update
+	relevant func(domain.QueueUpdate) bool
+	// refresh sends a snapshot this often even without updates, e.g. to
+	// keep countdowns current; 0 only sends keep-alive comments
+	refresh time.Duration
+	load    func() (any, error)
+}
+
+// streamSnapshots sends a snapshot when the stream opens and again after
+// relevant updates, coalescing a burst of them into one push.
+func streamSnapshots(w http.ResponseWriter, r *http.Request, name string, s snapshotStream) {
+	flusher, ok := startStream(w, name)
+	if !ok {
+		return
+	}
+
+	push := func() bool {
+		v, err := s.load()
+		if err != nil {
+			log.Println(name, "error:", err)
+			return false
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			log.Println(name, "error:", err)
+			return false
+		}
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", s.event, data)
+		flusher.Flush()
+		return true
+	}
+
+	if !push() {
+		return
+	}
+
+	interval := keepAliveInterval
+	if s.refresh > 0 {
+		interval = s.refresh
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case update := <-s.updates:
+			if s.relevant != nil && !s.relevant(update) {
+				continue
+			}
+			if debounce == nil {
+				debounce = time.After(snapshotDebounce)
+			}
+		case <-debounce:
+			debounce = nil
+			push()
+		case <-ticker.C:
+			if s.refresh > 0 {
+				push()
+				continue
+			}
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+// StreamQueue pushes updates of a game's queue as server-sent events.
+func (h *Handler) StreamQueue(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("StreamQueue error:", err)
+		return
+	}
+
+	updates, unsubscribe := h.queuesService.Subscribe(gameID)
+	defer unsubscribe()
+
+	flusher, ok := startStream(w, "StreamQueue")
+	if !ok {
+		return
+	}
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case update := <-updates:
+			data, err := json.Marshal(update)
+			if err != nil {
+				log.Println("StreamQueue error:", err)
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", update.Type, data)
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,75 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+)
+
+// syncRecorder is a ResponseRecorder safe to read while a stream writes.
+type syncRecorder struct {
+	mu sync.Mutex
+	*httptest.ResponseRecorder
+}
+
+func (r *syncRecorder) Write(b []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ResponseRecorder.Write(b)
+}
+
+func (r *syncRecorder) body() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.Body.String()
+}
+
+func TestStreamSnapshots(t *testing.T) {
+	updates := make(chan domain.QueueUpdate)
+	var loads atomic.Int32
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest(http.MethodGet, "/stream", nil).WithContext(ctx)
+	w := &syncRecorder{ResponseRecorder: httptest.NewRecorder()}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		streamSnapshots(w, r, "TestStream", snapshotStream{
+			event:   "snap",
+			updates: updates,
+			relevant: func(update domain.QueueUpdate) bool {
+				return update.Type == "scored"
+			},
+			load: func() (any, error) {
+				return map[string]int32{"n": loads.Add(1)}, nil
+			},
+		})
+	}()
+
+	// ignored updates, then a burst that is pushed once
+	updates <- domain.QueueUpdate{Type: "joined"}
+	for range 5 {
+		updates <- domain.QueueUpdate{Type: "scored"}
+	}
+	time.Sleep(snapshotDebounce + 200*time.Millisecond)
+	cancel()
+	<-done
+
+	if got := w.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("content type = %q", got)
+	}
+	want := "event: snap\ndata: {\"n\":1}\n\nevent: snap\ndata: {\"n\":2}\n\n"
+	if got := w.body(); got != want {
+		t.Fatalf("stream = %q, want %q", got, want)
+	}
+	if n := loads.Load(); n != 2 {
+		t.Fatalf("loaded %d times, want 2", n)
+	}
+}
@@ -0,0 +1,78 @@
+package rest
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/DexScen/Queue/backend/internal/domain"
+	"github.com/gorilla/mux"
+)
+
+func (h *Handler) IssueTicket(w http.ResponseWriter, r *http.Request) {
+	gameID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("IssueTicket error:", err)
+		return
+	}
+
+	var info domain.TicketInfo
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("IssueTicket error:", err)
+		return
+	}
+
+	ticket, err := h.queuesService.IssueTicket(r.Context(), actorFrom(r.Context()).ID, gameID, info)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("IssueTicket error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, ticket)
+}
+
+func (h *Handler) ClaimTicket(w http.ResponseWriter, r *http.Request) {
+	userID, err := h.currentUserID(r)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ClaimTicket error:", err)
+		return
+	}
+
+	var info domain.ClaimInfo
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("ClaimTicket error:", err)
+		return
+	}
+
+	entry, err := h.queuesService.ClaimTicket(r.Context(), userID, info)
+	if err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("ClaimTicket error:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entry)
+}
+
+func (h *Handler) RemoveEntry(w http.ResponseWriter, r *http.Request) {
+	gameID, entryID, err := entryVars(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		log.Println("RemoveEntry error:", err)
+		return
+	}
+
+	if err := h.queuesService.RemoveEntry(r.Context(), actorFrom(r.Context()).ID, gameID, entryID); err != nil {
+		w.WriteHeader(errorStatus(err))
+		log.Println("RemoveEntry error:", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,45 @@
+// Package requestid carries the id of the HTTP request being served
+// through a context, so that records written on its behalf can be traced
+// back to it.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+type (
+	ctxKey       struct{}
+	clientCtxKey struct{}
+)
+
+// New returns a random 32-character hex id.
+func New() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// From returns the request id of ctx, or "" outside of a request.
+func From(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// WithClient records the id the client or a proxy sent along with the
+// request. It is only kept for reference and never replaces the request
+// id, which the server always generates itself.
+func WithClient(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, clientCtxKey{}, id)
+}
+
+// ClientFrom returns the id the client sent, or "".
+func ClientFrom(ctx context.Context) string {
+	id, _ := ctx.Value(clientCtxKey{}).(string)
+	return id
+}
@@ -63,7 +63,10 @@ document.addEventListener("DOMContentLoaded", async () => {
     try {
       const resp = await fetch("http://localhost:8080/remove", {
         method: "DELETE",
-        headers: { "Content-Type": "application/json" },
+        headers: {
+          "Content-Type": "application/json",
+          "Authorization": `Bearer ${localStorage.getItem("token")}`
+        },
         body: JSON.stringify({ user_id: Number(userId), game_id: gameId })
       });
 
@@ -31,6 +31,7 @@ form.addEventListener('submit', async (e) => {
         if (answer.role === 'user') {
             console.log("успешный вход");
             localStorage.setItem('username', login);
+            localStorage.setItem('token', answer.token);
             window.location.href = '/stands/';
         } else {
             if (answer.role === 'user not found') {
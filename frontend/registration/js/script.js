@@ -41,6 +41,7 @@ form.addEventListener('submit', async(e) => {
             if (answer.role === 'user') {
                 console.log("регистрация прошла успешно, пользователя еще нет в системе.");
                 localStorage.setItem('username', login);
+                localStorage.setItem('token', answer.token);
                 window.location.href = '../stands/index.html';
             } else if (answer.role === 'user exists') {
                 console.log("пользователь уже существует.");
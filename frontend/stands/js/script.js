@@ -117,7 +117,8 @@ async function signUpForStand(standId) {
         const signupResponse = await fetch(`http://localhost:8080/add`, {
             method: 'POST',
             headers: {
-                'Content-Type': 'application/json'
+                'Content-Type': 'application/json',
+                'Authorization': `Bearer ${localStorage.getItem('token')}`
             },
             body: JSON.stringify({
                 user_id: userId,
@@ -153,7 +153,8 @@ function renderQueues(queues) {
                 const response = await fetch('http://localhost:8080/remove', {
                     method: 'DELETE',
                     headers: {
-                        'Content-Type': 'application/json'
+                        'Content-Type': 'application/json',
+                        'Authorization': `Bearer ${localStorage.getItem('token')}`
                     },
                     body: JSON.stringify({
                         user_id: userID,